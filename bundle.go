@@ -0,0 +1,261 @@
+package smapi
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"slices"
+	"sort"
+	"strconv"
+	"time"
+
+	"github.com/grafana/synthetic-monitoring-api-go-client/model"
+
+	"github.com/grafana/synthetic-monitoring-agent/pkg/pb/synthetic_monitoring"
+)
+
+var (
+	// ErrCheckExists is reported when importing a check with the same
+	// job and target as an existing one.
+	ErrCheckExists = errors.New("check already exists")
+
+	// ErrNoProbes is reported when importing a check none of whose
+	// probes exist in the destination tenant.
+	ErrNoProbes = errors.New("no probes found")
+)
+
+// ExportBundle returns the configuration of the authenticated tenant:
+// its settings, its probes and its checks, with their alerts.
+func (h *Client) ExportBundle(ctx context.Context) (*model.Bundle, error) {
+	tenant, err := h.GetTenant(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	probes, err := h.ListProbes(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	checks, err := h.ListChecksWithAlerts(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	bundle := model.Bundle{
+		Version: model.BundleVersion,
+		Created: time.Now().Unix(),
+		Tenant: model.BundleTenant{
+			CostAttributionLabels: tenant.CostAttributionLabels,
+			LabelMode:             tenant.LabelMode,
+		},
+		Probes: make([]model.BundleProbe, 0, len(probes)),
+		Checks: make([]model.BundleCheck, 0, len(checks)),
+	}
+
+	probeNames := make(map[int64]string, len(probes))
+
+	for _, p := range probes {
+		probeNames[p.Id] = p.Name
+
+		bundle.Probes = append(bundle.Probes, model.BundleProbe{
+			Name:         p.Name,
+			Public:       p.Public,
+			Latitude:     p.Latitude,
+			Longitude:    p.Longitude,
+			Region:       p.Region,
+			Labels:       p.Labels,
+			Capabilities: p.Capabilities,
+		})
+	}
+
+	for _, c := range checks {
+		check := model.BundleCheck{
+			Check:      c.Check,
+			ProbeNames: make([]string, 0, len(c.Probes)),
+		}
+
+		check.TenantId = 0
+		check.Check.Check.Probes = nil
+
+		for _, id := range c.Probes {
+			name, found := probeNames[id]
+			if !found {
+				// Keep the ID, so that the probe is reported as
+				// unmapped when importing the bundle.
+				name = strconv.FormatInt(id, 10)
+			}
+
+			check.ProbeNames = append(check.ProbeNames, name)
+		}
+
+		for _, alert := range c.Alerts {
+			alert.Created = 0
+			alert.Modified = 0
+			check.Alerts = append(check.Alerts, alert.CheckAlert)
+		}
+
+		bundle.Checks = append(bundle.Checks, check)
+	}
+
+	sort.Slice(bundle.Probes, func(i, j int) bool { return bundle.Probes[i].Name < bundle.Probes[j].Name })
+	sort.Slice(bundle.Checks, func(i, j int) bool { return bundle.Checks[i].Id < bundle.Checks[j].Id })
+
+	return &bundle, nil
+}
+
+// ImportBundle recreates the configuration in bundle in the authenticated
+// tenant.
+//
+// The settings of the tenant are updated. Private probes are created,
+// unless a probe with the same name exists. Checks are created with the
+// probes that have the same names in the tenant, along with their alerts.
+//
+// Problems with individual checks, like existing checks with the same job
+// and target or probes that cannot be found, are recorded in the returned
+// report instead of stopping the import. In case of error, the report
+// describes what was imported until then.
+func (h *Client) ImportBundle(ctx context.Context, bundle model.Bundle) (*model.BundleImportReport, error) {
+	var report model.BundleImportReport
+
+	if err := bundle.Validate(); err != nil {
+		return nil, err
+	}
+
+	tenant, err := h.GetTenant(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	if !slices.Equal(tenant.CostAttributionLabels, bundle.Tenant.CostAttributionLabels) || tenant.LabelMode != bundle.Tenant.LabelMode {
+		tenant.CostAttributionLabels = bundle.Tenant.CostAttributionLabels
+		tenant.LabelMode = bundle.Tenant.LabelMode
+
+		if _, err := h.UpdateTenant(ctx, *tenant); err != nil {
+			return &report, err
+		}
+
+		report.TenantUpdated = true
+	}
+
+	probes, err := h.ListProbes(ctx)
+	if err != nil {
+		return &report, err
+	}
+
+	probeIDs := make(map[string]int64, len(probes))
+	for _, p := range probes {
+		probeIDs[p.Name] = p.Id
+	}
+
+	for _, p := range bundle.Probes {
+		if p.Public {
+			continue
+		}
+
+		if id, found := probeIDs[p.Name]; found {
+			report.Probes = append(report.Probes, model.BundleProbeImport{Name: p.Name, ID: id})
+			continue
+		}
+
+		newProbe, token, err := h.AddProbe(ctx, synthetic_monitoring.Probe{
+			Name:         p.Name,
+			Latitude:     p.Latitude,
+			Longitude:    p.Longitude,
+			Region:       p.Region,
+			Labels:       p.Labels,
+			Capabilities: p.Capabilities,
+		})
+		if err != nil {
+			return &report, fmt.Errorf("creating probe %q: %w", p.Name, err)
+		}
+
+		probeIDs[p.Name] = newProbe.Id
+
+		report.Probes = append(report.Probes, model.BundleProbeImport{
+			Name:    p.Name,
+			ID:      newProbe.Id,
+			Created: true,
+			Token:   token,
+		})
+	}
+
+	report.Checks, err = h.importChecks(ctx, tenant.Id, bundle.Checks, probeIDs)
+	if err != nil {
+		return &report, err
+	}
+
+	return &report, nil
+}
+
+// importChecks creates checks in the tenant identified by tenantID, using
+// probeIDs to map probe names to IDs. It returns the result of importing
+// each check.
+func (h *Client) importChecks(ctx context.Context, tenantID int64, checks []model.BundleCheck, probeIDs map[string]int64) ([]model.BundleCheckImport, error) {
+	existing, err := h.ListChecks(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	type checkKey struct{ job, target string }
+
+	existingChecks := make(map[checkKey]struct{}, len(existing))
+	for _, c := range existing {
+		existingChecks[checkKey{c.Job, c.Target}] = struct{}{}
+	}
+
+	results := make([]model.BundleCheckImport, 0, len(checks))
+
+	for _, c := range checks {
+		result := model.BundleCheckImport{Job: c.Job, Target: c.Target, SourceID: c.Id}
+
+		if _, found := existingChecks[checkKey{c.Job, c.Target}]; found {
+			result.Error = ErrCheckExists.Error()
+			results = append(results, result)
+
+			continue
+		}
+
+		check := c.Check
+		check.Id = 0
+		check.TenantId = tenantID
+		check.Created = 0
+		check.Modified = 0
+		check.Probes = make([]int64, 0, len(c.ProbeNames))
+
+		for _, name := range c.ProbeNames {
+			if id, found := probeIDs[name]; found {
+				check.Probes = append(check.Probes, id)
+			} else {
+				result.UnmappedProbes = append(result.UnmappedProbes, name)
+			}
+		}
+
+		result.ID, result.Error = h.importCheck(ctx, check, c.Alerts)
+		results = append(results, result)
+	}
+
+	return results, nil
+}
+
+// importCheck creates check and its alerts. It returns the ID of the new
+// check, or 0 if it was not created, and a description of the error, if
+// any.
+func (h *Client) importCheck(ctx context.Context, check model.Check, alerts []model.CheckAlert) (int64, string) {
+	if len(check.Probes) == 0 {
+		return 0, ErrNoProbes.Error()
+	}
+
+	newCheck, err := h.AddCheck(ctx, check)
+	if err != nil {
+		return 0, err.Error()
+	}
+
+	if len(alerts) > 0 {
+		if _, err := h.UpdateCheckAlerts(ctx, newCheck.Id, alerts); err != nil {
+			return newCheck.Id, fmt.Sprintf("setting alerts: %s", err)
+		}
+	}
+
+	return newCheck.Id, ""
+}
@@ -0,0 +1,209 @@
+package smapi
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"testing"
+	"time"
+
+	"github.com/grafana/synthetic-monitoring-agent/pkg/pb/synthetic_monitoring"
+	"github.com/grafana/synthetic-monitoring-api-go-client/model"
+	"github.com/stretchr/testify/require"
+)
+
+func TestExportBundle(t *testing.T) {
+	url, mux, cleanup := newTestServer(t)
+	defer cleanup()
+
+	mux.Handle("/api/v1/tenant", http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		writeResponse(w, http.StatusOK, synthetic_monitoring.Tenant{
+			Id:                    1,
+			CostAttributionLabels: []string{"team"},
+			LabelMode:             synthetic_monitoring.LabelMode_LABEL_MODE_UNPREFIXED,
+		})
+	}))
+
+	mux.Handle("/api/v1/probe/list", http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		writeResponse(w, http.StatusOK, []synthetic_monitoring.Probe{
+			{Id: 2, TenantId: 1, Name: "office", Region: "EMEA", Latitude: 51.5},
+			{Id: 1, Name: "Atlanta", Region: "AMER", Public: true},
+		})
+	}))
+
+	mux.Handle("/api/v1/check/list", http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		require.Equal(t, "true", r.URL.Query().Get("includeAlerts"))
+
+		writeResponse(w, http.StatusOK, []model.CheckWithAlerts{
+			{
+				Check: model.Check{Check: synthetic_monitoring.Check{
+					Id:       10,
+					TenantId: 1,
+					Job:      "job",
+					Target:   "target",
+					Probes:   []int64{1, 2, 3},
+				}},
+				Alerts: []model.CheckAlertWithStatus{
+					{
+						CheckAlert: model.CheckAlert{Name: model.AlertProbeFailedExecutionsTooHigh, Threshold: 2, Created: 100},
+						Status:     "OK",
+					},
+				},
+			},
+		})
+	}))
+
+	c := NewClient(url, "token", http.DefaultClient)
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	bundle, err := c.ExportBundle(ctx)
+	require.NoError(t, err)
+	require.Equal(t, model.BundleVersion, bundle.Version)
+	require.NotZero(t, bundle.Created)
+
+	require.Equal(t, model.BundleTenant{
+		CostAttributionLabels: []string{"team"},
+		LabelMode:             synthetic_monitoring.LabelMode_LABEL_MODE_UNPREFIXED,
+	}, bundle.Tenant)
+
+	require.Equal(t, []model.BundleProbe{
+		{Name: "Atlanta", Region: "AMER", Public: true},
+		{Name: "office", Region: "EMEA", Latitude: 51.5},
+	}, bundle.Probes)
+
+	require.Len(t, bundle.Checks, 1)
+	require.Equal(t, int64(10), bundle.Checks[0].Id)
+	require.Zero(t, bundle.Checks[0].TenantId)
+	require.Equal(t, []string{"Atlanta", "office", "3"}, bundle.Checks[0].ProbeNames)
+	require.Equal(t, []model.CheckAlert{{Name: model.AlertProbeFailedExecutionsTooHigh, Threshold: 2}}, bundle.Checks[0].Alerts)
+
+	// Probe names replace the IDs in the JSON representation.
+	buf, err := json.Marshal(bundle.Checks[0])
+	require.NoError(t, err)
+	require.Contains(t, string(buf), `"probes":["Atlanta","office","3"]`)
+}
+
+func TestImportBundle(t *testing.T) {
+	url, mux, cleanup := newTestServer(t)
+	defer cleanup()
+
+	var (
+		updatedTenant synthetic_monitoring.Tenant
+		addedProbes   []synthetic_monitoring.Probe
+		addedChecks   []model.Check
+		alerts        = make(map[int64][]model.CheckAlert)
+	)
+
+	mux.Handle("/api/v1/tenant", http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		writeResponse(w, http.StatusOK, synthetic_monitoring.Tenant{Id: 5})
+	}))
+
+	mux.Handle("/api/v1/tenant/update", http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		require.NoError(t, json.NewDecoder(r.Body).Decode(&updatedTenant))
+		writeResponse(w, http.StatusOK, updatedTenant)
+	}))
+
+	mux.Handle("/api/v1/probe/list", http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		writeResponse(w, http.StatusOK, []synthetic_monitoring.Probe{
+			{Id: 20, Name: "Atlanta", Public: true},
+			{Id: 21, Name: "lab"},
+		})
+	}))
+
+	mux.Handle("/api/v1/probe/add", http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var probe synthetic_monitoring.Probe
+		require.NoError(t, json.NewDecoder(r.Body).Decode(&probe))
+
+		probe.Id = int64(30 + len(addedProbes))
+		addedProbes = append(addedProbes, probe)
+
+		writeResponse(w, http.StatusOK, model.ProbeAddResponse{Probe: probe, Token: []byte("secret")})
+	}))
+
+	mux.Handle("/api/v1/check/list", http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		writeResponse(w, http.StatusOK, []model.Check{
+			{Check: synthetic_monitoring.Check{Id: 40, Job: "existing", Target: "target"}},
+		})
+	}))
+
+	mux.Handle("/api/v1/check/add", http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var check model.Check
+		require.NoError(t, json.NewDecoder(r.Body).Decode(&check))
+
+		check.Id = int64(50 + len(addedChecks))
+		addedChecks = append(addedChecks, check)
+
+		writeResponse(w, http.StatusOK, check)
+	}))
+
+	mux.Handle("/api/v1/check/50/alerts", http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var req struct {
+			Alerts []model.CheckAlert `json:"alerts"`
+		}
+		require.NoError(t, json.NewDecoder(r.Body).Decode(&req))
+
+		alerts[50] = req.Alerts
+
+		writeResponse(w, http.StatusOK, req)
+	}))
+
+	bundle := model.Bundle{
+		Version: model.BundleVersion,
+		Tenant:  model.BundleTenant{CostAttributionLabels: []string{"team"}},
+		Probes: []model.BundleProbe{
+			{Name: "Atlanta", Public: true},
+			{Name: "lab"},
+			{Name: "office", Region: "EMEA"},
+		},
+		Checks: []model.BundleCheck{
+			{
+				Check:      model.Check{Check: synthetic_monitoring.Check{Id: 1, Job: "j1", Target: "t1"}},
+				ProbeNames: []string{"Atlanta", "office", "Paris"},
+				Alerts:     []model.CheckAlert{{Name: model.AlertProbeFailedExecutionsTooHigh, Threshold: 2}},
+			},
+			{
+				Check:      model.Check{Check: synthetic_monitoring.Check{Id: 2, Job: "existing", Target: "target"}},
+				ProbeNames: []string{"Atlanta"},
+			},
+			{
+				Check:      model.Check{Check: synthetic_monitoring.Check{Id: 3, Job: "j3", Target: "t3"}},
+				ProbeNames: []string{"Paris"},
+			},
+		},
+	}
+
+	c := NewClient(url, "token", http.DefaultClient)
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	report, err := c.ImportBundle(ctx, bundle)
+	require.NoError(t, err)
+
+	require.Equal(t, &model.BundleImportReport{
+		TenantUpdated: true,
+		Probes: []model.BundleProbeImport{
+			{Name: "lab", ID: 21},
+			{Name: "office", ID: 30, Created: true, Token: []byte("secret")},
+		},
+		Checks: []model.BundleCheckImport{
+			{Job: "j1", Target: "t1", SourceID: 1, ID: 50, UnmappedProbes: []string{"Paris"}},
+			{Job: "existing", Target: "target", SourceID: 2, Error: ErrCheckExists.Error()},
+			{Job: "j3", Target: "t3", SourceID: 3, UnmappedProbes: []string{"Paris"}, Error: ErrNoProbes.Error()},
+		},
+	}, report)
+
+	require.Equal(t, []string{"team"}, updatedTenant.CostAttributionLabels)
+	require.Len(t, addedProbes, 1)
+	require.Equal(t, "EMEA", addedProbes[0].Region)
+	require.Len(t, addedChecks, 1)
+	require.Equal(t, int64(5), addedChecks[0].TenantId)
+	require.Equal(t, []int64{20, 30}, addedChecks[0].Probes)
+	require.Equal(t, bundle.Checks[0].Alerts, alerts[50])
+
+	bundle.Version = 2
+	_, err = c.ImportBundle(ctx, bundle)
+	require.ErrorIs(t, err, model.ErrUnsupportedBundleVersion)
+}
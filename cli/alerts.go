@@ -0,0 +1,223 @@
+package cli
+
+import (
+	"errors"
+	"fmt"
+	"slices"
+	"strings"
+
+	"github.com/grafana/synthetic-monitoring-api-go-client/model"
+	"github.com/urfave/cli/v2"
+)
+
+var errAlertNotFound = errors.New("alert not found")
+
+func getCheckAlertCommands(cc ChecksClient) *cli.Command {
+	checkIDFlag := func() cli.Flag {
+		return &cli.Int64Flag{
+			Name:     "id",
+			Usage:    "id of the check",
+			Required: true,
+		}
+	}
+
+	return &cli.Command{
+		Name:  "alerts",
+		Usage: "manage the alerts of a Synthetic Monitoring check",
+		Subcommands: []*cli.Command{
+			{
+				Name:   "get",
+				Usage:  "get the alerts of a check",
+				Action: cc.checkAlertsGet,
+				Flags:  []cli.Flag{checkIDFlag()},
+			},
+			{
+				Name:   "set",
+				Usage:  "replace all the alerts of a check",
+				Action: cc.checkAlertsSet,
+				Flags: []cli.Flag{
+					checkIDFlag(),
+					&cli.StringFlag{
+						Name:     "alerts",
+						Usage:    `JSON list of alerts, e.g. '[{"name":"ProbeFailedExecutionsTooHigh","threshold":3,"period":"5m"}]', use @filename to read it from a file`,
+						Required: true,
+					},
+				},
+			},
+			{
+				Name:   "add",
+				Usage:  "add an alert to a check, replacing the existing one with the same name",
+				Action: cc.checkAlertsAdd,
+				Flags: []cli.Flag{
+					checkIDFlag(),
+					&cli.StringFlag{
+						Name:     "name",
+						Usage:    "name of the alert, one of " + strings.Join(model.AlertNames(), ", "),
+						Required: true,
+					},
+					&cli.Float64Flag{
+						Name:     "threshold",
+						Usage:    "threshold of the alert",
+						Required: true,
+					},
+					&cli.StringFlag{
+						Name:  "period",
+						Usage: "period over which the alert is evaluated, one of " + strings.Join(model.AlertPeriods(), ", "),
+					},
+					&cli.StringFlag{
+						Name:  "runbook-url",
+						Usage: "URL of the runbook for the alert",
+					},
+				},
+			},
+			{
+				Name:   "remove",
+				Usage:  "remove alerts from a check",
+				Action: cc.checkAlertsRemove,
+				Flags: []cli.Flag{
+					checkIDFlag(),
+					&cli.StringSliceFlag{
+						Name:     "name",
+						Usage:    "name of the alert to remove (can be repeated)",
+						Required: true,
+					},
+				},
+			},
+		},
+	}
+}
+
+func (c ChecksClient) checkAlertsGet(ctx *cli.Context) error {
+	smClient, cleanup, err := c.ClientBuilder(ctx)
+	if err != nil {
+		return err
+	}
+	defer func() { _ = cleanup(ctx.Context) }()
+
+	alerts, err := smClient.GetCheckAlerts(ctx.Context, ctx.Int64("id"))
+	if err != nil {
+		return fmt.Errorf("getting check alerts: %w", err)
+	}
+
+	return c.showAlerts(ctx, alerts)
+}
+
+func (c ChecksClient) checkAlertsSet(ctx *cli.Context) error {
+	var alerts []model.CheckAlert
+
+	if err := readJsonArg(ctx.String("alerts"), &alerts); err != nil {
+		return fmt.Errorf("reading alerts: %w", err)
+	}
+
+	return c.updateAlerts(ctx, func([]model.CheckAlertWithStatus) ([]model.CheckAlert, error) {
+		return alerts, nil
+	})
+}
+
+func (c ChecksClient) checkAlertsAdd(ctx *cli.Context) error {
+	alert := model.CheckAlert{
+		Name:       ctx.String("name"),
+		Threshold:  ctx.Float64("threshold"),
+		Period:     ctx.String("period"),
+		RunbookUrl: ctx.String("runbook-url"),
+	}
+
+	return c.updateAlerts(ctx, func(current []model.CheckAlertWithStatus) ([]model.CheckAlert, error) {
+		alerts := make([]model.CheckAlert, 0, len(current)+1)
+
+		for _, a := range current {
+			if a.Name != alert.Name {
+				alerts = append(alerts, a.CheckAlert)
+			}
+		}
+
+		return append(alerts, alert), nil
+	})
+}
+
+func (c ChecksClient) checkAlertsRemove(ctx *cli.Context) error {
+	names := ctx.StringSlice("name")
+
+	return c.updateAlerts(ctx, func(current []model.CheckAlertWithStatus) ([]model.CheckAlert, error) {
+		alerts := make([]model.CheckAlert, 0, len(current))
+		removed := make(map[string]struct{}, len(names))
+
+		for _, a := range current {
+			if slices.Contains(names, a.Name) {
+				removed[a.Name] = struct{}{}
+				continue
+			}
+
+			alerts = append(alerts, a.CheckAlert)
+		}
+
+		for _, name := range names {
+			if _, found := removed[name]; !found {
+				return nil, fmt.Errorf("%s: %w", name, errAlertNotFound)
+			}
+		}
+
+		return alerts, nil
+	})
+}
+
+// updateAlerts replaces the alerts of the check with the ones returned
+// by update, which receives the current ones. The alerts are validated
+// before sending them.
+func (c ChecksClient) updateAlerts(ctx *cli.Context, update func([]model.CheckAlertWithStatus) ([]model.CheckAlert, error)) error {
+	smClient, cleanup, err := c.ClientBuilder(ctx)
+	if err != nil {
+		return err
+	}
+	defer func() { _ = cleanup(ctx.Context) }()
+
+	checkID := ctx.Int64("id")
+
+	current, err := smClient.GetCheckAlerts(ctx.Context, checkID)
+	if err != nil {
+		return fmt.Errorf("getting check alerts: %w", err)
+	}
+
+	alerts, err := update(current)
+	if err != nil {
+		return err
+	}
+
+	for _, alert := range alerts {
+		if err := alert.Validate(); err != nil {
+			return fmt.Errorf("invalid alert: %w", err)
+		}
+	}
+
+	if _, err := smClient.UpdateCheckAlerts(ctx.Context, checkID, alerts); err != nil {
+		return fmt.Errorf("updating check alerts: %w", err)
+	}
+
+	// Get the alerts again in order to show their status.
+	updated, err := smClient.GetCheckAlerts(ctx.Context, checkID)
+	if err != nil {
+		return fmt.Errorf("getting check alerts: %w", err)
+	}
+
+	return c.showAlerts(ctx, updated)
+}
+
+func (c ChecksClient) showAlerts(ctx *cli.Context, alerts []model.CheckAlertWithStatus) error {
+	jsonWriter := c.JsonWriterBuilder(ctx)
+
+	if done, err := jsonWriter(alerts, "marshaling alerts"); err != nil || done {
+		return err
+	}
+
+	w := c.TabWriterBuilder(ctx)
+	fmt.Fprintf(w, "%s\t%s\t%s\t%s\t%s\t%s\n", "name", "threshold", "period", "runbook", "status", "error")
+	for _, alert := range alerts {
+		fmt.Fprintf(w, "%s\t%.2f\t%s\t%s\t%s\t%s\n", alert.Name, alert.Threshold, alert.Period, alert.RunbookUrl, alert.Status, alert.Error)
+	}
+
+	if err := w.Flush(); err != nil {
+		return fmt.Errorf("flushing output: %w", err)
+	}
+
+	return nil
+}
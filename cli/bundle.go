@@ -0,0 +1,297 @@
+package cli
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"strings"
+
+	smapi "github.com/grafana/synthetic-monitoring-api-go-client"
+	"github.com/grafana/synthetic-monitoring-api-go-client/model"
+	"github.com/urfave/cli/v2"
+)
+
+var (
+	errIncompleteImport = errors.New("some checks could not be imported")
+	errMissingProbes    = errors.New("some checks were imported without some of their probes, use --allow-missing-probes to accept this")
+	errInvalidProbeMap  = errors.New("invalid probe map")
+)
+
+func getAllowMissingProbesFlag() cli.Flag {
+	return &cli.BoolFlag{
+		Name:  "allow-missing-probes",
+		Usage: "don't fail when checks are imported without the probes that cannot be found",
+	}
+}
+
+func GetBundleCommands(c BundlesClient) cli.Commands {
+	return cli.Commands{
+		&cli.Command{
+			Name:   "export",
+			Usage:  "export the settings, probes and checks of the tenant as a bundle",
+			Action: c.exportBundle,
+			Flags: []cli.Flag{
+				&cli.StringFlag{
+					Name:    "file",
+					Aliases: []string{"f"},
+					Usage:   "write the bundle to `FILE` instead of standard output",
+					Value:   "-",
+				},
+			},
+		},
+		&cli.Command{
+			Name:   "import",
+			Usage:  "import a bundle created by export, mapping probes by name",
+			Action: c.importBundle,
+			Flags: []cli.Flag{
+				&cli.StringFlag{
+					Name:     "file",
+					Aliases:  []string{"f"},
+					Usage:    "read the bundle from `FILE`, use - for standard input",
+					Required: true,
+				},
+				getAllowMissingProbesFlag(),
+			},
+		},
+		&cli.Command{
+			Name:   "migrate",
+			Usage:  "copy the checks of the tenant, with their alerts, to another tenant, mapping probes by name",
+			Action: c.migrate,
+			Flags: []cli.Flag{
+				&cli.StringFlag{
+					Name:     "dest-sm-api-url",
+					Usage:    "base URL of the Synthetic Monitoring API server of the destination tenant",
+					Required: true,
+				},
+				&cli.StringFlag{
+					Name:     "dest-sm-api-token",
+					Usage:    "token used to access the destination tenant",
+					EnvVars:  []string{"DEST_SM_API_TOKEN"},
+					Required: true,
+				},
+				&cli.StringSliceFlag{
+					Name:  "probe-map",
+					Usage: "map the probe named SOURCE to the probe named DEST in the destination tenant, in the form SOURCE=DEST (can be repeated)",
+				},
+				&cli.StringFlag{
+					Name:  "report",
+					Usage: "write the migration report as JSON to `FILE`",
+				},
+				getAllowMissingProbesFlag(),
+			},
+		},
+	}
+}
+
+type BundlesClient ServiceClient
+
+func (c BundlesClient) exportBundle(ctx *cli.Context) error {
+	smClient, cleanup, err := c.ClientBuilder(ctx)
+	if err != nil {
+		return err
+	}
+	defer func() { _ = cleanup(ctx.Context) }()
+
+	bundle, err := smClient.ExportBundle(ctx.Context)
+	if err != nil {
+		return fmt.Errorf("exporting bundle: %w", err)
+	}
+
+	buf, err := json.MarshalIndent(bundle, "", "  ")
+	if err != nil {
+		return fmt.Errorf("marshaling bundle: %w", err)
+	}
+
+	buf = append(buf, '\n')
+
+	if filename := ctx.String("file"); filename == "-" {
+		_, err = ctx.App.Writer.Write(buf)
+	} else {
+		// The bundle doesn't contain any secrets, but it describes
+		// the tenant in detail.
+		err = os.WriteFile(filename, buf, 0o600)
+	}
+
+	if err != nil {
+		return fmt.Errorf("writing bundle: %w", err)
+	}
+
+	return nil
+}
+
+func (c BundlesClient) importBundle(ctx *cli.Context) error {
+	var (
+		buf []byte
+		err error
+	)
+
+	if filename := ctx.String("file"); filename == "-" {
+		buf, err = io.ReadAll(ctx.App.Reader)
+	} else {
+		buf, err = os.ReadFile(filename)
+	}
+
+	if err != nil {
+		return fmt.Errorf("reading bundle: %w", err)
+	}
+
+	var bundle model.Bundle
+	if err := json.Unmarshal(buf, &bundle); err != nil {
+		return fmt.Errorf("parsing bundle: %w", err)
+	}
+
+	smClient, cleanup, err := c.ClientBuilder(ctx)
+	if err != nil {
+		return err
+	}
+	defer func() { _ = cleanup(ctx.Context) }()
+
+	report, err := smClient.ImportBundle(ctx.Context, bundle)
+
+	// Show the report even in case of error, as it contains the tokens
+	// of the probes created until then.
+	if report != nil {
+		if err := c.writeImportReport(ctx, report); err != nil {
+			return err
+		}
+	}
+
+	if err != nil {
+		return fmt.Errorf("importing bundle: %w", err)
+	}
+
+	return importError(report.Checks, ctx.Bool("allow-missing-probes"))
+}
+
+func (c BundlesClient) writeImportReport(ctx *cli.Context, report *model.BundleImportReport) error {
+	jsonWriter := c.JsonWriterBuilder(ctx)
+	if done, err := jsonWriter(report, "marshaling report"); err != nil || done {
+		return err
+	}
+
+	w := c.TabWriterBuilder(ctx)
+
+	if report.TenantUpdated {
+		fmt.Fprintln(w, "tenant settings updated")
+		fmt.Fprintln(w)
+	}
+
+	if len(report.Probes) > 0 {
+		fmt.Fprintf(w, "%s\t%s\t%s\t%s\n", "probe", "id", "created", "token")
+		for _, p := range report.Probes {
+			fmt.Fprintf(w, "%s\t%d\t%t\t%s\n", p.Name, p.ID, p.Created, string(p.Token))
+		}
+		fmt.Fprintln(w)
+	}
+
+	fmt.Fprintf(w, "%s\t%s\t%s\t%s\t%s\t%s\n", "job", "target", "source id", "id", "unmapped probes", "error")
+	for _, check := range report.Checks {
+		fmt.Fprintf(w, "%s\t%s\t%d\t%d\t%s\t%s\n", check.Job, check.Target, check.SourceID, check.ID, strings.Join(check.UnmappedProbes, ","), check.Error)
+	}
+
+	if err := w.Flush(); err != nil {
+		return fmt.Errorf("flushing output: %w", err)
+	}
+
+	return nil
+}
+
+func (c BundlesClient) migrate(ctx *cli.Context) error {
+	probeMap := make(map[string]string)
+
+	for _, m := range ctx.StringSlice("probe-map") {
+		src, dst, found := strings.Cut(m, "=")
+		if !found || src == "" || dst == "" {
+			return fmt.Errorf("%q: %w", m, errInvalidProbeMap)
+		}
+
+		probeMap[src] = dst
+	}
+
+	srcClient, cleanup, err := c.ClientBuilder(ctx)
+	if err != nil {
+		return err
+	}
+	defer func() { _ = cleanup(ctx.Context) }()
+
+	dstClient, err := newAPIClient(ctx, ctx.String("dest-sm-api-url"), smapi.WithAccessToken(ctx.String("dest-sm-api-token")))
+	if err != nil {
+		return err
+	}
+
+	report, err := smapi.Migrate(ctx.Context, srcClient, dstClient, smapi.MigrateOptions{ProbeMap: probeMap})
+
+	if report != nil {
+		if err := c.writeMigrationReport(ctx, report); err != nil {
+			return err
+		}
+	}
+
+	if err != nil {
+		return fmt.Errorf("migrating checks: %w", err)
+	}
+
+	return importError(report.Checks, ctx.Bool("allow-missing-probes"))
+}
+
+// importError returns an error if any of the checks could not be imported
+// or, unless allowMissingProbes is true, if any was imported without some
+// of its probes, as it doesn't run where it used to.
+func importError(checks []model.BundleCheckImport, allowMissingProbes bool) error {
+	var missingProbes bool
+
+	for _, check := range checks {
+		switch {
+		case check.Error != "":
+			return errIncompleteImport
+
+		case len(check.UnmappedProbes) > 0:
+			missingProbes = true
+		}
+	}
+
+	if missingProbes && !allowMissingProbes {
+		return errMissingProbes
+	}
+
+	return nil
+}
+
+func (c BundlesClient) writeMigrationReport(ctx *cli.Context, report *model.MigrationReport) error {
+	if filename := ctx.String("report"); filename != "" {
+		buf, err := json.MarshalIndent(report, "", "  ")
+		if err != nil {
+			return fmt.Errorf("marshaling report: %w", err)
+		}
+
+		if err := os.WriteFile(filename, append(buf, '\n'), 0o600); err != nil {
+			return fmt.Errorf("writing report: %w", err)
+		}
+	}
+
+	jsonWriter := c.JsonWriterBuilder(ctx)
+	if done, err := jsonWriter(report, "marshaling report"); err != nil || done {
+		return err
+	}
+
+	w := c.TabWriterBuilder(ctx)
+
+	fmt.Fprintf(w, "%s\t%s\t%s\t%s\n", "probe", "region", "destination", "id")
+	for _, p := range report.Probes {
+		fmt.Fprintf(w, "%s\t%s\t%s\t%d\n", p.Source, p.SourceRegion, p.Destination, p.ID)
+	}
+	fmt.Fprintln(w)
+
+	fmt.Fprintf(w, "%s\t%s\t%s\t%s\t%s\t%s\n", "job", "target", "old id", "new id", "unmapped probes", "error")
+	for _, check := range report.Checks {
+		fmt.Fprintf(w, "%s\t%s\t%d\t%d\t%s\t%s\n", check.Job, check.Target, check.SourceID, check.ID, strings.Join(check.UnmappedProbes, ","), check.Error)
+	}
+
+	if err := w.Flush(); err != nil {
+		return fmt.Errorf("flushing output: %w", err)
+	}
+
+	return nil
+}
@@ -0,0 +1,95 @@
+package cli
+
+import (
+	"encoding/json"
+	"testing"
+
+	sm "github.com/grafana/synthetic-monitoring-agent/pkg/pb/synthetic_monitoring"
+	"github.com/grafana/synthetic-monitoring-api-go-client/model"
+	"github.com/stretchr/testify/require"
+)
+
+func TestImportError(t *testing.T) {
+	var (
+		imported = model.BundleCheckImport{Job: "j1", Target: "t1", SourceID: 1, ID: 10}
+		partial  = model.BundleCheckImport{Job: "j2", Target: "t2", SourceID: 2, ID: 11, UnmappedProbes: []string{"Paris"}}
+		failed   = model.BundleCheckImport{Job: "j3", Target: "t3", SourceID: 3, UnmappedProbes: []string{"Paris"}, Error: "no probes found"}
+	)
+
+	testcases := map[string]struct {
+		checks             []model.BundleCheckImport
+		allowMissingProbes bool
+		err                error
+	}{
+		"no checks":               {},
+		"imported":                {checks: []model.BundleCheckImport{imported}},
+		"partial":                 {checks: []model.BundleCheckImport{imported, partial}, err: errMissingProbes},
+		"partial allowed":         {checks: []model.BundleCheckImport{imported, partial}, allowMissingProbes: true},
+		"failed":                  {checks: []model.BundleCheckImport{failed}, err: errIncompleteImport},
+		"failed and partial":      {checks: []model.BundleCheckImport{partial, failed}, err: errIncompleteImport},
+		"failed, partial allowed": {checks: []model.BundleCheckImport{partial, failed}, allowMissingProbes: true, err: errIncompleteImport},
+	}
+
+	for name, tc := range testcases {
+		t.Run(name, func(t *testing.T) {
+			err := importError(tc.checks, tc.allowMissingProbes)
+			if tc.err != nil {
+				require.ErrorIs(t, err, tc.err)
+				return
+			}
+
+			require.NoError(t, err)
+		})
+	}
+}
+
+func TestBundleImportMissingProbes(t *testing.T) {
+	bundle := model.Bundle{
+		Version: model.BundleVersion,
+		Probes:  []model.BundleProbe{{Name: "Atlanta", Public: true}, {Name: "Paris", Public: true}},
+		Checks: []model.BundleCheck{{
+			Check:      model.Check{Check: sm.Check{Id: 1, Job: "homepage", Target: "https://example.org/"}},
+			ProbeNames: []string{"Atlanta", "Paris"},
+		}},
+	}
+
+	buf, err := json.Marshal(bundle)
+	require.NoError(t, err)
+
+	path := writeTestFile(t, "bundle.json", string(buf))
+
+	testcases := map[string]struct {
+		args []string
+		err  error
+	}{
+		"missing probes": {
+			err: errMissingProbes,
+		},
+		"allow missing probes": {
+			args: []string{"--allow-missing-probes"},
+		},
+	}
+
+	for name, tc := range testcases {
+		t.Run(name, func(t *testing.T) {
+			var added []model.Check
+
+			// Only Atlanta exists in the tenant.
+			url := newChecksTestAPI(t, []sm.Probe{{Id: 1, Name: "Atlanta", Public: true}}, &added)
+			commands := GetBundleCommands(BundlesClient(newTestServiceClient(url)))
+
+			stdout, _, err := runCommands(commands, "", append([]string{"import", "--file", path}, tc.args...)...)
+			if tc.err != nil {
+				require.ErrorIs(t, err, tc.err)
+			} else {
+				require.NoError(t, err)
+			}
+
+			// The check is created in both cases, and the missing probe
+			// is reported.
+			require.Len(t, added, 1)
+			require.Equal(t, []int64{1}, added[0].Probes)
+			require.Contains(t, stdout, "Paris")
+		})
+	}
+}
@@ -2,6 +2,7 @@ package cli
 
 import (
 	"encoding/json"
+	"errors"
 	"fmt"
 	"io"
 	"os"
@@ -11,10 +12,23 @@ import (
 
 	sm "github.com/grafana/synthetic-monitoring-agent/pkg/pb/synthetic_monitoring"
 	smapi "github.com/grafana/synthetic-monitoring-api-go-client"
+	"github.com/grafana/synthetic-monitoring-api-go-client/internal/jsondiff"
 	"github.com/grafana/synthetic-monitoring-api-go-client/model"
+	"github.com/grafana/synthetic-monitoring-api-go-client/multihttp"
 	"github.com/urfave/cli/v2"
 )
 
+var (
+	errProbeCapability   = errors.New("probe does not support this check type")
+	errCheckUpdateTarget = errors.New("either --id or both --job and --target must be used to select the check to update")
+	errCheckHasNoScript  = errors.New("check does not have a script")
+	errCheckDeleteTarget = errors.New("either --id or --selector must be used to select the checks to delete")
+	errInvalidSelector   = errors.New("invalid label selector")
+	errEmptySelector     = errors.New("the selector must have at least one matcher, use --id to delete checks one by one")
+	errCheckTypeChange   = errors.New("the settings must keep the type of the check")
+	errNoProbes          = errors.New("no probe selected to run the check")
+)
+
 func getCommonCheckFlags() []cli.Flag {
 	const defaultFrequency = 60 * time.Second
 	const defaultTimeout = 5 * time.Second
@@ -54,11 +68,77 @@ func getCommonCheckFlags() []cli.Flag {
 			Name:  "folder-uid",
 			Usage: "UID of the Grafana folder to associate with the check",
 		},
+		&cli.StringSliceFlag{
+			Name:  "label",
+			Usage: "label for the check, as name=value (can be repeated)",
+		},
+	}
+}
+
+func getSelectorFlag(usage string) cli.Flag {
+	return &cli.StringFlag{
+		Name:  "selector",
+		Usage: usage + ", e.g. 'team=sre,env!=dev'",
+	}
+}
+
+func getTLSFlags() []cli.Flag {
+	return []cli.Flag{
+		// Tls                  bool               `protobuf:"varint,3,opt,name=tls,proto3" json:"tls,omitempty"`
+		&cli.BoolFlag{
+			Name:  "tls",
+			Usage: "use TLS to connect to the target",
+		},
+		// TlsConfig            *TLSConfig         `protobuf:"bytes,4,opt,name=tlsConfig,proto3" json:"tlsConfig,omitempty"`
+		// InsecureSkipVerify   bool     `protobuf:"varint,1,opt,name=insecureSkipVerify,proto3" json:"insecureSkipVerify,omitempty"`
+		&cli.BoolFlag{
+			Name:  "tls-insecure-skip-verify",
+			Usage: "skip verification of the server certificate",
+		},
+		// CACert               []byte   `protobuf:"bytes,2,opt,name=CACert,proto3" json:"caCert,omitempty"`
+		&cli.StringFlag{
+			Name:  "tls-ca-cert",
+			Usage: "CA certificate to use to verify the server certificate",
+		},
+		// ClientCert           []byte   `protobuf:"bytes,3,opt,name=clientCert,proto3" json:"clientCert,omitempty"`
+		&cli.StringFlag{
+			Name:  "tls-client-cert",
+			Usage: "client certificate to use to connect to the target",
+		},
+		// ClientKey            []byte   `protobuf:"bytes,4,opt,name=clientKey,proto3" json:"clientKey,omitempty"`
+		&cli.StringFlag{
+			Name:  "tls-client-key",
+			Usage: "client key to use to connect to the target",
+		},
+		// ServerName           string   `protobuf:"bytes,5,opt,name=serverName,proto3" json:"serverName,omitempty"`
+		&cli.StringFlag{
+			Name:  "tls-server-name",
+			Usage: "server name to use to connect to the target",
+		},
+	}
+}
+
+// tlsConfig returns the TLS configuration specified using the flags
+// returned by getTLSFlags.
+func tlsConfig(ctx *cli.Context) *sm.TLSConfig {
+	return &sm.TLSConfig{
+		InsecureSkipVerify: ctx.Bool("tls-insecure-skip-verify"),
+		CACert:             []byte(ctx.String("tls-ca-cert")),
+		ClientCert:         []byte(ctx.String("tls-client-cert")),
+		ClientKey:          []byte(ctx.String("tls-client-key")),
+		ServerName:         ctx.String("tls-server-name"),
 	}
 }
 
 func GetCheckCommands(cc ChecksClient) cli.Commands {
-	const defaultDNSPort = 53
+	const (
+		defaultDNSPort                  = 53
+		defaultTracerouteMaxHops        = 64
+		defaultTracerouteMaxUnknownHops = 15
+		// The agent requires these for traceroute checks.
+		defaultTracerouteFrequency = 2 * time.Minute
+		defaultTracerouteTimeout   = 30 * time.Second
+	)
 
 	commands := cli.Commands{
 		&cli.Command{
@@ -72,6 +152,7 @@ func GetCheckCommands(cc ChecksClient) cli.Commands {
 					Required: false,
 					Value:    false,
 				},
+				getSelectorFlag("list only the checks with matching labels"),
 			},
 		},
 		&cli.Command{
@@ -231,58 +312,171 @@ func GetCheckCommands(cc ChecksClient) cli.Commands {
 					Name:   "tcp",
 					Usage:  "add a Synthetic Monitoring tcp check",
 					Action: cc.checkAddTcp,
-					Flags: []cli.Flag{
+					Flags: append([]cli.Flag{
 						&cli.GenericFlag{
 							Name:  "ip-version",
 							Usage: "IP version to use to connect to the target",
 							Value: newIpVersion(sm.IpVersion_Any),
 						},
-						// Tls                  bool               `protobuf:"varint,3,opt,name=tls,proto3" json:"tls,omitempty"`
-						&cli.BoolFlag{
-							Name:  "tls",
-							Usage: "use TLS to connect to the target",
+						// QueryResponse        []TCPQueryResponse `protobuf:"bytes,5,rep,name=queryResponse,proto3" json:"queryResponse,omitempty"`
+					}, getTLSFlags()...),
+				},
+				{
+					Name:   "grpc",
+					Usage:  "add a Synthetic Monitoring gRPC health check",
+					Action: cc.checkAddGrpc,
+					Flags: append([]cli.Flag{
+						&cli.GenericFlag{
+							Name:  "ip-version",
+							Usage: "IP version to use to connect to the target",
+							Value: newIpVersion(sm.IpVersion_Any),
+						},
+						&cli.StringFlag{
+							Name:  "service",
+							Usage: "name of the service to check (default: the overall health of the server)",
+						},
+					}, getTLSFlags()...),
+				},
+				{
+					Name:   "traceroute",
+					Usage:  "add a Synthetic Monitoring traceroute check",
+					Action: cc.checkAddTraceroute,
+					Flags: []cli.Flag{
+						&cli.DurationFlag{
+							Name:  "frequency",
+							Usage: "frequency of the check, at least 2m",
+							Value: defaultTracerouteFrequency,
+						},
+						&cli.DurationFlag{
+							Name:  "timeout",
+							Usage: "timeout of the check, must be 30s",
+							Value: defaultTracerouteTimeout,
+						},
+						&cli.Int64Flag{
+							Name:  "max-hops",
+							Usage: "maximum number of hops to reach the target",
+							Value: defaultTracerouteMaxHops,
+						},
+						&cli.Int64Flag{
+							Name:  "max-unknown-hops",
+							Usage: "maximum number of consecutive hops that don't respond",
+							Value: defaultTracerouteMaxUnknownHops,
 						},
-						// TlsConfig            *TLSConfig         `protobuf:"bytes,4,opt,name=tlsConfig,proto3" json:"tlsConfig,omitempty"`
-						// InsecureSkipVerify   bool     `protobuf:"varint,1,opt,name=insecureSkipVerify,proto3" json:"insecureSkipVerify,omitempty"`
 						&cli.BoolFlag{
-							Name:  "tls-insecure-skip-verify",
-							Usage: "skip verification of the server certificate",
+							Name:  "ptr-lookup",
+							Usage: "look up the host names of the hops",
+							Value: true,
 						},
-						// CACert               []byte   `protobuf:"bytes,2,opt,name=CACert,proto3" json:"caCert,omitempty"`
+					},
+				},
+				{
+					Name:   "scripted",
+					Usage:  "add a Synthetic Monitoring scripted (k6) check",
+					Action: cc.checkAddScripted,
+					Flags: []cli.Flag{
 						&cli.StringFlag{
-							Name:  "tls-ca-cert",
-							Usage: "CA certificate to use to verify the server certificate",
+							Name:     "script",
+							Usage:    "k6 script to run, use @filename to read it from a file",
+							Required: true,
 						},
-						// ClientCert           []byte   `protobuf:"bytes,3,opt,name=clientCert,proto3" json:"clientCert,omitempty"`
+					},
+				},
+				{
+					Name:   "browser",
+					Usage:  "add a Synthetic Monitoring browser (k6) check",
+					Action: cc.checkAddBrowser,
+					Flags: []cli.Flag{
 						&cli.StringFlag{
-							Name:  "tls-client-cert",
-							Usage: "client certificate to use to connect to the target",
+							Name:     "script",
+							Usage:    "k6 browser script to run, use @filename to read it from a file",
+							Required: true,
 						},
-						// ClientKey            []byte   `protobuf:"bytes,4,opt,name=clientKey,proto3" json:"clientKey,omitempty"`
 						&cli.StringFlag{
-							Name:  "tls-client-key",
-							Usage: "client key to use to connect to the target",
+							Name:  "channel",
+							Usage: "k6 channel to run the script with (default: the channel selected by the API)",
 						},
-						// ServerName           string   `protobuf:"bytes,5,opt,name=serverName,proto3" json:"serverName,omitempty"`
+					},
+				},
+				{
+					Name:   "multihttp",
+					Usage:  "add a Synthetic Monitoring MultiHTTP check",
+					Action: cc.checkAddMultiHttp,
+					Flags: []cli.Flag{
 						&cli.StringFlag{
-							Name:  "tls-server-name",
-							Usage: "server name to use to connect to the target",
+							Name:     "file",
+							Usage:    "YAML or JSON `FILE` describing the requests to make, use - for stdin",
+							Required: true,
 						},
-						// QueryResponse        []TCPQueryResponse `protobuf:"bytes,5,rep,name=queryResponse,proto3" json:"queryResponse,omitempty"`
 					},
 				},
 			},
 		},
+		getCheckAlertCommands(cc),
+		&cli.Command{
+			Name:   "update",
+			Usage:  "update a Synthetic Monitoring check, changing only the specified settings",
+			Action: cc.checkUpdate,
+			Flags: []cli.Flag{
+				&cli.Int64Flag{
+					Name:  "id",
+					Usage: "id of the check to update",
+				},
+				&cli.StringFlag{
+					Name:  "job",
+					Usage: "job of the check to update, together with --target",
+				},
+				&cli.StringFlag{
+					Name:  "target",
+					Usage: "target of the check to update, together with --job",
+				},
+				&cli.DurationFlag{
+					Name:  "frequency",
+					Usage: "new frequency of the check",
+				},
+				&cli.DurationFlag{
+					Name:  "timeout",
+					Usage: "new timeout of the check",
+				},
+				&cli.BoolFlag{
+					Name:  "enabled",
+					Usage: "whether the check is enabled",
+				},
+				&cli.StringSliceFlag{
+					Name:  "probes",
+					Usage: "names or IDs of the probes where this check should run, or all",
+				},
+				&cli.StringSliceFlag{
+					Name:  "label",
+					Usage: "new labels of the check, as name=value (can be repeated)",
+				},
+				&cli.StringFlag{
+					Name:  "folder-uid",
+					Usage: "UID of the Grafana folder to associate with the check",
+				},
+				&cli.StringFlag{
+					Name:  "script",
+					Usage: "new k6 script for scripted and browser checks, use @filename to read it from a file",
+				},
+				&cli.StringFlag{
+					Name:  "settings",
+					Usage: `JSON merged into the check's settings, e.g. '{"http":{"method":"POST"}}', use @filename to read it from a file`,
+				},
+				&cli.BoolFlag{
+					Name:  "dry-run",
+					Usage: "show the changes without updating the check",
+				},
+			},
+		},
 		&cli.Command{
 			Name:   "delete",
 			Usage:  "delete one or more Synthetic Monitoring checks",
 			Action: cc.checkDelete,
 			Flags: []cli.Flag{
 				&cli.Int64SliceFlag{
-					Name:     "id",
-					Usage:    "id of the check to delete",
-					Required: true,
+					Name:  "id",
+					Usage: "id of the check to delete",
 				},
+				getSelectorFlag("delete all the checks with matching labels"),
 			},
 		},
 	}
@@ -292,9 +486,20 @@ func GetCheckCommands(cc ChecksClient) cli.Commands {
 			continue
 		}
 		for _, subCmd := range cmd.Subcommands {
+			// Subcommands can override the common flags, e.g. to
+			// use different defaults.
+			overridden := make(map[string]bool)
+			for _, flag := range subCmd.Flags {
+				overridden[flag.Names()[0]] = true
+			}
+
 			commonCheckFlags := getCommonCheckFlags()
 			flags := make([]cli.Flag, 0, len(commonCheckFlags)+len(subCmd.Flags))
-			flags = append(flags, commonCheckFlags...)
+			for _, flag := range commonCheckFlags {
+				if !overridden[flag.Names()[0]] {
+					flags = append(flags, flag)
+				}
+			}
 			flags = append(flags, subCmd.Flags...)
 			subCmd.Flags = flags
 		}
@@ -320,23 +525,41 @@ func (c ChecksClient) checkList(ctx *cli.Context) error {
 }
 
 func (c ChecksClient) listAndPrintChecks(ctx *cli.Context, smClient *smapi.Client) error {
-	checks, err := smClient.ListChecks(ctx.Context)
+	selector, err := parseSelector(ctx.String("selector"))
+	if err != nil {
+		return err
+	}
+
+	allChecks, err := smClient.ListChecks(ctx.Context)
 	if err != nil {
 		return fmt.Errorf("listing checks: %w", err)
 	}
 
+	checks := make([]model.Check, 0, len(allChecks))
+	for _, check := range allChecks {
+		if selector.Matches(check.Labels) {
+			checks = append(checks, check)
+		}
+	}
+
 	jsonWriter := c.JsonWriterBuilder(ctx)
 
 	if done, err := jsonWriter(checks, "marshaling checks"); err != nil || done {
 		return err
 	}
 
+	wide := wideOutput(ctx)
+
 	w := c.TabWriterBuilder(ctx)
-	fmt.Fprintf(w, "%s\t%s\t%s\t%s\t%s\t%s\t%s\n", "id", "type", "job", "target", "enabled", "frequency", "timeout")
+	fmt.Fprintf(w, "%s\t%s\t%s\t%s\t%s\t%s\t%s\t%s\t%s", "id", "type", "job", "target", "enabled", "frequency", "timeout", "script", "labels")
+	if wide {
+		fmt.Fprintf(w, "\t%s\t%s\t%s\t%s", "probes", "alert sensitivity", "basic metrics only", "modified")
+	}
+	fmt.Fprintln(w)
 	for _, check := range checks {
 		fmt.Fprintf(
 			w,
-			"%d\t%s\t%s\t%s\t%t\t%s\t%s\n",
+			"%d\t%s\t%s\t%s\t%t\t%s\t%s\t%s\t%s",
 			check.Id,
 			check.Type(),
 			check.Job,
@@ -344,7 +567,13 @@ func (c ChecksClient) listAndPrintChecks(ctx *cli.Context, smClient *smapi.Clien
 			check.Enabled,
 			time.Duration(check.Frequency)*time.Millisecond,
 			time.Duration(check.Timeout)*time.Millisecond,
+			scriptSize(check.Check),
+			formatLabels(check.Labels),
 		)
+		if wide {
+			writeWideCheckColumns(w, check.Check)
+		}
+		fmt.Fprintln(w)
 	}
 	if err := w.Flush(); err != nil {
 		return fmt.Errorf("flushing output: %w", err)
@@ -354,23 +583,41 @@ func (c ChecksClient) listAndPrintChecks(ctx *cli.Context, smClient *smapi.Clien
 }
 
 func (c ChecksClient) listAndPrintChecksWithAlerts(ctx *cli.Context, smClient *smapi.Client) error {
-	checks, err := smClient.ListChecksWithAlerts(ctx.Context)
+	selector, err := parseSelector(ctx.String("selector"))
+	if err != nil {
+		return err
+	}
+
+	allChecks, err := smClient.ListChecksWithAlerts(ctx.Context)
 	if err != nil {
 		return fmt.Errorf("listing checks: %w", err)
 	}
 
+	checks := make([]model.CheckWithAlerts, 0, len(allChecks))
+	for _, check := range allChecks {
+		if selector.Matches(check.Labels) {
+			checks = append(checks, check)
+		}
+	}
+
 	jsonWriter := c.JsonWriterBuilder(ctx)
 
 	if done, err := jsonWriter(checks, "marshaling checks"); err != nil || done {
 		return err
 	}
 
+	wide := wideOutput(ctx)
+
 	w := c.TabWriterBuilder(ctx)
-	fmt.Fprintf(w, "%s\t%s\t%s\t%s\t%s\t%s\t%s\n", "id", "type", "job", "target", "enabled", "frequency", "timeout")
+	fmt.Fprintf(w, "%s\t%s\t%s\t%s\t%s\t%s\t%s\t%s\t%s", "id", "type", "job", "target", "enabled", "frequency", "timeout", "script", "labels")
+	if wide {
+		fmt.Fprintf(w, "\t%s\t%s\t%s\t%s", "probes", "alert sensitivity", "basic metrics only", "modified")
+	}
+	fmt.Fprintln(w)
 	for _, check := range checks {
 		fmt.Fprintf(
 			w,
-			"%d\t%s\t%s\t%s\t%t\t%s\t%s\n",
+			"%d\t%s\t%s\t%s\t%t\t%s\t%s\t%s\t%s",
 			check.Id,
 			check.Type(),
 			check.Job,
@@ -378,7 +625,13 @@ func (c ChecksClient) listAndPrintChecksWithAlerts(ctx *cli.Context, smClient *s
 			check.Enabled,
 			time.Duration(check.Frequency)*time.Millisecond,
 			time.Duration(check.Timeout)*time.Millisecond,
+			scriptSize(check.Check.Check),
+			formatLabels(check.Labels),
 		)
+		if wide {
+			writeWideCheckColumns(w, check.Check.Check)
+		}
+		fmt.Fprintln(w)
 		for i, alert := range check.Alerts {
 			if i == 0 {
 				fmt.Fprintf(w, "\t%s\t%s\t%s\t%s\t%s\t%s\n", "alert name", "alert threshold", "alert period", "alert runbook", "alert status", "alert error")
@@ -411,196 +664,195 @@ func (c ChecksClient) checkGet(ctx *cli.Context) error {
 		return err
 	}
 
-	return c.showCheck(ctx, os.Stdout, check)
+	return c.showCheck(ctx, check)
 }
 
 func (c ChecksClient) checkAddPing(ctx *cli.Context) error {
-	smClient, cleanup, err := c.ClientBuilder(ctx)
-	if err != nil {
-		return err
+	settings := sm.CheckSettings{
+		Ping: &sm.PingSettings{
+			IpVersion:    sm.IpVersion(*(ctx.Generic("ip-version").(*ipVersion))),
+			DontFragment: ctx.Bool("dont-fragment"),
+			PacketCount:  ctx.Int64("packet-count"),
+		},
 	}
-	defer func() { _ = cleanup(ctx.Context) }()
 
-	ipVersion := sm.IpVersion(*(ctx.Generic("ip-version").(*ipVersion)))
+	return c.addCheck(ctx, settings, anyProbe)
+}
 
-	probes, err := smClient.ListProbes(ctx.Context)
-	if err != nil {
-		return fmt.Errorf("getting probes: %w", err)
+func (c ChecksClient) checkAddHttp(ctx *cli.Context) error {
+	var validHttpStatusCodes []int32
+	if ctx.IsSet("valid-status-codes") {
+		in := ctx.IntSlice("valid-status-codes")
+		validHttpStatusCodes = make([]int32, 0, len(in))
+		for _, statusCode := range in {
+			validHttpStatusCodes = append(validHttpStatusCodes, int32(statusCode))
+		}
 	}
 
-	check := sm.Check{
-		Job:       ctx.String("job"),
-		Target:    ctx.String("target"),
-		Frequency: ctx.Duration("frequency").Milliseconds(),
-		Timeout:   ctx.Duration("timeout").Milliseconds(),
-		Enabled:   ctx.Bool("enabled"),
-		Settings: sm.CheckSettings{
-			Ping: &sm.PingSettings{
-				IpVersion:    ipVersion,
-				DontFragment: ctx.Bool("dont-fragment"),
-				PacketCount:  ctx.Int64("packet-count"),
-			},
+	settings := sm.CheckSettings{
+		Http: &sm.HttpSettings{
+			IpVersion:                  sm.IpVersion(*(ctx.Generic("ip-version").(*ipVersion))),
+			Method:                     sm.HttpMethod(*(ctx.Generic("method").(*httpMethod))),
+			Headers:                    ctx.StringSlice("headers"),
+			Body:                       ctx.String("body"),
+			NoFollowRedirects:          ctx.Bool("no-follow-redirects"),
+			BearerToken:                ctx.String("bearer-token"),
+			SecretManagerEnabled:       ctx.Bool("secret-manager-enabled"),
+			FailIfSSL:                  ctx.Bool("fail-if-ssl"),
+			FailIfNotSSL:               ctx.Bool("fail-if-not-ssl"),
+			ValidStatusCodes:           validHttpStatusCodes,
+			ValidHTTPVersions:          ctx.StringSlice("valid-http-versions"),
+			FailIfBodyMatchesRegexp:    ctx.StringSlice("fail-if-body-matches-regexp"),
+			FailIfBodyNotMatchesRegexp: ctx.StringSlice("fail-if-body-not-matches-regexp"),
+			// FailIfHeaderMatchesRegexp:    c.StringSlice("fail-if-header-matches-regexp"),
+			// FailIfHeaderNotMatchesRegexp: c.StringSlice("fail-if-header-not-matches-regexp"),
+			Compression:                sm.CompressionAlgorithm(*(ctx.Generic("compression-algorithm").(*compressionAlgo))),
+			CacheBustingQueryParamName: ctx.String("cache-busting-parameter-name"),
 		},
 	}
 
-	wantedProbes := make(map[string]struct{})
+	return c.addCheck(ctx, settings, anyProbe)
+}
 
-	for _, probe := range ctx.StringSlice("probes") {
-		wantedProbes[strings.ToLower(strings.TrimSpace(probe))] = struct{}{}
+func (c ChecksClient) checkAddDns(ctx *cli.Context) error {
+	settings := sm.CheckSettings{
+		Dns: &sm.DnsSettings{
+			IpVersion:   sm.IpVersion(*(ctx.Generic("ip-version").(*ipVersion))),
+			Server:      ctx.String("server"),
+			Port:        int32(ctx.Int("port")),
+			RecordType:  sm.DnsRecordType(*(ctx.Generic("record-type").(*dnsRecordType))),
+			Protocol:    sm.DnsProtocol(*(ctx.Generic("protocol").(*dnsProtocol))),
+			ValidRCodes: ctx.StringSlice("valid-rcodes"),
+			// ValidateAnswer       *DNSRRValidator
+			// ValidateAuthority    *DNSRRValidator
+			// ValidateAdditional   *DNSRRValidator
+		},
 	}
 
-	if _, found := wantedProbes["all"]; found {
-		for _, probe := range probes {
-			check.Probes = append(check.Probes, probe.Id)
-		}
-	} else {
-		for _, probe := range probes {
-			if _, found := wantedProbes[strings.ToLower(probe.Name)]; found {
-				check.Probes = append(check.Probes, probe.Id)
-			} else if _, found := wantedProbes[idToStr(probe.Id)]; found {
-				check.Probes = append(check.Probes, probe.Id)
-			}
-		}
-	}
+	return c.addCheck(ctx, settings, anyProbe)
+}
 
-	if err := check.Validate(); err != nil {
-		return fmt.Errorf("invalid check: %w", err)
+func (c ChecksClient) checkAddTcp(ctx *cli.Context) error {
+	settings := sm.CheckSettings{
+		Tcp: &sm.TcpSettings{
+			IpVersion: sm.IpVersion(*(ctx.Generic("ip-version").(*ipVersion))),
+			Tls:       ctx.Bool("tls"),
+			TlsConfig: tlsConfig(ctx),
+			// QueryResponse        []TCPQueryResponse `protobuf:"bytes,5,rep,name=queryResponse,proto3" json:"queryResponse,omitempty"`
+		},
 	}
 
-	newCheck, err := smClient.AddCheck(ctx.Context, model.Check{Check: check, FolderUid: ctx.String("folder-uid")})
-	if err != nil {
-		return fmt.Errorf("adding check: %w", err)
+	return c.addCheck(ctx, settings, anyProbe)
+}
+
+func (c ChecksClient) checkAddGrpc(ctx *cli.Context) error {
+	settings := sm.CheckSettings{
+		Grpc: &sm.GrpcSettings{
+			IpVersion: sm.IpVersion(*(ctx.Generic("ip-version").(*ipVersion))),
+			Service:   ctx.String("service"),
+			Tls:       ctx.Bool("tls"),
+			TlsConfig: tlsConfig(ctx),
+		},
 	}
 
-	jsonWriter := c.JsonWriterBuilder(ctx)
+	return c.addCheck(ctx, settings, anyProbe)
+}
 
-	if done, err := jsonWriter(newCheck, "marshaling check"); err != nil || done {
-		return err
+func (c ChecksClient) checkAddTraceroute(ctx *cli.Context) error {
+	settings := sm.CheckSettings{
+		Traceroute: &sm.TracerouteSettings{
+			MaxHops:        ctx.Int64("max-hops"),
+			MaxUnknownHops: ctx.Int64("max-unknown-hops"),
+			PtrLookup:      ctx.Bool("ptr-lookup"),
+		},
 	}
 
-	return c.showCheck(ctx, os.Stdout, newCheck)
+	return c.addCheck(ctx, settings, anyProbe)
 }
 
-func (c ChecksClient) checkAddHttp(ctx *cli.Context) error {
-	smClient, cleanup, err := c.ClientBuilder(ctx)
+func (c ChecksClient) checkAddScripted(ctx *cli.Context) error {
+	script, err := readFileArg(ctx.String("script"))
 	if err != nil {
-		return err
+		return fmt.Errorf("reading script: %w", err)
 	}
-	defer func() { _ = cleanup(ctx.Context) }()
-
-	ipVersion := sm.IpVersion(*(ctx.Generic("ip-version").(*ipVersion)))
-	httpMethod := sm.HttpMethod(*(ctx.Generic("method").(*httpMethod)))
-	compressionAlgo := sm.CompressionAlgorithm(*(ctx.Generic("compression-algorithm").(*compressionAlgo)))
 
-	var validHttpStatusCodes []int32
-	if ctx.IsSet("http-status-codes") {
-		in := ctx.IntSlice("http-status-codes")
-		validHttpStatusCodes = make([]int32, 0, len(in))
-		for _, statusCode := range in {
-			validHttpStatusCodes = append(validHttpStatusCodes, int32(statusCode))
-		}
+	settings := sm.CheckSettings{
+		Scripted: &sm.ScriptedSettings{
+			Script: script,
+		},
 	}
 
-	probes, err := smClient.ListProbes(ctx.Context)
+	return c.addCheck(ctx, settings, scriptedProbe)
+}
+
+func (c ChecksClient) checkAddBrowser(ctx *cli.Context) error {
+	script, err := readFileArg(ctx.String("script"))
 	if err != nil {
-		return fmt.Errorf("getting probes: %w", err)
+		return fmt.Errorf("reading script: %w", err)
 	}
 
-	check := sm.Check{
-		Job:       ctx.String("job"),
-		Target:    ctx.String("target"),
-		Frequency: ctx.Duration("frequency").Milliseconds(),
-		Timeout:   ctx.Duration("timeout").Milliseconds(),
-		Enabled:   ctx.Bool("enabled"),
-		Settings: sm.CheckSettings{
-			Http: &sm.HttpSettings{
-				IpVersion:                  ipVersion,
-				Method:                     httpMethod,
-				Headers:                    ctx.StringSlice("headers"),
-				Body:                       ctx.String("body"),
-				NoFollowRedirects:          ctx.Bool("no-follow-redirects"),
-				BearerToken:                ctx.String("bearer-token"),
-				SecretManagerEnabled:       ctx.Bool("secret-manager-enabled"),
-				FailIfSSL:                  ctx.Bool("fail-if-ssl"),
-				FailIfNotSSL:               ctx.Bool("fail-if-not-ssl"),
-				ValidStatusCodes:           validHttpStatusCodes,
-				ValidHTTPVersions:          ctx.StringSlice("valid-http-versions"),
-				FailIfBodyMatchesRegexp:    ctx.StringSlice("fail-if-body-matches-regexp"),
-				FailIfBodyNotMatchesRegexp: ctx.StringSlice("fail-if-body-not-matches-regexp"),
-				// FailIfHeaderMatchesRegexp:    c.StringSlice("fail-if-header-matches-regexp"),
-				// FailIfHeaderNotMatchesRegexp: c.StringSlice("fail-if-header-not-matches-regexp"),
-				Compression:                compressionAlgo,
-				CacheBustingQueryParamName: ctx.String("cache-busting-query-param-name"),
-			},
+	settings := sm.CheckSettings{
+		Browser: &sm.BrowserSettings{
+			Script: script,
 		},
 	}
 
-	wantedProbes := make(map[string]struct{})
+	return c.addCheck(ctx, settings, browserProbe)
+}
 
-	for _, probe := range ctx.StringSlice("probes") {
-		wantedProbes[strings.ToLower(strings.TrimSpace(probe))] = struct{}{}
-	}
+func (c ChecksClient) checkAddMultiHttp(ctx *cli.Context) error {
+	var (
+		buf []byte
+		err error
+	)
 
-	if _, found := wantedProbes["all"]; found {
-		for _, probe := range probes {
-			check.Probes = append(check.Probes, probe.Id)
-		}
+	if filename := ctx.String("file"); filename == "-" {
+		buf, err = io.ReadAll(ctx.App.Reader)
 	} else {
-		for _, probe := range probes {
-			if _, found := wantedProbes[strings.ToLower(probe.Name)]; found {
-				check.Probes = append(check.Probes, probe.Id)
-			} else if _, found := wantedProbes[idToStr(probe.Id)]; found {
-				check.Probes = append(check.Probes, probe.Id)
-			}
-		}
+		buf, err = os.ReadFile(filename)
 	}
 
-	if err := check.Validate(); err != nil {
-		return fmt.Errorf("invalid check: %w", err)
+	if err != nil {
+		return fmt.Errorf("reading MultiHTTP definition: %w", err)
 	}
 
-	newCheck, err := smClient.AddCheck(ctx.Context, model.Check{Check: check, FolderUid: ctx.String("folder-uid")})
+	def, err := multihttp.Parse(buf)
 	if err != nil {
-		return fmt.Errorf("adding check: %w", err)
+		return err
 	}
 
-	jsonWriter := c.JsonWriterBuilder(ctx)
-
-	if done, err := jsonWriter(newCheck, "marshaling check"); err != nil || done {
+	settings, err := def.Settings()
+	if err != nil {
 		return err
 	}
 
-	return c.showCheck(ctx, os.Stdout, newCheck)
+	// MultiHTTP checks are run using k6, just like scripted checks.
+	return c.addCheck(ctx, sm.CheckSettings{Multihttp: settings}, scriptedProbe)
 }
 
-func (c ChecksClient) checkAddDns(ctx *cli.Context) error {
+// addCheck adds a check with the specified settings, using only the
+// probes for which supported returns true. For checks running k6
+// scripts, the channel is selected using the --channel flag, if any.
+func (c ChecksClient) addCheck(ctx *cli.Context, settings sm.CheckSettings, supported func(sm.Probe) bool) error {
 	smClient, cleanup, err := c.ClientBuilder(ctx)
 	if err != nil {
 		return err
 	}
 	defer func() { _ = cleanup(ctx.Context) }()
 
-	ipVersion := sm.IpVersion(*(ctx.Generic("ip-version").(*ipVersion)))
-
 	check := sm.Check{
 		Job:       ctx.String("job"),
 		Target:    ctx.String("target"),
 		Frequency: ctx.Duration("frequency").Milliseconds(),
 		Timeout:   ctx.Duration("timeout").Milliseconds(),
 		Enabled:   ctx.Bool("enabled"),
-		Settings: sm.CheckSettings{
-			Dns: &sm.DnsSettings{
-				IpVersion:   ipVersion,
-				Server:      ctx.String("server"),
-				Port:        int32(ctx.Int("port")),
-				RecordType:  sm.DnsRecordType(*(ctx.Generic("record-type").(*dnsRecordType))),
-				Protocol:    sm.DnsProtocol(*(ctx.Generic("protocol").(*dnsProtocol))),
-				ValidRCodes: ctx.StringSlice("valid-rcodes"),
-				// ValidateAnswer       *DNSRRValidator
-				// ValidateAuthority    *DNSRRValidator
-				// ValidateAdditional   *DNSRRValidator
-			},
-		},
+		Settings:  settings,
+	}
+
+	if channel := ctx.String("channel"); channel != "" {
+		check.Channels = &sm.Channels{
+			K6: &sm.K6Channel{Id: channel},
+		}
 	}
 
 	probes, err := smClient.ListProbes(ctx.Context)
@@ -608,28 +860,18 @@ func (c ChecksClient) checkAddDns(ctx *cli.Context) error {
 		return fmt.Errorf("getting probes: %w", err)
 	}
 
-	wantedProbes := make(map[string]struct{})
-
-	for _, probe := range ctx.StringSlice("probes") {
-		wantedProbes[strings.ToLower(strings.TrimSpace(probe))] = struct{}{}
+	check.Probes, err = selectProbes(ctx.StringSlice("probes"), probes, supported)
+	if err != nil {
+		return err
 	}
 
-	if _, found := wantedProbes["all"]; found {
-		for _, probe := range probes {
-			check.Probes = append(check.Probes, probe.Id)
-		}
-	} else {
-		for _, probe := range probes {
-			if _, found := wantedProbes[strings.ToLower(probe.Name)]; found {
-				check.Probes = append(check.Probes, probe.Id)
-			} else if _, found := wantedProbes[idToStr(probe.Id)]; found {
-				check.Probes = append(check.Probes, probe.Id)
-			}
-		}
+	check.Labels, err = parseLabels(ctx.StringSlice("label"))
+	if err != nil {
+		return err
 	}
 
-	if err := check.Validate(); err != nil {
-		return fmt.Errorf("invalid check: %w", err)
+	if err := validateCheck(ctx, smClient, &check); err != nil {
+		return err
 	}
 
 	newCheck, err := smClient.AddCheck(ctx.Context, model.Check{Check: check, FolderUid: ctx.String("folder-uid")})
@@ -643,91 +885,397 @@ func (c ChecksClient) checkAddDns(ctx *cli.Context) error {
 		return err
 	}
 
-	return c.showCheck(ctx, os.Stdout, newCheck)
+	return c.showCheck(ctx, newCheck)
 }
 
-func (c ChecksClient) checkAddTcp(ctx *cli.Context) error {
+// validateCheck validates check on behalf of the authenticated tenant,
+// as the validation requires a tenant ID. The API sets it in any case.
+func validateCheck(ctx *cli.Context, smClient *smapi.Client, check *sm.Check) error {
+	tenant, err := smClient.GetTenant(ctx.Context)
+	if err != nil {
+		return fmt.Errorf("getting tenant: %w", err)
+	}
+
+	check.TenantId = tenant.Id
+
+	if err := check.Validate(); err != nil {
+		return fmt.Errorf("invalid check: %w", err)
+	}
+
+	return nil
+}
+
+// anyProbe is used with selectProbes for check types all probes support.
+func anyProbe(sm.Probe) bool {
+	return true
+}
+
+// scriptedProbe reports whether p can run scripted and MultiHTTP checks.
+func scriptedProbe(p sm.Probe) bool {
+	return p.Capabilities == nil || !p.Capabilities.DisableScriptedChecks
+}
+
+// browserProbe reports whether p can run browser checks.
+func browserProbe(p sm.Probe) bool {
+	return p.Capabilities == nil || !p.Capabilities.DisableBrowserChecks
+}
+
+// probeFilter returns the function to use with selectProbes for checks
+// of type t.
+func probeFilter(t sm.CheckType) func(sm.Probe) bool {
+	switch t {
+	case sm.CheckTypeScripted, sm.CheckTypeMultiHttp:
+		return scriptedProbe
+
+	case sm.CheckTypeBrowser:
+		return browserProbe
+
+	default:
+		return anyProbe
+	}
+}
+
+// selectProbes returns the IDs of the probes named in wanted, either by
+// name or ID, or all of them if "all" is included. Probes for which
+// supported returns false are skipped when selecting all of them, and
+// selecting them explicitly is an error, as is selecting no probe at all.
+func selectProbes(wanted []string, probes []sm.Probe, supported func(sm.Probe) bool) ([]int64, error) {
+	wantedProbes := make(map[string]struct{})
+
+	for _, probe := range wanted {
+		wantedProbes[strings.ToLower(strings.TrimSpace(probe))] = struct{}{}
+	}
+
+	_, all := wantedProbes["all"]
+
+	var ids []int64
+
+	for _, probe := range probes {
+		_, foundName := wantedProbes[strings.ToLower(probe.Name)]
+		_, foundID := wantedProbes[idToStr(probe.Id)]
+
+		switch {
+		case supported(probe) && (all || foundName || foundID):
+			ids = append(ids, probe.Id)
+
+		case foundName || foundID:
+			return nil, fmt.Errorf("%s: %w", probe.Name, errProbeCapability)
+		}
+	}
+
+	if len(ids) == 0 {
+		return nil, fmt.Errorf("%s: %w", strings.Join(wanted, ","), errNoProbes)
+	}
+
+	return ids, nil
+}
+
+func (c ChecksClient) checkUpdate(ctx *cli.Context) error {
 	smClient, cleanup, err := c.ClientBuilder(ctx)
 	if err != nil {
 		return err
 	}
 	defer func() { _ = cleanup(ctx.Context) }()
 
-	ipVersion := sm.IpVersion(*(ctx.Generic("ip-version").(*ipVersion)))
+	var check *model.Check
 
-	check := sm.Check{
-		Job:       ctx.String("job"),
-		Target:    ctx.String("target"),
-		Frequency: ctx.Duration("frequency").Milliseconds(),
-		Timeout:   ctx.Duration("timeout").Milliseconds(),
-		Enabled:   ctx.Bool("enabled"),
-		Settings: sm.CheckSettings{
-			Tcp: &sm.TcpSettings{
-				IpVersion: ipVersion,
-				Tls:       ctx.Bool("tls"),
-				TlsConfig: &sm.TLSConfig{
-					InsecureSkipVerify: ctx.Bool("tls-insecure-skip-verify"),
-					CACert:             []byte(ctx.String("tls-ca-cert")),
-					ClientCert:         []byte(ctx.String("tls-client-cert")),
-					ClientKey:          []byte(ctx.String("tls-client-key")),
-					ServerName:         ctx.String("tls-server-name"),
-				},
-				// QueryResponse        []TCPQueryResponse `protobuf:"bytes,5,rep,name=queryResponse,proto3" json:"queryResponse,omitempty"`
-			},
-		},
+	switch {
+	case ctx.IsSet("id"):
+		check, err = smClient.GetCheck(ctx.Context, ctx.Int64("id"))
+
+	case ctx.IsSet("job") && ctx.IsSet("target"):
+		check, err = smClient.QueryCheck(ctx.Context, ctx.String("job"), ctx.String("target"))
+
+	default:
+		return errCheckUpdateTarget
 	}
 
-	probes, err := smClient.ListProbes(ctx.Context)
 	if err != nil {
-		return fmt.Errorf("getting probes: %w", err)
+		return fmt.Errorf("getting check: %w", err)
 	}
 
-	wantedProbes := make(map[string]struct{})
+	updated, err := c.applyCheckUpdate(ctx, smClient, *check)
+	if err != nil {
+		return err
+	}
 
-	for _, probe := range ctx.StringSlice("probes") {
-		wantedProbes[strings.ToLower(strings.TrimSpace(probe))] = struct{}{}
+	if err := validateCheck(ctx, smClient, &updated.Check); err != nil {
+		return err
+	}
+
+	changes, err := jsondiff.Changes(check, updated)
+	if err != nil {
+		return err
 	}
 
-	if _, found := wantedProbes["all"]; found {
-		for _, probe := range probes {
-			check.Probes = append(check.Probes, probe.Id)
+	if len(changes) == 0 {
+		return c.showChanges(ctx, check, changes)
+	}
+
+	if ctx.Bool("dry-run") {
+		return c.showChanges(ctx, &updated, changes)
+	}
+
+	newCheck, err := smClient.UpdateCheck(ctx.Context, updated)
+	if err != nil {
+		return fmt.Errorf("updating check: %w", err)
+	}
+
+	return c.showChanges(ctx, newCheck, changes)
+}
+
+// applyCheckUpdate returns a copy of check with the changes requested
+// using command line flags applied.
+func (c ChecksClient) applyCheckUpdate(ctx *cli.Context, smClient *smapi.Client, check model.Check) (model.Check, error) {
+	// The settings might be changed in place below, so work on a copy
+	// to avoid modifying the original check.
+	settings, err := cloneSettings(check.Settings)
+	if err != nil {
+		return check, err
+	}
+
+	check.Settings = settings
+
+	if ctx.IsSet("frequency") {
+		check.Frequency = ctx.Duration("frequency").Milliseconds()
+	}
+
+	if ctx.IsSet("timeout") {
+		check.Timeout = ctx.Duration("timeout").Milliseconds()
+	}
+
+	if ctx.IsSet("enabled") {
+		check.Enabled = ctx.Bool("enabled")
+	}
+
+	if ctx.IsSet("folder-uid") {
+		check.FolderUid = ctx.String("folder-uid")
+	}
+
+	if ctx.IsSet("label") {
+		labels, err := parseLabels(ctx.StringSlice("label"))
+		if err != nil {
+			return check, err
 		}
-	} else {
-		for _, probe := range probes {
-			if _, found := wantedProbes[strings.ToLower(probe.Name)]; found {
-				check.Probes = append(check.Probes, probe.Id)
-			} else if _, found := wantedProbes[idToStr(probe.Id)]; found {
-				check.Probes = append(check.Probes, probe.Id)
-			}
+
+		check.Labels = labels
+	}
+
+	if ctx.IsSet("settings") {
+		var patch map[string]any
+		if err := readJsonArg(ctx.String("settings"), &patch); err != nil {
+			return check, fmt.Errorf("reading settings: %w", err)
+		}
+
+		if check.Settings, err = mergeSettings(check.Settings, patch); err != nil {
+			return check, fmt.Errorf("updating settings: %w", err)
 		}
 	}
 
-	if err := check.Validate(); err != nil {
-		return fmt.Errorf("invalid check: %w", err)
+	if ctx.IsSet("script") {
+		script, err := readFileArg(ctx.String("script"))
+		if err != nil {
+			return check, fmt.Errorf("reading script: %w", err)
+		}
+
+		switch {
+		case check.Settings.Scripted != nil:
+			check.Settings.Scripted.Script = script
+
+		case check.Settings.Browser != nil:
+			check.Settings.Browser.Script = script
+
+		default:
+			return check, fmt.Errorf("%s check: %w", check.Type(), errCheckHasNoScript)
+		}
 	}
 
-	newCheck, err := smClient.AddCheck(ctx.Context, model.Check{Check: check, FolderUid: ctx.String("folder-uid")})
+	if ctx.IsSet("probes") {
+		probes, err := smClient.ListProbes(ctx.Context)
+		if err != nil {
+			return check, fmt.Errorf("getting probes: %w", err)
+		}
+
+		check.Probes, err = selectProbes(ctx.StringSlice("probes"), probes, probeFilter(check.Type()))
+		if err != nil {
+			return check, err
+		}
+	}
+
+	return check, nil
+}
+
+// cloneSettings returns a deep copy of settings.
+func cloneSettings(settings sm.CheckSettings) (sm.CheckSettings, error) {
+	var clone sm.CheckSettings
+
+	buf, err := json.Marshal(settings)
 	if err != nil {
-		return fmt.Errorf("adding check: %w", err)
+		return clone, fmt.Errorf("marshaling settings: %w", err)
 	}
 
+	if err := json.Unmarshal(buf, &clone); err != nil {
+		return clone, fmt.Errorf("unmarshaling settings: %w", err)
+	}
+
+	return clone, nil
+}
+
+// mergeSettings returns settings with patch merged into them, following
+// the JSON merge patch rules (RFC 7396): objects are merged recursively,
+// null values remove the corresponding field and any other value replaces
+// the existing one. The patch cannot change the type of the check, or
+// remove its settings altogether.
+func mergeSettings(settings sm.CheckSettings, patch map[string]any) (sm.CheckSettings, error) {
+	var (
+		current map[string]any
+		merged  sm.CheckSettings
+	)
+
+	buf, err := json.Marshal(settings)
+	if err != nil {
+		return merged, fmt.Errorf("marshaling settings: %w", err)
+	}
+
+	if err := json.Unmarshal(buf, &current); err != nil {
+		return merged, fmt.Errorf("unmarshaling settings: %w", err)
+	}
+
+	buf, err = json.Marshal(mergePatch(current, patch))
+	if err != nil {
+		return merged, fmt.Errorf("marshaling settings: %w", err)
+	}
+
+	if err := json.Unmarshal(buf, &merged); err != nil {
+		return merged, fmt.Errorf("unmarshaling settings: %w", err)
+	}
+
+	// Check the type here, as sm.Check.Type panics when the settings
+	// don't have exactly one type.
+	before, okBefore := settingsType(settings)
+	after, okAfter := settingsType(merged)
+
+	if !okBefore || !okAfter || after != before {
+		return merged, errCheckTypeChange
+	}
+
+	return merged, nil
+}
+
+// settingsType returns the type of the check with the given settings. It
+// returns false unless the settings are for exactly one type of check.
+func settingsType(settings sm.CheckSettings) (sm.CheckType, bool) {
+	var types []sm.CheckType
+
+	for t, set := range map[sm.CheckType]bool{
+		sm.CheckTypeDns:        settings.Dns != nil,
+		sm.CheckTypeHttp:       settings.Http != nil,
+		sm.CheckTypePing:       settings.Ping != nil,
+		sm.CheckTypeTcp:        settings.Tcp != nil,
+		sm.CheckTypeTraceroute: settings.Traceroute != nil,
+		sm.CheckTypeScripted:   settings.Scripted != nil,
+		sm.CheckTypeMultiHttp:  settings.Multihttp != nil,
+		sm.CheckTypeGrpc:       settings.Grpc != nil,
+		sm.CheckTypeBrowser:    settings.Browser != nil,
+	} {
+		if set {
+			types = append(types, t)
+		}
+	}
+
+	if len(types) != 1 {
+		return 0, false
+	}
+
+	return types[0], true
+}
+
+func mergePatch(dst, patch map[string]any) map[string]any {
+	if dst == nil {
+		dst = make(map[string]any)
+	}
+
+	for key, value := range patch {
+		switch value := value.(type) {
+		case nil:
+			delete(dst, key)
+
+		case map[string]any:
+			current, _ := dst[key].(map[string]any)
+			dst[key] = mergePatch(current, value)
+
+		default:
+			dst[key] = value
+		}
+	}
+
+	return dst
+}
+
+// showChanges writes the check and the list of changes made to it.
+func (c ChecksClient) showChanges(ctx *cli.Context, check *model.Check, changes []string) error {
 	jsonWriter := c.JsonWriterBuilder(ctx)
 
-	if done, err := jsonWriter(newCheck, "marshaling check"); err != nil || done {
+	if done, err := jsonWriter(check, "marshaling check"); err != nil || done {
 		return err
 	}
 
-	return c.showCheck(ctx, os.Stdout, newCheck)
+	w := c.TabWriterBuilder(ctx)
+
+	if len(changes) == 0 {
+		fmt.Fprintln(w, "no changes")
+	}
+
+	for _, change := range changes {
+		fmt.Fprintln(w, change)
+	}
+
+	if err := w.Flush(); err != nil {
+		return fmt.Errorf("flushing output: %w", err)
+	}
+
+	return nil
 }
 
 func (c ChecksClient) checkDelete(ctx *cli.Context) error {
+	if !ctx.IsSet("id") && !ctx.IsSet("selector") {
+		return errCheckDeleteTarget
+	}
+
+	selector, err := parseSelector(ctx.String("selector"))
+	if err != nil {
+		return err
+	}
+
+	// An empty selector matches every check, which is most likely the
+	// result of an unset variable rather than a request to delete all
+	// the checks.
+	if ctx.IsSet("selector") && len(selector) == 0 {
+		return errEmptySelector
+	}
+
 	smClient, cleanup, err := c.ClientBuilder(ctx)
 	if err != nil {
 		return err
 	}
 	defer func() { _ = cleanup(ctx.Context) }()
 
-	for _, id := range ctx.Int64Slice("id") {
+	ids := ctx.Int64Slice("id")
+
+	if ctx.IsSet("selector") {
+		checks, err := smClient.ListChecks(ctx.Context)
+		if err != nil {
+			return fmt.Errorf("listing checks: %w", err)
+		}
+
+		for _, check := range checks {
+			if selector.Matches(check.Labels) {
+				ids = append(ids, check.Id)
+			}
+		}
+	}
+
+	for _, id := range ids {
 		err := smClient.DeleteCheck(ctx.Context, id)
 		if err != nil {
 			return fmt.Errorf("deleting check %d: %w", id, err)
@@ -743,7 +1291,7 @@ func (c ChecksClient) checkDelete(ctx *cli.Context) error {
 	return nil
 }
 
-func (c ChecksClient) showCheck(ctx *cli.Context, output io.Writer, check *model.Check) error {
+func (c ChecksClient) showCheck(ctx *cli.Context, check *model.Check) error {
 	w := c.TabWriterBuilder(ctx)
 	fmt.Fprintf(w, "%s:\t%d\n", "id", check.Id)
 	fmt.Fprintf(w, "%s:\t%s\n", "type", check.Type())
@@ -752,6 +1300,13 @@ func (c ChecksClient) showCheck(ctx *cli.Context, output io.Writer, check *model
 	fmt.Fprintf(w, "%s:\t%t\n", "enabled", check.Enabled)
 	fmt.Fprintf(w, "%s:\t%s\n", "frequency", time.Duration(check.Frequency)*time.Millisecond)
 	fmt.Fprintf(w, "%s:\t%s\n", "timeout", time.Duration(check.Timeout)*time.Millisecond)
+	if size := scriptSize(check.Check); size != "" {
+		fmt.Fprintf(w, "%s:\t%s\n", "script", size)
+	}
+	if check.Channels != nil && check.Channels.K6 != nil {
+		fmt.Fprintf(w, "%s:\t%s\n", "channel", check.Channels.K6.Id)
+	}
+	fmt.Fprintf(w, "%s:\t%s\n", "labels", formatLabels(check.Labels))
 	fmt.Fprintf(w, "%s:\t%s\n", "folder-uid", check.FolderUid)
 	fmt.Fprintf(w, "%s:\t%s\n", "created", formatSMTime(check.Created))
 	fmt.Fprintf(w, "%s:\t%s\n", "modified", formatSMTime(check.Modified))
@@ -763,6 +1318,34 @@ func (c ChecksClient) showCheck(ctx *cli.Context, output io.Writer, check *model
 	return nil
 }
 
+// scriptSize returns the size of the k6 script run by check, or an empty
+// string if the check doesn't run one.
+func scriptSize(check sm.Check) string {
+	var script []byte
+
+	switch {
+	case check.Settings.Scripted != nil:
+		script = check.Settings.Scripted.Script
+	case check.Settings.Browser != nil:
+		script = check.Settings.Browser.Script
+	default:
+		return ""
+	}
+
+	return strconv.Itoa(len(script)) + " bytes"
+}
+
+// writeWideCheckColumns writes the columns added to the list of checks by
+// the wide output format.
+func writeWideCheckColumns(w io.Writer, check sm.Check) {
+	probes := make([]string, 0, len(check.Probes))
+	for _, id := range check.Probes {
+		probes = append(probes, idToStr(id))
+	}
+
+	fmt.Fprintf(w, "\t%s\t%s\t%t\t%s", strings.Join(probes, ","), check.AlertSensitivity, check.BasicMetricsOnly, formatSMTime(check.Modified))
+}
+
 func valueToString(value interface{}) string {
 	buf, err := json.Marshal(value)
 	if err != nil {
@@ -0,0 +1,611 @@
+package cli
+
+import (
+	"encoding/json"
+	"net/http"
+	"os"
+	"testing"
+
+	sm "github.com/grafana/synthetic-monitoring-agent/pkg/pb/synthetic_monitoring"
+	"github.com/grafana/synthetic-monitoring-api-go-client/model"
+	"github.com/grafana/synthetic-monitoring-api-go-client/multihttp"
+	"github.com/stretchr/testify/require"
+)
+
+func TestMergePatch(t *testing.T) {
+	testcases := map[string]struct {
+		dst      map[string]any
+		patch    map[string]any
+		expected map[string]any
+	}{
+		"nil destination": {
+			dst:      nil,
+			patch:    map[string]any{"a": 1.0},
+			expected: map[string]any{"a": 1.0},
+		},
+		"replace value": {
+			dst:      map[string]any{"a": 1.0, "b": "x"},
+			patch:    map[string]any{"a": 2.0},
+			expected: map[string]any{"a": 2.0, "b": "x"},
+		},
+		"remove value": {
+			dst:      map[string]any{"a": 1.0, "b": "x"},
+			patch:    map[string]any{"b": nil},
+			expected: map[string]any{"a": 1.0},
+		},
+		"remove missing value": {
+			dst:      map[string]any{"a": 1.0},
+			patch:    map[string]any{"b": nil},
+			expected: map[string]any{"a": 1.0},
+		},
+		"merge objects": {
+			dst:      map[string]any{"http": map[string]any{"method": "GET", "body": "x"}},
+			patch:    map[string]any{"http": map[string]any{"method": "POST"}},
+			expected: map[string]any{"http": map[string]any{"method": "POST", "body": "x"}},
+		},
+		"object replaces scalar": {
+			dst:      map[string]any{"a": "x"},
+			patch:    map[string]any{"a": map[string]any{"b": true}},
+			expected: map[string]any{"a": map[string]any{"b": true}},
+		},
+		"arrays are replaced": {
+			dst:      map[string]any{"a": []any{1.0, 2.0}},
+			patch:    map[string]any{"a": []any{3.0}},
+			expected: map[string]any{"a": []any{3.0}},
+		},
+	}
+
+	for name, tc := range testcases {
+		t.Run(name, func(t *testing.T) {
+			require.Equal(t, tc.expected, mergePatch(tc.dst, tc.patch))
+		})
+	}
+}
+
+func TestMergeSettings(t *testing.T) {
+	settings := sm.CheckSettings{
+		Http: &sm.HttpSettings{
+			Method:  sm.HttpMethod_GET,
+			Headers: []string{"Accept: text/html"},
+			Body:    "hello",
+		},
+	}
+
+	merged, err := mergeSettings(settings, map[string]any{
+		"http": map[string]any{
+			"method":  "POST",
+			"headers": []any{"Accept: application/json"},
+			"body":    nil,
+		},
+	})
+	require.NoError(t, err)
+	require.Equal(t, sm.HttpMethod_POST, merged.Http.Method)
+	require.Equal(t, []string{"Accept: application/json"}, merged.Http.Headers)
+	require.Empty(t, merged.Http.Body)
+
+	// The original settings are not modified.
+	require.Equal(t, sm.HttpMethod_GET, settings.Http.Method)
+	require.Equal(t, "hello", settings.Http.Body)
+
+	_, err = mergeSettings(settings, map[string]any{"http": map[string]any{"method": "NOPE"}})
+	require.Error(t, err)
+}
+
+func TestMergeSettingsCheckType(t *testing.T) {
+	settings := sm.CheckSettings{Http: &sm.HttpSettings{Method: sm.HttpMethod_GET}}
+
+	testcases := map[string]struct {
+		patch map[string]any
+		err   error
+	}{
+		"same type": {
+			patch: map[string]any{"http": map[string]any{"body": "x"}},
+		},
+		"empty patch": {
+			patch: map[string]any{},
+		},
+		"remove settings": {
+			patch: map[string]any{"http": nil},
+			err:   errCheckTypeChange,
+		},
+		"change type": {
+			patch: map[string]any{"http": nil, "ping": map[string]any{}},
+			err:   errCheckTypeChange,
+		},
+		"add type": {
+			patch: map[string]any{"ping": map[string]any{}},
+			err:   errCheckTypeChange,
+		},
+		"unknown type": {
+			patch: map[string]any{"http": nil, "smtp": map[string]any{}},
+			err:   errCheckTypeChange,
+		},
+	}
+
+	for name, tc := range testcases {
+		t.Run(name, func(t *testing.T) {
+			merged, err := mergeSettings(settings, tc.patch)
+			if tc.err != nil {
+				require.ErrorIs(t, err, tc.err)
+				return
+			}
+
+			require.NoError(t, err)
+
+			// The type of the merged settings can be used safely.
+			require.Equal(t, sm.CheckTypeHttp, sm.Check{Settings: merged}.Type())
+		})
+	}
+}
+
+// testProbes returns probes with different capabilities.
+func testProbes() []sm.Probe {
+	return []sm.Probe{
+		{Id: 1, Name: "Atlanta", Public: true},
+		{Id: 2, Name: "Paris", Public: true, Capabilities: &sm.Probe_Capabilities{DisableScriptedChecks: true}},
+		{Id: 3, Name: "office", Capabilities: &sm.Probe_Capabilities{DisableBrowserChecks: true}},
+		{Id: 4, Name: "lab", Capabilities: &sm.Probe_Capabilities{}},
+	}
+}
+
+// newChecksTestAPI returns the URL of an API server with probes, adding
+// checks to added and listing them.
+func newChecksTestAPI(t *testing.T, probes []sm.Probe, added *[]model.Check) string {
+	t.Helper()
+
+	return newTestAPI(t, func(method, path string, body []byte) (int, any) {
+		switch path {
+		case "/probe/list":
+			return http.StatusOK, probes
+
+		case "/tenant":
+			return http.StatusOK, sm.Tenant{Id: 1000}
+
+		case "/check/list":
+			return http.StatusOK, append([]model.Check{}, *added...)
+
+		case "/check/add":
+			var check model.Check
+			require.NoError(t, json.Unmarshal(body, &check))
+
+			check.Id = int64(len(*added) + 1)
+			*added = append(*added, check)
+
+			return http.StatusOK, check
+
+		default:
+			return http.StatusNotFound, model.ResponseError{Msg: "not found"}
+		}
+	})
+}
+
+// addTestCheck runs the check add command with args, and returns the
+// check sent to the API.
+func addTestCheck(t *testing.T, args ...string) (model.Check, error) {
+	t.Helper()
+
+	var added []model.Check
+
+	url := newChecksTestAPI(t, testProbes(), &added)
+	commands := GetCheckCommands(ChecksClient(newTestServiceClient(url)))
+
+	_, _, err := runCommands(commands, "", append([]string{"add"}, args...)...)
+	if err != nil {
+		return model.Check{}, err
+	}
+
+	require.Len(t, added, 1)
+
+	return added[0], nil
+}
+
+func TestSelectProbes(t *testing.T) {
+	testcases := map[string]struct {
+		wanted    []string
+		supported func(sm.Probe) bool
+		expected  []int64
+		err       error
+	}{
+		"all": {
+			wanted:    []string{"all"},
+			supported: anyProbe,
+			expected:  []int64{1, 2, 3, 4},
+		},
+		"all scripted": {
+			wanted:    []string{"all"},
+			supported: scriptedProbe,
+			expected:  []int64{1, 3, 4},
+		},
+		"by name": {
+			wanted:    []string{" paris ", "Office"},
+			supported: anyProbe,
+			expected:  []int64{2, 3},
+		},
+		"by ID": {
+			wanted:    []string{"4", "1"},
+			supported: scriptedProbe,
+			expected:  []int64{1, 4},
+		},
+		"all and name": {
+			wanted:    []string{"all", "lab"},
+			supported: scriptedProbe,
+			expected:  []int64{1, 3, 4},
+		},
+		"unsupported by name": {
+			wanted:    []string{"Atlanta", "Paris"},
+			supported: scriptedProbe,
+			err:       errProbeCapability,
+		},
+		"unsupported by ID": {
+			wanted:    []string{"2"},
+			supported: scriptedProbe,
+			err:       errProbeCapability,
+		},
+		"all browser": {
+			wanted:    []string{"all"},
+			supported: browserProbe,
+			expected:  []int64{1, 2, 4},
+		},
+		"browser by name": {
+			wanted:    []string{"Paris", "lab"},
+			supported: browserProbe,
+			expected:  []int64{2, 4},
+		},
+		"unsupported browser": {
+			wanted:    []string{"lab", "office"},
+			supported: browserProbe,
+			err:       errProbeCapability,
+		},
+		"unknown probe": {
+			wanted:    []string{"Tokyo"},
+			supported: anyProbe,
+			err:       errNoProbes,
+		},
+		"no probes": {
+			wanted:    nil,
+			supported: anyProbe,
+			err:       errNoProbes,
+		},
+	}
+
+	for name, tc := range testcases {
+		t.Run(name, func(t *testing.T) {
+			ids, err := selectProbes(tc.wanted, testProbes(), tc.supported)
+			if tc.err != nil {
+				require.ErrorIs(t, err, tc.err)
+				return
+			}
+
+			require.NoError(t, err)
+			require.Equal(t, tc.expected, ids)
+		})
+	}
+}
+
+func TestCheckAddScripted(t *testing.T) {
+	const script = "import http from 'k6/http';\n\nexport default function() {\n  http.get('https://example.org/');\n}\n"
+
+	path := writeTestFile(t, "script.js", script)
+
+	testcases := map[string]struct {
+		args     []string
+		script   string
+		expected []int64
+		err      error
+	}{
+		"script file": {
+			args:     []string{"--script", "@" + path},
+			script:   script,
+			expected: []int64{1, 3, 4},
+		},
+		"inline script": {
+			args:     []string{"--script", "export default function() {}"},
+			script:   "export default function() {}",
+			expected: []int64{1, 3, 4},
+		},
+		"selected probes": {
+			args:     []string{"--script", "@" + path, "--probes", "office", "--probes", "4"},
+			script:   script,
+			expected: []int64{3, 4},
+		},
+		"unsupported probe": {
+			args: []string{"--script", "@" + path, "--probes", "Paris"},
+			err:  errProbeCapability,
+		},
+		"missing script file": {
+			args: []string{"--script", "@" + path + ".missing"},
+			err:  os.ErrNotExist,
+		},
+	}
+
+	for name, tc := range testcases {
+		t.Run(name, func(t *testing.T) {
+			args := append([]string{"scripted", "--job", "homepage", "--target", "https://example.org/"}, tc.args...)
+
+			check, err := addTestCheck(t, args...)
+			if tc.err != nil {
+				require.ErrorIs(t, err, tc.err)
+				return
+			}
+
+			require.NoError(t, err)
+			require.Equal(t, sm.CheckTypeScripted, check.Type())
+			require.Equal(t, tc.script, string(check.Settings.Scripted.Script))
+			require.Equal(t, tc.expected, check.Probes)
+		})
+	}
+}
+
+func TestCheckAddMultiHttp(t *testing.T) {
+	const definition = "steps:\n  - url: https://example.org/login\n    method: POST\n  - url: https://example.org/profile\n"
+
+	testcases := map[string]struct {
+		file    string
+		stdin   string
+		entries int
+		err     error
+		errText string
+	}{
+		"file": {
+			file:    definition,
+			entries: 2,
+		},
+		"stdin": {
+			stdin:   definition,
+			entries: 2,
+		},
+		"empty file": {
+			file: "",
+			err:  multihttp.ErrEmptyDefinition,
+		},
+		"no steps": {
+			file: "logResponses: true\n",
+			err:  multihttp.ErrNoSteps,
+		},
+		"empty steps": {
+			file: "steps: []\n",
+			err:  multihttp.ErrNoSteps,
+		},
+		"steps is not a list": {
+			file:    "steps: {url: https://example.org/}\n",
+			errText: "parsing definition",
+		},
+		"invalid step": {
+			file: "steps:\n  - url: https://example.org/\n    method: FETCH\n",
+			err:  multihttp.ErrInvalidMethod,
+		},
+	}
+
+	for name, tc := range testcases {
+		t.Run(name, func(t *testing.T) {
+			var added []model.Check
+
+			url := newChecksTestAPI(t, testProbes(), &added)
+			commands := GetCheckCommands(ChecksClient(newTestServiceClient(url)))
+
+			file := "-"
+			if tc.stdin == "" {
+				file = writeTestFile(t, "multihttp.yaml", tc.file)
+			}
+
+			_, _, err := runCommands(commands, tc.stdin, "add", "multihttp", "--job", "login", "--target", "https://example.org/", "--file", file)
+
+			switch {
+			case tc.err != nil:
+				require.ErrorIs(t, err, tc.err)
+				require.Empty(t, added)
+
+			case tc.errText != "":
+				require.ErrorContains(t, err, tc.errText)
+				require.Empty(t, added)
+
+			default:
+				require.NoError(t, err)
+				require.Len(t, added, 1)
+				require.Equal(t, sm.CheckTypeMultiHttp, added[0].Type())
+				require.Len(t, added[0].Settings.Multihttp.Entries, tc.entries)
+				require.Equal(t, []int64{1, 3, 4}, added[0].Probes)
+			}
+		})
+	}
+}
+
+func TestCheckAddHttp(t *testing.T) {
+	check, err := addTestCheck(t, "http",
+		"--job", "homepage",
+		"--target", "https://example.org/",
+		"--method", "POST",
+		"--valid-status-codes", "200",
+		"--valid-status-codes", "204",
+		"--cache-busting-parameter-name", "cb",
+		"--no-follow-redirects",
+	)
+	require.NoError(t, err)
+
+	require.Equal(t, &sm.HttpSettings{
+		Method:                     sm.HttpMethod_POST,
+		ValidStatusCodes:           []int32{200, 204},
+		CacheBustingQueryParamName: "cb",
+		NoFollowRedirects:          true,
+	}, check.Settings.Http)
+	require.Equal(t, []int64{1, 2, 3, 4}, check.Probes)
+}
+
+func TestSelectProbesNoneSupported(t *testing.T) {
+	probes := []sm.Probe{
+		{Id: 1, Name: "office", Capabilities: &sm.Probe_Capabilities{DisableBrowserChecks: true}},
+		{Id: 2, Name: "lab", Capabilities: &sm.Probe_Capabilities{DisableScriptedChecks: true, DisableBrowserChecks: true}},
+	}
+
+	_, err := selectProbes([]string{"all"}, probes, browserProbe)
+	require.ErrorIs(t, err, errNoProbes)
+
+	ids, err := selectProbes([]string{"all"}, probes, scriptedProbe)
+	require.NoError(t, err)
+	require.Equal(t, []int64{1}, ids)
+}
+
+func TestCheckAddBrowser(t *testing.T) {
+	const script = "import { browser } from 'k6/browser';\n\nexport default async function() {}\n"
+
+	path := writeTestFile(t, "browser.js", script)
+
+	testcases := map[string]struct {
+		args     []string
+		expected []int64
+		channel  string
+		err      error
+	}{
+		"all probes": {
+			expected: []int64{1, 2, 4},
+		},
+		"channel": {
+			args:     []string{"--channel", "v1"},
+			expected: []int64{1, 2, 4},
+			channel:  "v1",
+		},
+		"selected probes": {
+			args:     []string{"--probes", "Paris"},
+			expected: []int64{2},
+		},
+		"unsupported probe": {
+			args: []string{"--probes", "office"},
+			err:  errProbeCapability,
+		},
+		"no probes": {
+			args: []string{"--probes", "Tokyo"},
+			err:  errNoProbes,
+		},
+	}
+
+	for name, tc := range testcases {
+		t.Run(name, func(t *testing.T) {
+			args := append([]string{"browser", "--job", "homepage", "--target", "https://example.org/", "--script", "@" + path}, tc.args...)
+
+			check, err := addTestCheck(t, args...)
+			if tc.err != nil {
+				require.ErrorIs(t, err, tc.err)
+				return
+			}
+
+			require.NoError(t, err)
+			require.Equal(t, sm.CheckTypeBrowser, check.Type())
+			require.Equal(t, script, string(check.Settings.Browser.Script))
+			require.Equal(t, tc.expected, check.Probes)
+
+			if tc.channel == "" {
+				require.Nil(t, check.Channels)
+			} else {
+				require.Equal(t, tc.channel, check.Channels.K6.Id)
+			}
+		})
+	}
+}
+
+func TestCheckAddGrpc(t *testing.T) {
+	testcases := map[string]struct {
+		args     []string
+		expected *sm.GrpcSettings
+		err      bool
+	}{
+		"defaults": {
+			expected: &sm.GrpcSettings{IpVersion: sm.IpVersion_Any, TlsConfig: &sm.TLSConfig{}},
+		},
+		"service": {
+			args:     []string{"--service", "grpc.health.v1.Health", "--ip-version", "V6"},
+			expected: &sm.GrpcSettings{IpVersion: sm.IpVersion_V6, Service: "grpc.health.v1.Health", TlsConfig: &sm.TLSConfig{}},
+		},
+		"tls": {
+			args: []string{
+				"--tls",
+				"--tls-insecure-skip-verify",
+				"--tls-server-name", "grpc.example.org",
+				"--tls-ca-cert", "ca",
+				"--tls-client-cert", "cert",
+				"--tls-client-key", "key",
+			},
+			expected: &sm.GrpcSettings{
+				Tls: true,
+				TlsConfig: &sm.TLSConfig{
+					InsecureSkipVerify: true,
+					ServerName:         "grpc.example.org",
+					CACert:             []byte("ca"),
+					ClientCert:         []byte("cert"),
+					ClientKey:          []byte("key"),
+				},
+			},
+		},
+		"invalid ip version": {
+			args: []string{"--ip-version", "V5"},
+			err:  true,
+		},
+	}
+
+	for name, tc := range testcases {
+		t.Run(name, func(t *testing.T) {
+			args := append([]string{"grpc", "--job", "health", "--target", "grpc.example.org:443"}, tc.args...)
+
+			check, err := addTestCheck(t, args...)
+			if tc.err {
+				require.Error(t, err)
+				return
+			}
+
+			require.NoError(t, err)
+			require.Equal(t, tc.expected, check.Settings.Grpc)
+		})
+	}
+}
+
+func TestCheckAddTraceroute(t *testing.T) {
+	testcases := map[string]struct {
+		args      []string
+		expected  *sm.TracerouteSettings
+		frequency int64
+		timeout   int64
+		err       bool
+	}{
+		"defaults": {
+			expected:  &sm.TracerouteSettings{MaxHops: 64, MaxUnknownHops: 15, PtrLookup: true},
+			frequency: 120000,
+			timeout:   30000,
+		},
+		"hops": {
+			args:      []string{"--max-hops", "30", "--max-unknown-hops", "5"},
+			expected:  &sm.TracerouteSettings{MaxHops: 30, MaxUnknownHops: 5, PtrLookup: true},
+			frequency: 120000,
+			timeout:   30000,
+		},
+		"no ptr lookup": {
+			args:      []string{"--ptr-lookup=false", "--frequency", "5m"},
+			expected:  &sm.TracerouteSettings{MaxHops: 64, MaxUnknownHops: 15},
+			frequency: 300000,
+			timeout:   30000,
+		},
+		"invalid hops": {
+			args: []string{"--max-hops", "many"},
+			err:  true,
+		},
+		"short timeout": {
+			args: []string{"--timeout", "5s"},
+			err:  true,
+		},
+	}
+
+	for name, tc := range testcases {
+		t.Run(name, func(t *testing.T) {
+			args := append([]string{"traceroute", "--job", "route", "--target", "example.org"}, tc.args...)
+
+			check, err := addTestCheck(t, args...)
+			if tc.err {
+				require.Error(t, err)
+				return
+			}
+
+			require.NoError(t, err)
+			require.Equal(t, tc.expected, check.Settings.Traceroute)
+			require.Equal(t, tc.frequency, check.Frequency)
+			require.Equal(t, tc.timeout, check.Timeout)
+		})
+	}
+}
@@ -6,9 +6,12 @@ import (
 	"fmt"
 	"io"
 	"os"
+	"strings"
 	"time"
 
+	sm "github.com/grafana/synthetic-monitoring-agent/pkg/pb/synthetic_monitoring"
 	smapi "github.com/grafana/synthetic-monitoring-api-go-client"
+	"github.com/grafana/synthetic-monitoring-api-go-client/cli/output"
 	"github.com/urfave/cli/v2"
 )
 
@@ -23,26 +26,126 @@ type ServiceClient struct {
 	TabWriterBuilder  func(*cli.Context) WriteFlusher
 }
 
+// OutputFormat returns the output format selected using the --output flag.
+// The --json flag is kept as a shorthand for --output json.
+func OutputFormat(ctx *cli.Context) (output.Format, error) {
+	if ctx.Bool("json") && !ctx.IsSet("output") {
+		return output.ParseFormat(output.JSON)
+	}
+
+	return output.ParseFormat(ctx.String("output"))
+}
+
+// wideOutput reports whether the tables should include additional columns.
+func wideOutput(ctx *cli.Context) bool {
+	return ctx.String("output") == output.Wide
+}
+
 func formatSMTime(t float64) string {
 	return time.Unix(int64(t), 0).Format(time.RFC3339)
 }
 
-func readJsonArg(arg string, dst interface{}) error {
-	var buf []byte
+// parseLabels parses labels in the form "name=value".
+func parseLabels(labels []string) ([]sm.Label, error) {
+	result := make([]sm.Label, 0, len(labels))
 
-	if len(arg) > 0 && arg[0] == '@' {
-		fh, err := os.Open(arg[1:])
-		if err != nil {
-			return fmt.Errorf("opening input: %w", err)
+	for _, label := range labels {
+		const labelParts = 2
+		parts := strings.SplitN(label, "=", labelParts)
+		if len(parts) != labelParts || parts[0] == "" {
+			return nil, fmt.Errorf("%q: %w", label, errInvalidLabel)
 		}
-		defer func() { _ = fh.Close() }()
+		result = append(result, sm.Label{
+			Name:  parts[0],
+			Value: parts[1],
+		})
+	}
+
+	return result, nil
+}
+
+// labelMatcher matches labels with the specified name and value or, if
+// negated, labels not having that value.
+type labelMatcher struct {
+	name    string
+	value   string
+	negated bool
+}
+
+// labelSelector selects objects having labels that match all the
+// matchers. An empty selector matches everything.
+type labelSelector []labelMatcher
 
-		buf, err = io.ReadAll(fh)
-		if err != nil {
-			return fmt.Errorf("reading input: %w", err)
+// parseSelector parses a comma-separated list of matchers in the form
+// "name=value" or "name!=value".
+func parseSelector(selector string) (labelSelector, error) {
+	var result labelSelector
+
+	if strings.TrimSpace(selector) == "" {
+		return result, nil
+	}
+
+	for _, expr := range strings.Split(selector, ",") {
+		var m labelMatcher
+
+		name, value, found := strings.Cut(expr, "=")
+		if !found {
+			return nil, fmt.Errorf("%q: %w", expr, errInvalidSelector)
+		}
+
+		if strings.HasSuffix(name, "!") {
+			name = strings.TrimSuffix(name, "!")
+			m.negated = true
 		}
-	} else {
-		buf = []byte(arg)
+
+		m.name = strings.TrimSpace(name)
+		m.value = strings.TrimSpace(value)
+
+		if m.name == "" {
+			return nil, fmt.Errorf("%q: %w", expr, errInvalidSelector)
+		}
+
+		result = append(result, m)
+	}
+
+	return result, nil
+}
+
+// Matches reports whether labels match all the matchers in s. A label
+// that is not present matches any negated matcher for it.
+func (s labelSelector) Matches(labels []sm.Label) bool {
+	for _, m := range s {
+		found := false
+
+		for _, label := range labels {
+			if label.Name == m.name && label.Value == m.value {
+				found = true
+				break
+			}
+		}
+
+		if found == m.negated {
+			return false
+		}
+	}
+
+	return true
+}
+
+func formatLabels(labels []sm.Label) string {
+	parts := make([]string, 0, len(labels))
+
+	for _, label := range labels {
+		parts = append(parts, label.Name+"="+label.Value)
+	}
+
+	return strings.Join(parts, ",")
+}
+
+func readJsonArg(arg string, dst interface{}) error {
+	buf, err := readFileArg(arg)
+	if err != nil {
+		return err
 	}
 
 	if err := json.Unmarshal(buf, dst); err != nil {
@@ -51,3 +154,24 @@ func readJsonArg(arg string, dst interface{}) error {
 
 	return nil
 }
+
+// readFileArg returns the contents of the file named by arg if it
+// starts with "@", or arg itself otherwise.
+func readFileArg(arg string) ([]byte, error) {
+	if len(arg) == 0 || arg[0] != '@' {
+		return []byte(arg), nil
+	}
+
+	fh, err := os.Open(arg[1:])
+	if err != nil {
+		return nil, fmt.Errorf("opening input: %w", err)
+	}
+	defer func() { _ = fh.Close() }()
+
+	buf, err := io.ReadAll(fh)
+	if err != nil {
+		return nil, fmt.Errorf("reading input: %w", err)
+	}
+
+	return buf, nil
+}
@@ -0,0 +1,284 @@
+package cli
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"text/tabwriter"
+
+	sm "github.com/grafana/synthetic-monitoring-agent/pkg/pb/synthetic_monitoring"
+	smapi "github.com/grafana/synthetic-monitoring-api-go-client"
+	"github.com/stretchr/testify/require"
+	"github.com/urfave/cli/v2"
+)
+
+// testHandler returns the status and the response for an API request to
+// path, without the /api/v1 prefix, with the given body. The paths of the
+// secrets API keep their /api/v1alpha1 prefix.
+type testHandler func(method, path string, body []byte) (int, any)
+
+// newTestAPI returns the URL of an API server answering requests using
+// handler.
+func newTestAPI(t *testing.T, handler testHandler) string {
+	t.Helper()
+
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		body, err := io.ReadAll(r.Body)
+		require.NoError(t, err)
+
+		path := r.URL.Path
+		if strings.HasPrefix(path, "/api/v1/") {
+			path = strings.TrimPrefix(path, "/api/v1")
+		}
+
+		status, resp := handler(r.Method, path, body)
+
+		w.Header().Set("Content-Type", "application/json")
+		w.WriteHeader(status)
+		require.NoError(t, json.NewEncoder(w).Encode(resp))
+	}))
+	t.Cleanup(server.Close)
+
+	return server.URL
+}
+
+// newTestServiceClient returns a ServiceClient using the API server at
+// url, and writing output as sm-client does.
+func newTestServiceClient(url string) ServiceClient {
+	return ServiceClient{
+		ClientBuilder: func(*cli.Context) (*smapi.Client, func(context.Context) error, error) {
+			client, err := smapi.New(url, smapi.WithAccessToken("token"))
+
+			return client, func(context.Context) error { return nil }, err
+		},
+		JsonWriterBuilder: func(ctx *cli.Context) func(interface{}, string) (bool, error) {
+			return func(value interface{}, errMsg string) (bool, error) {
+				format, err := OutputFormat(ctx)
+				if err != nil || !format.Structured() {
+					return err != nil, err
+				}
+
+				if err := format.Write(ctx.App.Writer, value); err != nil {
+					return true, fmt.Errorf("%s: %w", errMsg, err)
+				}
+
+				return true, nil
+			}
+		},
+		TabWriterBuilder: func(ctx *cli.Context) WriteFlusher {
+			return tabwriter.NewWriter(ctx.App.Writer, 0, 0, 2, ' ', 0)
+		},
+	}
+}
+
+// runCommands runs an application with the global flags and commands,
+// reading stdin, and returns what was written to stdout and stderr.
+func runCommands(commands cli.Commands, stdin string, args ...string) (string, string, error) {
+	var stdout, stderr bytes.Buffer
+
+	app := &cli.App{
+		Name:      "sm-client",
+		Flags:     testGlobalFlags(),
+		Commands:  commands,
+		Reader:    strings.NewReader(stdin),
+		Writer:    &stdout,
+		ErrWriter: &stderr,
+		// Return exit errors instead of exiting.
+		ExitErrHandler: func(*cli.Context, error) {},
+	}
+
+	err := app.Run(append([]string{"sm-client"}, args...))
+
+	return stdout.String(), stderr.String(), err
+}
+
+func writeTestFile(t *testing.T, name, content string) string {
+	t.Helper()
+
+	path := filepath.Join(t.TempDir(), name)
+	require.NoError(t, os.WriteFile(path, []byte(content), 0o600))
+
+	return path
+}
+
+func TestReadFileArg(t *testing.T) {
+	path := writeTestFile(t, "script.js", "export default function() {}\n")
+
+	testcases := map[string]struct {
+		arg      string
+		expected string
+		err      bool
+	}{
+		"literal":      {arg: "export default function() {}", expected: "export default function() {}"},
+		"empty":        {arg: "", expected: ""},
+		"file":         {arg: "@" + path, expected: "export default function() {}\n"},
+		"missing file": {arg: "@" + path + ".missing", err: true},
+		"no file name": {arg: "@", err: true},
+	}
+
+	for name, tc := range testcases {
+		t.Run(name, func(t *testing.T) {
+			buf, err := readFileArg(tc.arg)
+			if tc.err {
+				require.Error(t, err)
+				return
+			}
+
+			require.NoError(t, err)
+			require.Equal(t, tc.expected, string(buf))
+		})
+	}
+}
+
+func TestParseLabels(t *testing.T) {
+	testcases := map[string]struct {
+		input    []string
+		expected []sm.Label
+		err      error
+	}{
+		"none": {
+			input:    nil,
+			expected: []sm.Label{},
+		},
+		"labels": {
+			input:    []string{"team=sre", "env=prod"},
+			expected: []sm.Label{{Name: "team", Value: "sre"}, {Name: "env", Value: "prod"}},
+		},
+		"empty value": {
+			input:    []string{"team="},
+			expected: []sm.Label{{Name: "team", Value: ""}},
+		},
+		"equal sign in value": {
+			input:    []string{"query=a=b"},
+			expected: []sm.Label{{Name: "query", Value: "a=b"}},
+		},
+		"missing value": {
+			input: []string{"team"},
+			err:   errInvalidLabel,
+		},
+		"missing name": {
+			input: []string{"=sre"},
+			err:   errInvalidLabel,
+		},
+		"empty": {
+			input: []string{""},
+			err:   errInvalidLabel,
+		},
+	}
+
+	for name, tc := range testcases {
+		t.Run(name, func(t *testing.T) {
+			labels, err := parseLabels(tc.input)
+			if tc.err != nil {
+				require.ErrorIs(t, err, tc.err)
+				return
+			}
+
+			require.NoError(t, err)
+			require.Equal(t, tc.expected, labels)
+		})
+	}
+}
+
+func TestParseSelector(t *testing.T) {
+	testcases := map[string]struct {
+		input    string
+		expected labelSelector
+		err      error
+	}{
+		"empty": {
+			input: "",
+		},
+		"whitespace": {
+			input: "  ",
+		},
+		"single": {
+			input:    "team=sre",
+			expected: labelSelector{{name: "team", value: "sre"}},
+		},
+		"multiple": {
+			input: "team=sre, env != dev",
+			expected: labelSelector{
+				{name: "team", value: "sre"},
+				{name: "env", value: "dev", negated: true},
+			},
+		},
+		"empty value": {
+			input:    "team=",
+			expected: labelSelector{{name: "team", value: ""}},
+		},
+		"missing operator": {
+			input: "team",
+			err:   errInvalidSelector,
+		},
+		"missing name": {
+			input: "=sre",
+			err:   errInvalidSelector,
+		},
+		"missing negated name": {
+			input: "!=sre",
+			err:   errInvalidSelector,
+		},
+		"empty matcher": {
+			input: "team=sre,,env=prod",
+			err:   errInvalidSelector,
+		},
+		"trailing comma": {
+			input: "team=sre,",
+			err:   errInvalidSelector,
+		},
+	}
+
+	for name, tc := range testcases {
+		t.Run(name, func(t *testing.T) {
+			selector, err := parseSelector(tc.input)
+			if tc.err != nil {
+				require.ErrorIs(t, err, tc.err)
+				return
+			}
+
+			require.NoError(t, err)
+			require.Equal(t, tc.expected, selector)
+		})
+	}
+}
+
+func TestLabelSelectorMatches(t *testing.T) {
+	labels := []sm.Label{{Name: "team", Value: "sre"}, {Name: "env", Value: "prod"}}
+
+	testcases := map[string]struct {
+		selector string
+		expected bool
+	}{
+		"empty":                   {selector: "", expected: true},
+		"match":                   {selector: "team=sre", expected: true},
+		"all match":               {selector: "team=sre,env=prod", expected: true},
+		"one does not match":      {selector: "team=sre,env=dev", expected: false},
+		"different value":         {selector: "team=web", expected: false},
+		"missing label":           {selector: "region=eu", expected: false},
+		"negated match":           {selector: "env!=dev", expected: true},
+		"negated no match":        {selector: "env!=prod", expected: false},
+		"negated missing label":   {selector: "region!=eu", expected: true},
+		"empty value not present": {selector: "region=", expected: false},
+	}
+
+	for name, tc := range testcases {
+		t.Run(name, func(t *testing.T) {
+			selector, err := parseSelector(tc.selector)
+			require.NoError(t, err)
+			require.Equal(t, tc.expected, selector.Matches(labels))
+		})
+	}
+
+	selector, err := parseSelector("team=sre")
+	require.NoError(t, err)
+	require.False(t, selector.Matches(nil))
+}
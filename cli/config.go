@@ -0,0 +1,392 @@
+package cli
+
+import (
+	"errors"
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"sort"
+	"strconv"
+
+	"github.com/grafana/synthetic-monitoring-api-go-client/cli/output"
+	"github.com/urfave/cli/v2"
+	"go.yaml.in/yaml/v3"
+)
+
+var (
+	errUnknownContext = errors.New("unknown context")
+	errContextName    = errors.New("a single context name is required, after any flags")
+)
+
+// Config is the sm-client configuration file. It holds named contexts,
+// each with the settings needed to access a tenant.
+type Config struct {
+	CurrentContext string                    `yaml:"current-context,omitempty"`
+	Contexts       map[string]*ConfigContext `yaml:"contexts,omitempty"`
+}
+
+// ConfigContext holds default values for the global flags. The field names
+// match the names of the flags.
+type ConfigContext struct {
+	URL               string `yaml:"sm-api-url,omitempty"`
+	Token             string `yaml:"sm-api-token,omitempty"`
+	TokenCommand      string `yaml:"sm-api-token-command,omitempty"`
+	GrafanaInstanceID int64  `yaml:"grafana-instance-id,omitempty"`
+	MetricsInstanceID int64  `yaml:"metrics-instance-id,omitempty"`
+	LogsInstanceID    int64  `yaml:"logs-instance-id,omitempty"`
+	PublisherToken    string `yaml:"publisher-token,omitempty"`
+	// Output is the default output format, as accepted by --output.
+	Output string `yaml:"output,omitempty"`
+	// AccessToken is the token obtained by the login command. It's only
+	// used if no other token is given.
+	AccessToken string `yaml:"access-token,omitempty"`
+}
+
+// DefaultConfigPath returns the path of the configuration file in the
+// user's configuration directory, e.g. ~/.config/sm-client/config.yaml.
+func DefaultConfigPath() (string, error) {
+	dir, err := os.UserConfigDir()
+	if err != nil {
+		return "", fmt.Errorf("getting configuration directory: %w", err)
+	}
+
+	return filepath.Join(dir, "sm-client", "config.yaml"), nil
+}
+
+// LoadConfig reads the configuration file at path. If the file doesn't
+// exist, an empty configuration is returned.
+func LoadConfig(path string) (*Config, error) {
+	var config Config
+
+	buf, err := os.ReadFile(path)
+	switch {
+	case errors.Is(err, fs.ErrNotExist):
+		return &config, nil
+
+	case err != nil:
+		return nil, fmt.Errorf("reading configuration: %w", err)
+	}
+
+	if err := yaml.Unmarshal(buf, &config); err != nil {
+		return nil, fmt.Errorf("parsing configuration %s: %w", path, err)
+	}
+
+	return &config, nil
+}
+
+// Save writes the configuration to path. As it can contain tokens, the
+// file is only readable by the user.
+func (c *Config) Save(path string) error {
+	buf, err := yaml.Marshal(c)
+	if err != nil {
+		return fmt.Errorf("marshaling configuration: %w", err)
+	}
+
+	dir := filepath.Dir(path)
+
+	if err := os.MkdirAll(dir, 0o700); err != nil {
+		return fmt.Errorf("creating configuration directory: %w", err)
+	}
+
+	// Write to a temporary file, created with mode 0600, and rename it,
+	// so that the configuration is never left half written.
+	fh, err := os.CreateTemp(dir, ".config-*.yaml")
+	if err != nil {
+		return fmt.Errorf("writing configuration: %w", err)
+	}
+	defer func() { _ = os.Remove(fh.Name()) }()
+
+	if _, err := fh.Write(buf); err != nil {
+		_ = fh.Close()
+		return fmt.Errorf("writing configuration: %w", err)
+	}
+
+	if err := fh.Close(); err != nil {
+		return fmt.Errorf("writing configuration: %w", err)
+	}
+
+	if err := os.Rename(fh.Name(), path); err != nil {
+		return fmt.Errorf("writing configuration: %w", err)
+	}
+
+	return nil
+}
+
+// ApplyConfig sets the global flags that were not set on the command line
+// or using environment variables to the values in the selected context of
+// the configuration file. It's meant to be used as the Before function of
+// the application.
+func ApplyConfig(ctx *cli.Context) error {
+	path := ctx.String("config")
+	if path == "" {
+		return nil
+	}
+
+	config, err := LoadConfig(path)
+	if err != nil {
+		return err
+	}
+
+	name := selectedContext(ctx, config)
+	if name == "" {
+		return nil
+	}
+
+	cc, found := config.Contexts[name]
+	if !found {
+		// Don't fail when the current context is missing, so that it
+		// can be fixed using the config commands.
+		if ctx.IsSet("context") {
+			return fmt.Errorf("%q: %w", name, errUnknownContext)
+		}
+
+		return nil
+	}
+
+	values := map[string]string{
+		"sm-api-url":           cc.URL,
+		"sm-api-token":         cc.Token,
+		"sm-api-token-command": cc.TokenCommand,
+		"publisher-token":      cc.PublisherToken,
+	}
+
+	for name, value := range map[string]int64{
+		"grafana-instance-id": cc.GrafanaInstanceID,
+		"metrics-instance-id": cc.MetricsInstanceID,
+		"logs-instance-id":    cc.LogsInstanceID,
+	} {
+		if value != 0 {
+			values[name] = strconv.FormatInt(value, 10)
+		}
+	}
+
+	// --json is a shorthand for --output, so it takes precedence too.
+	if !ctx.IsSet("json") {
+		values["output"] = cc.Output
+	}
+
+	// A token given explicitly takes precedence over any token command,
+	// and the other way around.
+	if ctx.IsSet("sm-api-token") || ctx.IsSet("sm-api-token-command") {
+		delete(values, "sm-api-token")
+		delete(values, "sm-api-token-command")
+	}
+
+	for name, value := range values {
+		if value == "" || ctx.IsSet(name) {
+			continue
+		}
+
+		if err := ctx.Set(name, value); err != nil {
+			return fmt.Errorf("applying configuration for %s: %w", name, err)
+		}
+	}
+
+	return nil
+}
+
+// selectedContext returns the name of the context selected using the
+// --context flag, or the current one.
+func selectedContext(ctx *cli.Context, config *Config) string {
+	if name := ctx.String("context"); name != "" {
+		return name
+	}
+
+	return config.CurrentContext
+}
+
+func GetConfigCommands(c ConfigClient) cli.Commands {
+	return cli.Commands{
+		&cli.Command{
+			Name:   "get-contexts",
+			Usage:  "list the contexts in the configuration file",
+			Action: c.getContexts,
+		},
+		&cli.Command{
+			Name:      "use-context",
+			Usage:     "set the current context",
+			ArgsUsage: "NAME",
+			Action:    c.useContext,
+		},
+		&cli.Command{
+			Name:      "set",
+			Usage:     "create or update a context, the first one created becomes the current one",
+			ArgsUsage: "[flags] NAME",
+			Action:    c.setContext,
+			Flags: []cli.Flag{
+				&cli.StringFlag{
+					Name:  "sm-api-url",
+					Usage: "base URL used to access the Synthetic Monitoring API server",
+				},
+				&cli.StringFlag{
+					Name:  "sm-api-token",
+					Usage: "token used to access the Synthetic Monitoring API server",
+				},
+				&cli.StringFlag{
+					Name:  "sm-api-token-command",
+					Usage: "shell command printing the token used to access the Synthetic Monitoring API server",
+				},
+				&cli.Int64Flag{
+					Name:  "grafana-instance-id",
+					Usage: "Grafana Cloud's Grafana instance ID",
+				},
+				&cli.Int64Flag{
+					Name:  "metrics-instance-id",
+					Usage: "Grafana Cloud's metrics instance ID",
+				},
+				&cli.Int64Flag{
+					Name:  "logs-instance-id",
+					Usage: "Grafana Cloud's logs instance ID",
+				},
+				&cli.StringFlag{
+					Name:  "publisher-token",
+					Usage: "Grafana Cloud publisher token",
+				},
+				&cli.StringFlag{
+					Name:  "output",
+					Usage: "default output format, see the global --output flag",
+				},
+			},
+		},
+	}
+}
+
+type ConfigClient ServiceClient
+
+func (c ConfigClient) getContexts(ctx *cli.Context) error {
+	config, err := LoadConfig(ctx.String("config"))
+	if err != nil {
+		return err
+	}
+
+	names := make([]string, 0, len(config.Contexts))
+	for name := range config.Contexts {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	jsonWriter := c.JsonWriterBuilder(ctx)
+	if done, err := jsonWriter(names, "marshaling contexts"); err != nil || done {
+		return err
+	}
+
+	w := c.TabWriterBuilder(ctx)
+	fmt.Fprintf(w, "%s\t%s\t%s\t%s\n", "current", "name", "url", "output")
+	for _, name := range names {
+		var current string
+		if name == config.CurrentContext {
+			current = "*"
+		}
+
+		cc := config.Contexts[name]
+		fmt.Fprintf(w, "%s\t%s\t%s\t%s\n", current, name, cc.URL, cc.Output)
+	}
+
+	if err := w.Flush(); err != nil {
+		return fmt.Errorf("flushing output: %w", err)
+	}
+
+	return nil
+}
+
+func (c ConfigClient) useContext(ctx *cli.Context) error {
+	name, err := contextName(ctx)
+	if err != nil {
+		return err
+	}
+
+	return updateConfig(ctx, func(config *Config) error {
+		if _, found := config.Contexts[name]; !found {
+			return fmt.Errorf("%q: %w", name, errUnknownContext)
+		}
+
+		config.CurrentContext = name
+
+		return nil
+	})
+}
+
+func (c ConfigClient) setContext(ctx *cli.Context) error {
+	name, err := contextName(ctx)
+	if err != nil {
+		return err
+	}
+
+	if ctx.IsSet("output") {
+		if _, err := output.ParseFormat(ctx.String("output")); err != nil {
+			return err
+		}
+	}
+
+	return updateConfig(ctx, func(config *Config) error {
+		if config.Contexts == nil {
+			config.Contexts = make(map[string]*ConfigContext)
+		}
+
+		cc, found := config.Contexts[name]
+		if !found {
+			cc = &ConfigContext{}
+			config.Contexts[name] = cc
+		}
+
+		for flag, field := range map[string]*string{
+			"sm-api-url":           &cc.URL,
+			"sm-api-token":         &cc.Token,
+			"sm-api-token-command": &cc.TokenCommand,
+			"publisher-token":      &cc.PublisherToken,
+			"output":               &cc.Output,
+		} {
+			if ctx.IsSet(flag) {
+				*field = ctx.String(flag)
+			}
+		}
+
+		for flag, field := range map[string]*int64{
+			"grafana-instance-id": &cc.GrafanaInstanceID,
+			"metrics-instance-id": &cc.MetricsInstanceID,
+			"logs-instance-id":    &cc.LogsInstanceID,
+		} {
+			if ctx.IsSet(flag) {
+				*field = ctx.Int64(flag)
+			}
+		}
+
+		if config.CurrentContext == "" {
+			config.CurrentContext = name
+		}
+
+		return nil
+	})
+}
+
+// contextName returns the name of the context passed as argument. Flags
+// after the name are not parsed, so they are reported as an error rather
+// than ignored.
+func contextName(ctx *cli.Context) (string, error) {
+	if ctx.NArg() != 1 || ctx.Args().First() == "" {
+		return "", errContextName
+	}
+
+	return ctx.Args().First(), nil
+}
+
+// updateConfig loads the configuration file, modifies it using update and
+// saves it.
+func updateConfig(ctx *cli.Context, update func(*Config) error) error {
+	path := ctx.String("config")
+	if path == "" {
+		return cli.Exit("configuration file path is required", 1)
+	}
+
+	config, err := LoadConfig(path)
+	if err != nil {
+		return err
+	}
+
+	if err := update(config); err != nil {
+		return err
+	}
+
+	return config.Save(path)
+}
@@ -0,0 +1,327 @@
+package cli
+
+import (
+	"io"
+	"os"
+	"path/filepath"
+	"runtime"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+	"github.com/urfave/cli/v2"
+)
+
+const testConfig = `current-context: prod
+contexts:
+  prod:
+    sm-api-url: https://prod.example.org/
+    sm-api-token: prod-token
+    grafana-instance-id: 1
+    output: json
+  dev:
+    sm-api-url: https://dev.example.org/
+    sm-api-token-command: pass show sm/dev
+    output: wide
+`
+
+// testGlobalFlags returns the global flags of sm-client used by the
+// configuration file.
+func testGlobalFlags() []cli.Flag {
+	return []cli.Flag{
+		&cli.StringFlag{Name: "config"},
+		&cli.StringFlag{Name: "context"},
+		&cli.StringFlag{Name: "sm-api-url", Value: "https://default.example.org/"},
+		&cli.StringFlag{Name: "sm-api-token", EnvVars: []string{"SM_CLIENT_TEST_TOKEN"}},
+		&cli.StringFlag{Name: "sm-api-token-command"},
+		&cli.Int64Flag{Name: "grafana-instance-id"},
+		&cli.Int64Flag{Name: "metrics-instance-id"},
+		&cli.Int64Flag{Name: "logs-instance-id"},
+		&cli.StringFlag{Name: "publisher-token"},
+		&cli.StringFlag{Name: "output", Aliases: []string{"o"}, Value: "text"},
+		&cli.BoolFlag{Name: "json"},
+		&cli.BoolFlag{Name: "debug"},
+	}
+}
+
+// runTestApp runs an application with the global flags and commands, and
+// the configuration applied, calling action with the context of the
+// application or of the command that is run.
+func runTestApp(t *testing.T, args []string, commands cli.Commands, action cli.ActionFunc) error {
+	t.Helper()
+
+	app := &cli.App{
+		Name:     "sm-client",
+		Flags:    testGlobalFlags(),
+		Before:   ApplyConfig,
+		Commands: commands,
+		Action:   action,
+		Writer:   io.Discard,
+	}
+
+	return app.Run(append([]string{"sm-client"}, args...))
+}
+
+func writeTestConfig(t *testing.T, content string) string {
+	t.Helper()
+
+	return writeTestFile(t, "config.yaml", content)
+}
+
+func TestLoadConfig(t *testing.T) {
+	config, err := LoadConfig(filepath.Join(t.TempDir(), "missing.yaml"))
+	require.NoError(t, err)
+	require.Equal(t, &Config{}, config)
+
+	config, err = LoadConfig(writeTestConfig(t, testConfig))
+	require.NoError(t, err)
+	require.Equal(t, "prod", config.CurrentContext)
+	require.Equal(t, &ConfigContext{
+		URL:               "https://prod.example.org/",
+		Token:             "prod-token",
+		GrafanaInstanceID: 1,
+		Output:            "json",
+	}, config.Contexts["prod"])
+	require.Equal(t, "pass show sm/dev", config.Contexts["dev"].TokenCommand)
+
+	path := writeTestConfig(t, "contexts: [prod]\n")
+	_, err = LoadConfig(path)
+	require.ErrorContains(t, err, path)
+
+	_, err = LoadConfig(t.TempDir())
+	require.ErrorContains(t, err, "reading configuration")
+}
+
+func TestConfigSave(t *testing.T) {
+	dir := filepath.Join(t.TempDir(), "sm-client")
+	path := filepath.Join(dir, "config.yaml")
+
+	config := &Config{
+		CurrentContext: "prod",
+		Contexts: map[string]*ConfigContext{
+			"prod": {URL: "https://prod.example.org/", AccessToken: "secret"},
+		},
+	}
+
+	require.NoError(t, config.Save(path))
+
+	if runtime.GOOS != "windows" {
+		info, err := os.Stat(path)
+		require.NoError(t, err)
+		require.Equal(t, os.FileMode(0o600), info.Mode().Perm())
+
+		info, err = os.Stat(dir)
+		require.NoError(t, err)
+		require.Equal(t, os.FileMode(0o700), info.Mode().Perm())
+	}
+
+	loaded, err := LoadConfig(path)
+	require.NoError(t, err)
+	require.Equal(t, config, loaded)
+
+	// Saving again replaces the file, without leaving temporary files
+	// behind.
+	config.Contexts["prod"].AccessToken = ""
+	require.NoError(t, config.Save(path))
+
+	loaded, err = LoadConfig(path)
+	require.NoError(t, err)
+	require.Empty(t, loaded.Contexts["prod"].AccessToken)
+
+	entries, err := os.ReadDir(dir)
+	require.NoError(t, err)
+	require.Len(t, entries, 1)
+	require.Equal(t, "config.yaml", entries[0].Name())
+
+	// The temporary file is removed if the configuration cannot be
+	// written.
+	require.Error(t, config.Save(dir))
+
+	entries, err = os.ReadDir(filepath.Dir(dir))
+	require.NoError(t, err)
+	require.Len(t, entries, 1)
+}
+
+func TestApplyConfig(t *testing.T) {
+	type values struct {
+		url          string
+		token        string
+		tokenCommand string
+		instanceID   int64
+		output       string
+	}
+
+	testcases := map[string]struct {
+		config   string
+		args     []string
+		env      string
+		expected values
+		err      error
+	}{
+		"current context": {
+			config: testConfig,
+			expected: values{
+				url:        "https://prod.example.org/",
+				token:      "prod-token",
+				instanceID: 1,
+				output:     "json",
+			},
+		},
+		"selected context": {
+			config: testConfig,
+			args:   []string{"--context", "dev"},
+			expected: values{
+				url:          "https://dev.example.org/",
+				tokenCommand: "pass show sm/dev",
+				output:       "wide",
+			},
+		},
+		"flags take precedence": {
+			config: testConfig,
+			args:   []string{"--sm-api-url", "https://flag.example.org/", "--grafana-instance-id", "2", "-o", "yaml"},
+			expected: values{
+				url:        "https://flag.example.org/",
+				token:      "prod-token",
+				instanceID: 2,
+				output:     "yaml",
+			},
+		},
+		"environment takes precedence": {
+			config: testConfig,
+			env:    "env-token",
+			expected: values{
+				url:        "https://prod.example.org/",
+				token:      "env-token",
+				instanceID: 1,
+				output:     "json",
+			},
+		},
+		"token command replaces token": {
+			config: testConfig,
+			args:   []string{"--sm-api-token-command", "echo token"},
+			expected: values{
+				url:          "https://prod.example.org/",
+				tokenCommand: "echo token",
+				instanceID:   1,
+				output:       "json",
+			},
+		},
+		"token replaces token command": {
+			config: testConfig,
+			args:   []string{"--context", "dev", "--sm-api-token", "flag-token"},
+			expected: values{
+				url:    "https://dev.example.org/",
+				token:  "flag-token",
+				output: "wide",
+			},
+		},
+		"json replaces output": {
+			config: testConfig,
+			args:   []string{"--json"},
+			expected: values{
+				url:        "https://prod.example.org/",
+				token:      "prod-token",
+				instanceID: 1,
+				output:     "text",
+			},
+		},
+		"no configuration": {
+			expected: values{url: "https://default.example.org/", output: "text"},
+		},
+		"no current context": {
+			config:   "contexts: {prod: {sm-api-url: https://prod.example.org/}}\n",
+			expected: values{url: "https://default.example.org/", output: "text"},
+		},
+		"missing current context": {
+			config:   "current-context: staging\n",
+			expected: values{url: "https://default.example.org/", output: "text"},
+		},
+		"unknown context": {
+			config: testConfig,
+			args:   []string{"--context", "staging"},
+			err:    errUnknownContext,
+		},
+	}
+
+	for name, tc := range testcases {
+		t.Run(name, func(t *testing.T) {
+			if tc.env != "" {
+				t.Setenv("SM_CLIENT_TEST_TOKEN", tc.env)
+			}
+
+			path := filepath.Join(t.TempDir(), "missing.yaml")
+			if tc.config != "" {
+				path = writeTestConfig(t, tc.config)
+			}
+
+			var actual values
+
+			err := runTestApp(t, append([]string{"--config", path}, tc.args...), nil, func(ctx *cli.Context) error {
+				actual = values{
+					url:          ctx.String("sm-api-url"),
+					token:        ctx.String("sm-api-token"),
+					tokenCommand: ctx.String("sm-api-token-command"),
+					instanceID:   ctx.Int64("grafana-instance-id"),
+					output:       ctx.String("output"),
+				}
+
+				return nil
+			})
+
+			if tc.err != nil {
+				require.ErrorIs(t, err, tc.err)
+				return
+			}
+
+			require.NoError(t, err)
+			require.Equal(t, tc.expected, actual)
+		})
+	}
+
+	// The configuration is ignored without a path.
+	err := runTestApp(t, []string{"--context", "staging"}, nil, func(ctx *cli.Context) error {
+		require.Equal(t, "https://default.example.org/", ctx.String("sm-api-url"))
+		return nil
+	})
+	require.NoError(t, err)
+
+	err = runTestApp(t, []string{"--config", writeTestConfig(t, "current-context: [\n")}, nil, nil)
+	require.ErrorContains(t, err, "parsing configuration")
+}
+
+func TestConfigCommands(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "sm-client", "config.yaml")
+
+	commands := GetConfigCommands(ConfigClient{})
+
+	run := func(args ...string) error {
+		return runTestApp(t, append([]string{"--config", path}, args...), commands, nil)
+	}
+
+	// The first context created becomes the current one.
+	require.NoError(t, run("set", "--sm-api-url", "https://prod.example.org/", "--grafana-instance-id", "1", "prod"))
+	require.NoError(t, run("set", "--sm-api-token-command", "pass show sm/dev", "dev"))
+
+	config, err := LoadConfig(path)
+	require.NoError(t, err)
+	require.Equal(t, &Config{
+		CurrentContext: "prod",
+		Contexts: map[string]*ConfigContext{
+			"prod": {URL: "https://prod.example.org/", GrafanaInstanceID: 1},
+			"dev":  {TokenCommand: "pass show sm/dev"},
+		},
+	}, config)
+
+	// Only the fields given as flags are updated.
+	require.NoError(t, run("set", "--output", "yaml", "prod"))
+	require.NoError(t, run("use-context", "dev"))
+
+	config, err = LoadConfig(path)
+	require.NoError(t, err)
+	require.Equal(t, "dev", config.CurrentContext)
+	require.Equal(t, &ConfigContext{URL: "https://prod.example.org/", GrafanaInstanceID: 1, Output: "yaml"}, config.Contexts["prod"])
+
+	require.ErrorIs(t, run("use-context", "staging"), errUnknownContext)
+	require.ErrorIs(t, run("use-context"), errContextName)
+	require.ErrorIs(t, run("set", "prod", "--output", "json"), errContextName)
+	require.Error(t, run("set", "--output", "xml", "prod"))
+}
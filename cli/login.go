@@ -0,0 +1,194 @@
+package cli
+
+import (
+	"errors"
+	"fmt"
+
+	smapi "github.com/grafana/synthetic-monitoring-api-go-client"
+	"github.com/urfave/cli/v2"
+)
+
+// defaultContext is the name of the context created by login when there's
+// none.
+const defaultContext = "default"
+
+var errNotLoggedIn = errors.New("not logged in, run the login command")
+
+func GetLoginCommands(c LoginClient) cli.Commands {
+	return cli.Commands{
+		&cli.Command{
+			Name:   "login",
+			Usage:  "get an access token using the publisher token and instance IDs, and store it in the configuration file",
+			Action: c.login,
+			Flags: []cli.Flag{
+				&cli.BoolFlag{
+					Name:  "refresh",
+					Usage: "replace the stored access token with a new one",
+				},
+			},
+		},
+		&cli.Command{
+			Name:   "logout",
+			Usage:  "delete the access token stored in the configuration file",
+			Action: c.logout,
+		},
+	}
+}
+
+type LoginClient ServiceClient
+
+func (c LoginClient) login(ctx *cli.Context) error {
+	var oldToken string
+
+	err := updateConfig(ctx, func(config *Config) error {
+		name := selectedContext(ctx, config)
+		if name == "" {
+			name = defaultContext
+			config.CurrentContext = name
+		}
+
+		if config.Contexts == nil {
+			config.Contexts = make(map[string]*ConfigContext)
+		}
+
+		cc, found := config.Contexts[name]
+		if !found {
+			cc = &ConfigContext{}
+			config.Contexts[name] = cc
+		}
+
+		if ctx.Bool("refresh") {
+			if cc.AccessToken == "" {
+				return errNotLoggedIn
+			}
+
+			tokens := smapi.NewStaticTokenSource(cc.AccessToken)
+
+			smClient, err := newAPIClient(ctx, ctx.String("sm-api-url"), smapi.WithTokenSource(tokens))
+			if err != nil {
+				return err
+			}
+
+			if err := smClient.RefreshToken(ctx.Context); err != nil {
+				return fmt.Errorf("refreshing access token: %w", err)
+			}
+
+			cc.AccessToken, _ = tokens.Token(ctx.Context)
+
+			return nil
+		}
+
+		smClient, err := newAPIClient(ctx, ctx.String("sm-api-url"))
+		if err != nil {
+			return err
+		}
+
+		resp, err := smClient.Install(
+			ctx.Context,
+			ctx.Int64("grafana-instance-id"),
+			ctx.Int64("metrics-instance-id"),
+			ctx.Int64("logs-instance-id"),
+			ctx.String("publisher-token"),
+		)
+		if err != nil {
+			return fmt.Errorf("setting up Synthetic Monitoring tenant: %w", err)
+		}
+
+		oldToken = cc.AccessToken
+		cc.AccessToken = resp.AccessToken
+
+		return nil
+	})
+	if err != nil {
+		return err
+	}
+
+	if oldToken != "" {
+		// Don't leave the previous token behind, now that the new one
+		// is stored. It might be invalid already, so ignore errors.
+		oldClient, err := newAPIClient(ctx, ctx.String("sm-api-url"), smapi.WithAccessToken(oldToken))
+		if err == nil {
+			_ = oldClient.DeleteToken(ctx.Context)
+		}
+	}
+
+	return nil
+}
+
+func (c LoginClient) logout(ctx *cli.Context) error {
+	var deleteErr error
+
+	err := updateConfig(ctx, func(config *Config) error {
+		cc, found := config.Contexts[selectedContext(ctx, config)]
+		if !found || cc.AccessToken == "" {
+			return errNotLoggedIn
+		}
+
+		smClient, err := newAPIClient(ctx, ctx.String("sm-api-url"), smapi.WithAccessToken(cc.AccessToken))
+		if err != nil {
+			return err
+		}
+
+		// Forget the token even if it cannot be deleted, e.g. because
+		// it's no longer valid.
+		deleteErr = smClient.DeleteToken(ctx.Context)
+		cc.AccessToken = ""
+
+		return nil
+	})
+	if err != nil {
+		return err
+	}
+
+	if deleteErr != nil {
+		return fmt.Errorf("deleting access token: %w", deleteErr)
+	}
+
+	return nil
+}
+
+// StoredToken returns the access token stored by the login command in the
+// selected context, after verifying that it's still valid. It returns an
+// empty string if there's none.
+func StoredToken(ctx *cli.Context) (string, error) {
+	path := ctx.String("config")
+	if path == "" {
+		return "", nil
+	}
+
+	config, err := LoadConfig(path)
+	if err != nil {
+		return "", err
+	}
+
+	cc, found := config.Contexts[selectedContext(ctx, config)]
+	if !found || cc.AccessToken == "" {
+		return "", nil
+	}
+
+	smClient, err := newAPIClient(ctx, ctx.String("sm-api-url"), smapi.WithAccessToken(cc.AccessToken))
+	if err != nil {
+		return "", err
+	}
+
+	if err := smClient.ValidateToken(ctx.Context); err != nil {
+		return "", fmt.Errorf("validating stored access token, run the login command again: %w", err)
+	}
+
+	return cc.AccessToken, nil
+}
+
+// newAPIClient returns a client for the API server at url, logging
+// requests if the --debug flag is set.
+func newAPIClient(ctx *cli.Context, url string, opts ...smapi.Option) (*smapi.Client, error) {
+	if ctx.Bool("debug") {
+		opts = append(opts, smapi.WithLogger(smapi.NewDebugLogger(ctx.App.ErrWriter)))
+	}
+
+	smClient, err := smapi.New(url, opts...)
+	if err != nil {
+		return nil, fmt.Errorf("creating Synthetic Monitoring API client: %w", err)
+	}
+
+	return smClient, nil
+}
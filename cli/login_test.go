@@ -0,0 +1,242 @@
+package cli
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"path/filepath"
+	"strings"
+	"sync"
+	"testing"
+
+	"github.com/grafana/synthetic-monitoring-api-go-client/model"
+	"github.com/stretchr/testify/require"
+	"github.com/urfave/cli/v2"
+)
+
+// newTokenServer returns a server accepting the tokens in valid. Installs
+// with the "publisher" token and refreshes return new tokens, which are
+// valid too. The requests are recorded with the token used.
+func newTokenServer(t *testing.T, valid ...string) (string, func() []string) {
+	t.Helper()
+
+	var (
+		mu       sync.Mutex
+		requests []string
+		tokens   = make(map[string]bool)
+	)
+
+	for _, token := range valid {
+		tokens[token] = true
+	}
+
+	mux := http.NewServeMux()
+	mux.HandleFunc("/api/v1/", func(w http.ResponseWriter, r *http.Request) {
+		mu.Lock()
+		defer mu.Unlock()
+
+		path := strings.TrimPrefix(r.URL.Path, "/api/v1")
+		token := strings.TrimPrefix(r.Header.Get("Authorization"), "Bearer ")
+		requests = append(requests, path+" "+token)
+
+		w.Header().Set("Content-Type", "application/json")
+
+		var resp any
+
+		switch path {
+		case "/register/install":
+			if token != "publisher" {
+				w.WriteHeader(http.StatusUnauthorized)
+				resp = model.ResponseError{Msg: "invalid publisher token"}
+
+				break
+			}
+
+			tokens["installed"] = true
+			resp = model.RegistrationInstallResponse{AccessToken: "installed"}
+
+		case "/token/validate":
+			resp = model.TokenValidateResponse{IsValid: tokens[token]}
+
+		case "/token/refresh":
+			tokens["refreshed"] = true
+			resp = model.TokenRefreshResponse{AccessToken: "refreshed"}
+
+		case "/token/delete":
+			if !tokens[token] {
+				w.WriteHeader(http.StatusUnauthorized)
+			}
+
+			delete(tokens, token)
+			resp = struct{}{}
+
+		default:
+			w.WriteHeader(http.StatusNotFound)
+			resp = model.ResponseError{Msg: "not found"}
+		}
+
+		require.NoError(t, json.NewEncoder(w).Encode(resp))
+	})
+
+	server := httptest.NewServer(mux)
+	t.Cleanup(server.Close)
+
+	return server.URL, func() []string {
+		mu.Lock()
+		defer mu.Unlock()
+
+		return append([]string(nil), requests...)
+	}
+}
+
+func TestStoredToken(t *testing.T) {
+	url, _ := newTokenServer(t, "stored")
+
+	const config = `current-context: prod
+contexts:
+  prod:
+    access-token: stored
+  dev:
+    sm-api-token: dev-token
+  old:
+    access-token: expired
+`
+
+	testcases := map[string]struct {
+		config   string
+		args     []string
+		expected string
+		err      bool
+	}{
+		"stored": {
+			config:   config,
+			expected: "stored",
+		},
+		"selected context": {
+			config: config,
+			args:   []string{"--context", "dev"},
+		},
+		"no token": {
+			config: "current-context: prod\ncontexts: {prod: {}}\n",
+		},
+		"no context": {
+			config: "contexts: {prod: {access-token: stored}}\n",
+		},
+		"no configuration": {},
+		"invalid token": {
+			config: config,
+			args:   []string{"--context", "old"},
+			err:    true,
+		},
+	}
+
+	for name, tc := range testcases {
+		t.Run(name, func(t *testing.T) {
+			path := filepath.Join(t.TempDir(), "missing.yaml")
+			if tc.config != "" {
+				path = writeTestConfig(t, tc.config)
+			}
+
+			args := append([]string{"--config", path, "--sm-api-url", url}, tc.args...)
+
+			err := runTestApp(t, args, nil, func(ctx *cli.Context) error {
+				token, err := StoredToken(ctx)
+				if tc.err {
+					require.ErrorContains(t, err, "run the login command again")
+					return nil
+				}
+
+				require.NoError(t, err)
+				require.Equal(t, tc.expected, token)
+
+				return nil
+			})
+			require.NoError(t, err)
+		})
+	}
+
+	// The token is not looked up without a configuration file.
+	err := runTestApp(t, []string{"--sm-api-url", url}, nil, func(ctx *cli.Context) error {
+		token, err := StoredToken(ctx)
+		require.NoError(t, err)
+		require.Empty(t, token)
+
+		return nil
+	})
+	require.NoError(t, err)
+}
+
+func TestLoginLogout(t *testing.T) {
+	url, requests := newTokenServer(t)
+
+	path := filepath.Join(t.TempDir(), "sm-client", "config.yaml")
+	commands := GetLoginCommands(LoginClient{})
+
+	run := func(args ...string) error {
+		return runTestApp(t, append([]string{"--config", path, "--sm-api-url", url}, args...), commands, nil)
+	}
+
+	accessToken := func() string {
+		config, err := LoadConfig(path)
+		require.NoError(t, err)
+
+		cc, found := config.Contexts[config.CurrentContext]
+		if !found {
+			return ""
+		}
+
+		return cc.AccessToken
+	}
+
+	require.ErrorIs(t, run("logout"), errNotLoggedIn)
+	require.ErrorIs(t, run("login", "--refresh"), errNotLoggedIn)
+
+	// Without a context, login creates the default one.
+	require.NoError(t, run("--publisher-token", "publisher", "login"))
+
+	config, err := LoadConfig(path)
+	require.NoError(t, err)
+	require.Equal(t, &Config{
+		CurrentContext: defaultContext,
+		Contexts:       map[string]*ConfigContext{defaultContext: {AccessToken: "installed"}},
+	}, config)
+
+	require.NoError(t, run("login", "--refresh"))
+	require.Equal(t, "refreshed", accessToken())
+
+	// Failing to log in again keeps the previous token.
+	require.ErrorContains(t, run("--publisher-token", "revoked", "login"), "setting up Synthetic Monitoring tenant")
+	require.Equal(t, "refreshed", accessToken())
+
+	// Logging in again deletes the previous token, once the new one is
+	// stored.
+	require.NoError(t, run("--publisher-token", "publisher", "login"))
+	require.Equal(t, "installed", accessToken())
+
+	require.NoError(t, run("logout"))
+	require.Empty(t, accessToken())
+	require.ErrorIs(t, run("logout"), errNotLoggedIn)
+
+	require.Equal(t, []string{
+		"/register/install publisher",
+		"/token/refresh installed",
+		"/register/install revoked",
+		"/register/install publisher",
+		"/token/delete refreshed",
+		"/token/delete installed",
+	}, requests())
+}
+
+func TestLogoutInvalidToken(t *testing.T) {
+	url, _ := newTokenServer(t)
+
+	path := writeTestConfig(t, "current-context: prod\ncontexts: {prod: {access-token: expired}}\n")
+
+	err := runTestApp(t, []string{"--config", path, "--sm-api-url", url, "logout"}, GetLoginCommands(LoginClient{}), nil)
+	require.ErrorContains(t, err, "deleting access token")
+
+	// The token is forgotten anyway.
+	config, err := LoadConfig(path)
+	require.NoError(t, err)
+	require.Empty(t, config.Contexts["prod"].AccessToken)
+}
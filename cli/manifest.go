@@ -0,0 +1,272 @@
+package cli
+
+import (
+	"bufio"
+	"errors"
+	"fmt"
+	"io"
+	"sort"
+	"strings"
+
+	smapi "github.com/grafana/synthetic-monitoring-api-go-client"
+	"github.com/grafana/synthetic-monitoring-api-go-client/manifest"
+	"github.com/urfave/cli/v2"
+)
+
+func getManifestFlags() []cli.Flag {
+	return []cli.Flag{
+		&cli.StringSliceFlag{
+			Name:     "file",
+			Aliases:  []string{"f"},
+			Usage:    "manifest `FILE` or directory containing manifests (can be repeated)",
+			Required: true,
+		},
+		&cli.BoolFlag{
+			Name:  "prune",
+			Usage: "delete checks and private probes that are not in the manifests",
+		},
+	}
+}
+
+var (
+	errNotConfirmed      = errors.New("changes not applied, use --yes to apply them without confirmation")
+	errConfirmStructured = errors.New("changes not applied, use --yes or --dry-run with structured output formats")
+)
+
+func GetManifestCommands(c ManifestsClient) cli.Commands {
+	return cli.Commands{
+		&cli.Command{
+			Name:   "apply",
+			Usage:  "create, update and optionally delete checks and private probes to match manifests",
+			Action: c.apply,
+			Flags: append(getManifestFlags(),
+				&cli.BoolFlag{
+					Name:    "yes",
+					Aliases: []string{"y"},
+					Usage:   "apply the changes without asking for confirmation",
+				},
+				&cli.BoolFlag{
+					Name:  "dry-run",
+					Usage: "show the changes without applying them",
+				},
+			),
+		},
+		&cli.Command{
+			Name:   "diff",
+			Usage:  "show the differences between manifests and the checks and private probes in the tenant, exit with status 2 if there are any",
+			Action: c.diff,
+			Flags:  getManifestFlags(),
+		},
+	}
+}
+
+type ManifestsClient ServiceClient
+
+// applyResult is the structured output of the apply command.
+type applyResult struct {
+	Plan *manifest.Plan `json:"plan"`
+	// Tokens holds the tokens of the probes that were created, keyed by
+	// probe name.
+	Tokens map[string]string `json:"tokens"`
+}
+
+func (c ManifestsClient) apply(ctx *cli.Context) error {
+	format, err := OutputFormat(ctx)
+	if err != nil {
+		return err
+	}
+
+	smClient, cleanup, err := c.ClientBuilder(ctx)
+	if err != nil {
+		return err
+	}
+	defer func() { _ = cleanup(ctx.Context) }()
+
+	plan, err := c.plan(ctx, smClient)
+	if err != nil {
+		return err
+	}
+
+	// With structured output, the plan is written together with the
+	// tokens of the created probes once the changes are applied.
+	if !format.Structured() {
+		if err := c.writePlan(ctx, plan); err != nil {
+			return err
+		}
+	}
+
+	if plan.Empty() || ctx.Bool("dry-run") {
+		return c.writeApplyResult(ctx, plan, nil)
+	}
+
+	if !ctx.Bool("yes") {
+		// The plan has not been shown, so it cannot be confirmed.
+		if format.Structured() {
+			return errConfirmStructured
+		}
+
+		confirmed, err := confirm(ctx, "Apply these changes?")
+		if err != nil {
+			return err
+		}
+
+		if !confirmed {
+			return errNotConfirmed
+		}
+	}
+
+	tokens, err := plan.Apply(ctx.Context, smClient)
+
+	// Write the tokens even in case of error, as they cannot be
+	// retrieved later.
+	if writeErr := c.writeApplyResult(ctx, plan, tokens); writeErr != nil {
+		return writeErr
+	}
+
+	if err != nil {
+		return fmt.Errorf("applying changes: %w", err)
+	}
+
+	return nil
+}
+
+// writeApplyResult writes the plan and the tokens of the created probes
+// using the structured output format, if any, and otherwise only the
+// tokens, as the plan has already been written.
+func (c ManifestsClient) writeApplyResult(ctx *cli.Context, plan *manifest.Plan, tokens map[string][]byte) error {
+	result := applyResult{Plan: plan, Tokens: make(map[string]string, len(tokens))}
+	for name, token := range tokens {
+		result.Tokens[name] = string(token)
+	}
+
+	jsonWriter := c.JsonWriterBuilder(ctx)
+
+	if done, err := jsonWriter(result, "marshaling result"); err != nil || done || len(tokens) == 0 {
+		return err
+	}
+
+	names := make([]string, 0, len(tokens))
+	for name := range tokens {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	w := c.TabWriterBuilder(ctx)
+	fmt.Fprintf(w, "%s\t%s\n", "probe", "token")
+	for _, name := range names {
+		fmt.Fprintf(w, "%s\t%s\n", name, result.Tokens[name])
+	}
+
+	if err := w.Flush(); err != nil {
+		return fmt.Errorf("flushing output: %w", err)
+	}
+
+	return nil
+}
+
+func (c ManifestsClient) diff(ctx *cli.Context) error {
+	smClient, cleanup, err := c.ClientBuilder(ctx)
+	if err != nil {
+		return err
+	}
+	defer func() { _ = cleanup(ctx.Context) }()
+
+	plan, err := c.plan(ctx, smClient)
+	if err != nil {
+		return err
+	}
+
+	jsonWriter := c.JsonWriterBuilder(ctx)
+
+	done, err := jsonWriter(plan, "marshaling plan")
+	if err != nil {
+		return err
+	}
+
+	if !done {
+		diff, err := plan.Diff()
+		if err != nil {
+			return fmt.Errorf("computing diff: %w", err)
+		}
+
+		if _, err := io.WriteString(ctx.App.Writer, diff); err != nil {
+			return fmt.Errorf("writing diff: %w", err)
+		}
+	}
+
+	if !plan.Empty() {
+		// Use a distinct exit status, so that differences can be told
+		// apart from errors.
+		return cli.Exit("", 2)
+	}
+
+	return nil
+}
+
+// plan loads the manifests and computes the plan to apply them to the
+// tenant.
+func (c ManifestsClient) plan(ctx *cli.Context, smClient *smapi.Client) (*manifest.Plan, error) {
+	m, err := manifest.Load(ctx.StringSlice("file")...)
+	if err != nil {
+		return nil, err
+	}
+
+	checks, err := smClient.ListChecks(ctx.Context)
+	if err != nil {
+		return nil, fmt.Errorf("listing checks: %w", err)
+	}
+
+	probes, err := smClient.ListProbes(ctx.Context)
+	if err != nil {
+		return nil, fmt.Errorf("listing probes: %w", err)
+	}
+
+	return manifest.NewPlan(m, checks, probes, ctx.Bool("prune"))
+}
+
+// writePlan writes the changes in plan as a table.
+func (c ManifestsClient) writePlan(ctx *cli.Context, plan *manifest.Plan) error {
+	w := c.TabWriterBuilder(ctx)
+
+	if plan.Empty() {
+		fmt.Fprintln(w, "No changes.")
+	} else {
+		fmt.Fprintf(w, "%s\t%s\t%s\n", "action", "type", "name")
+		for _, change := range plan.Probes {
+			fmt.Fprintf(w, "%s\t%s\t%q\n", change.Action, "probe", change.Name)
+		}
+		for _, change := range plan.Checks {
+			fmt.Fprintf(w, "%s\t%s\t%s\n", change.Action, "check", change.Key)
+		}
+
+		create, update, del := plan.Counts()
+		fmt.Fprintf(w, "\nPlan: %d to create, %d to update, %d to delete.\n", create, update, del)
+	}
+
+	if err := w.Flush(); err != nil {
+		return fmt.Errorf("flushing output: %w", err)
+	}
+
+	return nil
+}
+
+// confirm asks the user to answer question with yes or no. The question is
+// written to the error writer, so that it's not mixed with structured
+// output. Anything other than yes, including the end of the input, is
+// taken as no.
+func confirm(ctx *cli.Context, question string) (bool, error) {
+	fmt.Fprintf(ctx.App.ErrWriter, "%s [y/N] ", question)
+
+	answer, err := bufio.NewReader(ctx.App.Reader).ReadString('\n')
+	if err != nil && !errors.Is(err, io.EOF) {
+		return false, fmt.Errorf("reading answer: %w", err)
+	}
+
+	switch strings.ToLower(strings.TrimSpace(answer)) {
+	case "y", "yes":
+		return true, nil
+
+	default:
+		return false, nil
+	}
+}
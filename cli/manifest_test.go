@@ -0,0 +1,305 @@
+package cli
+
+import (
+	"encoding/json"
+	"net/http"
+	"strings"
+	"sync"
+	"testing"
+
+	sm "github.com/grafana/synthetic-monitoring-agent/pkg/pb/synthetic_monitoring"
+	"github.com/grafana/synthetic-monitoring-api-go-client/model"
+	"github.com/stretchr/testify/require"
+	"github.com/urfave/cli/v2"
+)
+
+// newManifestTestAPI returns the URL of an API server with a check and a
+// public probe, and a function returning the requests made to it.
+func newManifestTestAPI(t *testing.T) (string, func() []string) {
+	t.Helper()
+
+	var (
+		mu       sync.Mutex
+		requests []string
+	)
+
+	url := newTestAPI(t, func(method, path string, body []byte) (int, any) {
+		mu.Lock()
+		requests = append(requests, method+" "+path)
+		mu.Unlock()
+
+		switch path {
+		case "/check/list":
+			return http.StatusOK, []model.Check{{Check: sm.Check{
+				Id:       10,
+				Job:      "old",
+				Target:   "www.example.org",
+				Probes:   []int64{1},
+				Settings: sm.CheckSettings{Ping: &sm.PingSettings{}},
+			}}}
+
+		case "/probe/list":
+			return http.StatusOK, []sm.Probe{{Id: 1, Name: "Atlanta", Public: true}}
+
+		case "/probe/add":
+			var probe sm.Probe
+			require.NoError(t, json.Unmarshal(body, &probe))
+			probe.Id = 2
+
+			return http.StatusOK, model.ProbeAddResponse{Probe: probe, Token: []byte("probe-token")}
+
+		default:
+			return http.StatusOK, struct{}{}
+		}
+	})
+
+	return url, func() []string {
+		mu.Lock()
+		defer mu.Unlock()
+
+		return append([]string(nil), requests...)
+	}
+}
+
+func TestManifestApply(t *testing.T) {
+	testcases := map[string]struct {
+		args    []string
+		input   string
+		err     error
+		prompt  bool
+		deleted bool
+	}{
+		"no answer": {
+			args:   []string{"--prune"},
+			err:    errNotConfirmed,
+			prompt: true,
+		},
+		"answer no": {
+			args:   []string{"--prune"},
+			input:  "n\n",
+			err:    errNotConfirmed,
+			prompt: true,
+		},
+		"answer yes": {
+			args:    []string{"--prune"},
+			input:   "yes\n",
+			prompt:  true,
+			deleted: true,
+		},
+		"yes flag": {
+			args:    []string{"--prune", "--yes"},
+			deleted: true,
+		},
+		"dry run": {
+			args:  []string{"--prune", "--dry-run"},
+			input: "y\n",
+		},
+		"no changes": {
+			input: "y\n",
+		},
+	}
+
+	manifestFile := writeTestFile(t, "manifest.yaml", "checks: []\n")
+
+	for name, tc := range testcases {
+		t.Run(name, func(t *testing.T) {
+			url, requests := newManifestTestAPI(t)
+			commands := GetManifestCommands(ManifestsClient(newTestServiceClient(url)))
+
+			args := append([]string{"apply", "--file", manifestFile}, tc.args...)
+
+			stdout, stderr, err := runCommands(commands, tc.input, args...)
+			if tc.err != nil {
+				require.ErrorIs(t, err, tc.err)
+			} else {
+				require.NoError(t, err)
+			}
+
+			require.Equal(t, tc.prompt, strings.Contains(stderr, "Apply these changes?"))
+			require.Equal(t, tc.deleted, strings.Contains(strings.Join(requests(), "\n"), "DELETE /check/delete/10"))
+
+			if len(tc.args) > 0 {
+				require.Contains(t, stdout, "delete  check")
+			} else {
+				require.Contains(t, stdout, "No changes.")
+			}
+		})
+	}
+}
+
+func TestManifestApplyStructured(t *testing.T) {
+	manifestFile := writeTestFile(t, "manifest.yaml", "probes: [{name: office, region: EMEA}]\n")
+
+	type result struct {
+		Plan struct {
+			Probes []struct {
+				Action string `json:"action"`
+				Name   string `json:"name"`
+			} `json:"probes"`
+			Checks []struct {
+				Action string `json:"action"`
+				Key    struct {
+					Job string `json:"job"`
+				} `json:"key"`
+			} `json:"checks"`
+		} `json:"plan"`
+		Tokens map[string]string `json:"tokens"`
+	}
+
+	testcases := map[string]struct {
+		args   []string
+		tokens map[string]string
+		err    error
+	}{
+		"yes": {
+			args:   []string{"--yes"},
+			tokens: map[string]string{"office": "probe-token"},
+		},
+		"dry run": {
+			args:   []string{"--dry-run"},
+			tokens: map[string]string{},
+		},
+		"no confirmation": {
+			err: errConfirmStructured,
+		},
+	}
+
+	for name, tc := range testcases {
+		t.Run(name, func(t *testing.T) {
+			url, requests := newManifestTestAPI(t)
+			commands := GetManifestCommands(ManifestsClient(newTestServiceClient(url)))
+
+			args := append([]string{"-o", "json", "apply", "--file", manifestFile, "--prune"}, tc.args...)
+
+			stdout, stderr, err := runCommands(commands, "y\n", args...)
+			require.Empty(t, stderr)
+
+			if tc.err != nil {
+				require.ErrorIs(t, err, tc.err)
+				require.Empty(t, stdout)
+				require.NotContains(t, requests(), "POST /probe/add")
+
+				return
+			}
+
+			require.NoError(t, err)
+
+			// The output is a single JSON document.
+			var out result
+			dec := json.NewDecoder(strings.NewReader(stdout))
+			require.NoError(t, dec.Decode(&out))
+			require.False(t, dec.More())
+
+			require.Len(t, out.Plan.Probes, 1)
+			require.Equal(t, "create", out.Plan.Probes[0].Action)
+			require.Equal(t, "office", out.Plan.Probes[0].Name)
+			require.Len(t, out.Plan.Checks, 1)
+			require.Equal(t, "delete", out.Plan.Checks[0].Action)
+			require.Equal(t, "old", out.Plan.Checks[0].Key.Job)
+			require.Equal(t, tc.tokens, out.Tokens)
+		})
+	}
+}
+
+func TestManifestApplyTokens(t *testing.T) {
+	manifestFile := writeTestFile(t, "manifest.yaml", "probes: [{name: office, region: EMEA}]\n")
+
+	url, _ := newManifestTestAPI(t)
+	commands := GetManifestCommands(ManifestsClient(newTestServiceClient(url)))
+
+	stdout, _, err := runCommands(commands, "", "apply", "--file", manifestFile, "--yes")
+	require.NoError(t, err)
+	require.Contains(t, stdout, "create  probe  \"office\"")
+	require.Contains(t, stdout, "office  probe-token")
+}
+
+func TestManifestDiff(t *testing.T) {
+	testcases := map[string]struct {
+		args     []string
+		manifest string
+		status   int
+		expected []string
+	}{
+		"no changes": {
+			manifest: "checks: [{job: old, target: www.example.org, probes: [Atlanta], settings: {ping: {}}}]\n",
+		},
+		"changes": {
+			manifest: "checks: [{job: old, target: www.example.org, probes: [Atlanta], settings: {ping: {}}, timeout: 5000}]\n",
+			status:   2,
+			expected: []string{`+++ manifest: check job="old" target="www.example.org"`, "+timeout: 5000"},
+		},
+		"pruned": {
+			args:     []string{"--prune"},
+			manifest: "checks: []\n",
+			status:   2,
+			expected: []string{`-job: "old"`},
+		},
+	}
+
+	for name, tc := range testcases {
+		t.Run(name, func(t *testing.T) {
+			url, requests := newManifestTestAPI(t)
+			commands := GetManifestCommands(ManifestsClient(newTestServiceClient(url)))
+
+			args := append([]string{"diff", "--file", writeTestFile(t, "manifest.yaml", tc.manifest)}, tc.args...)
+
+			stdout, _, err := runCommands(commands, "", args...)
+
+			if tc.status != 0 {
+				var exitErr cli.ExitCoder
+				require.ErrorAs(t, err, &exitErr)
+				require.Equal(t, tc.status, exitErr.ExitCode())
+			} else {
+				require.NoError(t, err)
+			}
+
+			if len(tc.expected) == 0 {
+				require.Empty(t, stdout)
+			}
+
+			for _, expected := range tc.expected {
+				require.Contains(t, stdout, expected)
+			}
+
+			// Nothing is changed.
+			for _, request := range requests() {
+				require.Contains(t, request, "GET ")
+			}
+		})
+	}
+}
+
+func TestManifestDiffStructured(t *testing.T) {
+	url, _ := newManifestTestAPI(t)
+	commands := GetManifestCommands(ManifestsClient(newTestServiceClient(url)))
+
+	manifestFile := writeTestFile(t, "manifest.yaml", "probes: [{name: office, region: EMEA}]\n")
+
+	stdout, _, err := runCommands(commands, "", "-o", "json", "diff", "--file", manifestFile, "--prune")
+
+	var exitErr cli.ExitCoder
+	require.ErrorAs(t, err, &exitErr)
+	require.Equal(t, 2, exitErr.ExitCode())
+
+	// The output is the plan as a single JSON document, without the
+	// diff.
+	var plan struct {
+		Probes []struct {
+			Action string `json:"action"`
+			Name   string `json:"name"`
+		} `json:"probes"`
+		Checks []struct {
+			Action string `json:"action"`
+		} `json:"checks"`
+	}
+
+	dec := json.NewDecoder(strings.NewReader(stdout))
+	require.NoError(t, dec.Decode(&plan))
+	require.False(t, dec.More())
+
+	require.Len(t, plan.Probes, 1)
+	require.Equal(t, "create", plan.Probes[0].Action)
+	require.Equal(t, "office", plan.Probes[0].Name)
+	require.Len(t, plan.Checks, 1)
+	require.Equal(t, "delete", plan.Checks[0].Action)
+}
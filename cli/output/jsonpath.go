@@ -0,0 +1,168 @@
+package output
+
+import (
+	"errors"
+	"fmt"
+	"maps"
+	"slices"
+	"strconv"
+	"strings"
+)
+
+var ErrInvalidJSONPath = errors.New("invalid JSONPath expression")
+
+// jsonPath is a parsed JSONPath expression. Only a subset of JSONPath is
+// supported: field access using .name or ['name'], array indexes, which
+// can be negative, and the * wildcard for all the elements of an array or
+// object. The expression can start with $ and, as in kubectl, be
+// surrounded with braces.
+//
+// For example, $[*].id returns the ID of every element of a list, and
+// .settings.http.method the method of an HTTP check.
+type jsonPath []pathStep
+
+type pathStep struct {
+	field    string
+	index    int
+	isIndex  bool
+	wildcard bool
+}
+
+func parseJSONPath(expr string) (jsonPath, error) {
+	s := strings.TrimSpace(expr)
+
+	if strings.HasPrefix(s, "{") && strings.HasSuffix(s, "}") {
+		s = s[1 : len(s)-1]
+	}
+
+	s = strings.TrimPrefix(s, "$")
+
+	invalid := func(reason string) error {
+		return fmt.Errorf("%q: %s: %w", expr, reason, ErrInvalidJSONPath)
+	}
+
+	var path jsonPath
+
+	for s != "" {
+		switch s[0] {
+		case '.':
+			s = s[1:]
+
+			// Allow .[n], as in {.[*].id}.
+			if strings.HasPrefix(s, "[") {
+				continue
+			}
+
+			if strings.HasPrefix(s, "*") {
+				path = append(path, pathStep{wildcard: true})
+				s = s[1:]
+
+				continue
+			}
+
+			end := strings.IndexAny(s, ".[")
+			if end < 0 {
+				end = len(s)
+			}
+
+			if end == 0 {
+				return nil, invalid("empty field name")
+			}
+
+			path = append(path, pathStep{field: s[:end]})
+			s = s[end:]
+
+		case '[':
+			end := strings.IndexByte(s, ']')
+			if end < 0 {
+				return nil, invalid("missing ]")
+			}
+
+			step, err := parseBracket(s[1:end])
+			if err != nil {
+				return nil, invalid(err.Error())
+			}
+
+			path = append(path, step)
+			s = s[end+1:]
+
+		default:
+			return nil, invalid(fmt.Sprintf("unexpected %q", s[0]))
+		}
+	}
+
+	return path, nil
+}
+
+func parseBracket(s string) (pathStep, error) {
+	switch {
+	case s == "*":
+		return pathStep{wildcard: true}, nil
+
+	case len(s) >= 2 && (s[0] == '\'' || s[0] == '"') && s[len(s)-1] == s[0]:
+		return pathStep{field: s[1 : len(s)-1]}, nil
+
+	default:
+		index, err := strconv.Atoi(s)
+		if err != nil {
+			return pathStep{}, fmt.Errorf("invalid index %q", s)
+		}
+
+		return pathStep{index: index, isIndex: true}, nil
+	}
+}
+
+// eval returns the values matching p in data, which must be made of maps,
+// slices and scalars. Missing fields and indexes out of range don't match
+// anything.
+func (p jsonPath) eval(data any) ([]any, error) {
+	current := []any{data}
+
+	for _, step := range p {
+		var next []any
+
+		for _, v := range current {
+			switch v := v.(type) {
+			case map[string]any:
+				switch {
+				case step.wildcard:
+					// Use the same order as the JSON output.
+					for _, key := range slices.Sorted(maps.Keys(v)) {
+						next = append(next, v[key])
+					}
+
+				case step.isIndex:
+					return nil, fmt.Errorf("index %d used on an object: %w", step.index, ErrInvalidJSONPath)
+
+				default:
+					if value, found := v[step.field]; found {
+						next = append(next, value)
+					}
+				}
+
+			case []any:
+				switch {
+				case step.wildcard:
+					next = append(next, v...)
+
+				case step.isIndex:
+					index := step.index
+					if index < 0 {
+						index += len(v)
+					}
+
+					if index >= 0 && index < len(v) {
+						next = append(next, v[index])
+					}
+
+				default:
+					return nil, fmt.Errorf("field %q used on an array: %w", step.field, ErrInvalidJSONPath)
+				}
+			}
+		}
+
+		current = next
+	}
+
+	return current, nil
+}
@@ -0,0 +1,272 @@
+// Package output formats the results of sm-client commands.
+//
+// The structured formats (json, yaml, template and jsonpath) work on the
+// JSON representation of the results, so that field names are the same
+// in all of them. The tabular formats (text, wide and csv) are produced by
+// the commands themselves, as tab-separated tables.
+package output
+
+import (
+	"bytes"
+	"encoding/csv"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"strings"
+	"text/template"
+
+	"go.yaml.in/yaml/v3"
+)
+
+// Kinds of output formats.
+const (
+	Text     = "text"
+	Wide     = "wide"
+	CSV      = "csv"
+	JSON     = "json"
+	YAML     = "yaml"
+	Template = "template"
+	JSONPath = "jsonpath"
+)
+
+var (
+	ErrInvalidFormat   = errors.New("invalid output format")
+	ErrMissingArgument = errors.New("missing output format argument")
+)
+
+// Format is a parsed output format.
+type Format struct {
+	Kind string
+
+	tmpl *template.Template
+	path jsonPath
+}
+
+// Formats returns the output formats accepted by ParseFormat, for usage
+// messages.
+func Formats() []string {
+	return []string{JSON, YAML, CSV, Wide, Template + "=TEMPLATE", JSONPath + "=EXPRESSION"}
+}
+
+// ParseFormat parses an output format: one of text, wide, csv, json and
+// yaml, or template=TEMPLATE, where TEMPLATE is a Go template, or
+// jsonpath=EXPRESSION. An empty string is the same as text.
+func ParseFormat(s string) (Format, error) {
+	kind, arg, hasArg := strings.Cut(s, "=")
+
+	switch kind {
+	case "":
+		return Format{Kind: Text}, nil
+
+	case Text, Wide, CSV, JSON, YAML:
+		if hasArg {
+			return Format{}, fmt.Errorf("%q: %w", s, ErrInvalidFormat)
+		}
+
+		return Format{Kind: kind}, nil
+
+	case Template:
+		if arg == "" {
+			return Format{}, fmt.Errorf("%s: %w", kind, ErrMissingArgument)
+		}
+
+		tmpl, err := template.New("output").Funcs(template.FuncMap{"json": toJSON}).Parse(arg)
+		if err != nil {
+			return Format{}, fmt.Errorf("parsing template: %w", err)
+		}
+
+		return Format{Kind: kind, tmpl: tmpl}, nil
+
+	case JSONPath:
+		if arg == "" {
+			return Format{}, fmt.Errorf("%s: %w", kind, ErrMissingArgument)
+		}
+
+		path, err := parseJSONPath(arg)
+		if err != nil {
+			return Format{}, err
+		}
+
+		return Format{Kind: kind, path: path}, nil
+
+	default:
+		return Format{}, fmt.Errorf("%q: %w", s, ErrInvalidFormat)
+	}
+}
+
+// Structured reports whether f is written by Write instead of as a table.
+func (f Format) Structured() bool {
+	switch f.Kind {
+	case JSON, YAML, Template, JSONPath:
+		return true
+
+	default:
+		return false
+	}
+}
+
+// Write writes value to w in format f, which must be structured.
+func (f Format) Write(w io.Writer, value any) error {
+	if f.Kind == JSON {
+		// Write the value directly, as it's the same as its generic
+		// representation.
+		return json.NewEncoder(w).Encode(value)
+	}
+
+	data, err := generic(value)
+	if err != nil {
+		return err
+	}
+
+	switch f.Kind {
+	case YAML:
+		enc := yaml.NewEncoder(w)
+		enc.SetIndent(2)
+
+		if err := enc.Encode(yamlValue(data)); err != nil {
+			return err
+		}
+
+		return enc.Close()
+
+	case Template:
+		return f.tmpl.Execute(w, data)
+
+	case JSONPath:
+		results, err := f.path.eval(data)
+		if err != nil {
+			return err
+		}
+
+		for _, result := range results {
+			s, err := scalarString(result)
+			if err != nil {
+				return err
+			}
+
+			if _, err := fmt.Fprintln(w, s); err != nil {
+				return err
+			}
+		}
+
+		return nil
+
+	default:
+		return fmt.Errorf("%s: %w", f.Kind, ErrInvalidFormat)
+	}
+}
+
+// generic returns the JSON representation of value as maps, slices and
+// scalars. Numbers are kept as json.Number, so that large IDs are not
+// printed in exponent notation.
+func generic(value any) (any, error) {
+	buf, err := json.Marshal(value)
+	if err != nil {
+		return nil, err
+	}
+
+	dec := json.NewDecoder(bytes.NewReader(buf))
+	dec.UseNumber()
+
+	var out any
+	if err := dec.Decode(&out); err != nil {
+		return nil, err
+	}
+
+	return out, nil
+}
+
+// yamlValue replaces the numbers in data with YAML nodes, so that they are
+// written as numbers rather than strings, without losing precision.
+func yamlValue(data any) any {
+	switch v := data.(type) {
+	case map[string]any:
+		for key, value := range v {
+			v[key] = yamlValue(value)
+		}
+
+	case []any:
+		for i, value := range v {
+			v[i] = yamlValue(value)
+		}
+
+	case json.Number:
+		tag := "!!int"
+		if strings.ContainsAny(v.String(), ".eE") {
+			tag = "!!float"
+		}
+
+		return &yaml.Node{Kind: yaml.ScalarNode, Tag: tag, Value: v.String()}
+	}
+
+	return data
+}
+
+// scalarString returns v as text: strings are written as is, and other
+// values as JSON.
+func scalarString(v any) (string, error) {
+	switch v := v.(type) {
+	case string:
+		return v, nil
+
+	case json.Number:
+		return v.String(), nil
+
+	default:
+		return toJSON(v)
+	}
+}
+
+func toJSON(v any) (string, error) {
+	buf, err := json.Marshal(v)
+	if err != nil {
+		return "", err
+	}
+
+	return string(buf), nil
+}
+
+// CSVWriter converts tab-separated tables into CSV. Rows are written when
+// Flush is called. Empty lines are dropped.
+type CSVWriter struct {
+	w   io.Writer
+	buf bytes.Buffer
+}
+
+// NewCSVWriter returns a CSVWriter writing to w.
+func NewCSVWriter(w io.Writer) *CSVWriter {
+	return &CSVWriter{w: w}
+}
+
+func (c *CSVWriter) Write(p []byte) (int, error) {
+	return c.buf.Write(p)
+}
+
+// Flush writes the complete lines written so far as CSV records.
+func (c *CSVWriter) Flush() error {
+	data := c.buf.Bytes()
+
+	end := bytes.LastIndexByte(data, '\n')
+	if end < 0 {
+		return nil
+	}
+
+	w := csv.NewWriter(c.w)
+
+	for _, line := range strings.Split(string(data[:end]), "\n") {
+		if strings.TrimSpace(line) == "" {
+			continue
+		}
+
+		if err := w.Write(strings.Split(line, "\t")); err != nil {
+			return err
+		}
+	}
+
+	c.buf.Next(end + 1)
+
+	w.Flush()
+
+	return w.Error()
+}
@@ -0,0 +1,181 @@
+package output
+
+import (
+	"bytes"
+	"fmt"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+type testItem struct {
+	ID     int64             `json:"id"`
+	Job    string            `json:"job"`
+	Labels map[string]string `json:"labels,omitempty"`
+}
+
+var testItems = []testItem{
+	{ID: 9007199254740993, Job: "homepage", Labels: map[string]string{"team": "web"}},
+	{ID: 2, Job: "ping"},
+}
+
+func TestParseFormat(t *testing.T) {
+	testcases := map[string]struct {
+		input      string
+		kind       string
+		structured bool
+		err        error
+	}{
+		"empty":         {input: "", kind: Text},
+		"text":          {input: "text", kind: Text},
+		"wide":          {input: "wide", kind: Wide},
+		"csv":           {input: "csv", kind: CSV},
+		"json":          {input: "json", kind: JSON, structured: true},
+		"yaml":          {input: "yaml", kind: YAML, structured: true},
+		"template":      {input: "template={{.id}}", kind: Template, structured: true},
+		"jsonpath":      {input: "jsonpath=$[*].id", kind: JSONPath, structured: true},
+		"unknown":       {input: "xml", err: ErrInvalidFormat},
+		"extra arg":     {input: "json=x", err: ErrInvalidFormat},
+		"no template":   {input: "template=", err: ErrMissingArgument},
+		"no jsonpath":   {input: "jsonpath", err: ErrMissingArgument},
+		"bad jsonpath":  {input: "jsonpath=$[x", err: ErrInvalidJSONPath},
+		"bad bracket":   {input: "jsonpath=$[x]", err: ErrInvalidJSONPath},
+		"empty field":   {input: "jsonpath=$..id", err: ErrInvalidJSONPath},
+		"no leading op": {input: "jsonpath=$id", err: ErrInvalidJSONPath},
+	}
+
+	for name, tc := range testcases {
+		t.Run(name, func(t *testing.T) {
+			f, err := ParseFormat(tc.input)
+			if tc.err != nil {
+				require.ErrorIs(t, err, tc.err)
+				return
+			}
+
+			require.NoError(t, err)
+			require.Equal(t, tc.kind, f.Kind)
+			require.Equal(t, tc.structured, f.Structured())
+		})
+	}
+
+	_, err := ParseFormat("template={{.id")
+	require.Error(t, err)
+}
+
+func TestWrite(t *testing.T) {
+	testcases := map[string]struct {
+		format   string
+		value    any
+		expected string
+	}{
+		"json": {
+			format:   "json",
+			value:    testItems,
+			expected: `[{"id":9007199254740993,"job":"homepage","labels":{"team":"web"}},{"id":2,"job":"ping"}]` + "\n",
+		},
+		"yaml": {
+			format: "yaml",
+			value:  testItems,
+			expected: `- id: 9007199254740993
+  job: homepage
+  labels:
+    team: web
+- id: 2
+  job: ping
+`,
+		},
+		"yaml float": {
+			format:   "yaml",
+			value:    map[string]any{"latitude": 51.5, "region": "EMEA"},
+			expected: "latitude: 51.5\nregion: EMEA\n",
+		},
+		"template": {
+			format:   `template={{range .}}{{.id}} {{.job}}{{"\n"}}{{end}}`,
+			value:    testItems,
+			expected: "9007199254740993 homepage\n2 ping\n",
+		},
+		"template json": {
+			format:   `template={{json (index . 0).labels}}`,
+			value:    testItems,
+			expected: `{"team":"web"}`,
+		},
+		"jsonpath ids": {
+			format:   "jsonpath=$[*].id",
+			value:    testItems,
+			expected: "9007199254740993\n2\n",
+		},
+		"jsonpath braces": {
+			format:   "jsonpath={.[*].job}",
+			value:    testItems,
+			expected: "homepage\nping\n",
+		},
+		"jsonpath negative index": {
+			format:   "jsonpath=$[-1]['job']",
+			value:    testItems,
+			expected: "ping\n",
+		},
+		"jsonpath object": {
+			format:   "jsonpath=$[0].labels",
+			value:    testItems,
+			expected: `{"team":"web"}` + "\n",
+		},
+		"jsonpath wildcard object": {
+			format:   "jsonpath=$.*",
+			value:    map[string]any{"b": 2, "a": true, "c": nil},
+			expected: "true\n2\nnull\n",
+		},
+		"jsonpath missing": {
+			format:   "jsonpath=$[5].id",
+			value:    testItems,
+			expected: "",
+		},
+	}
+
+	for name, tc := range testcases {
+		t.Run(name, func(t *testing.T) {
+			f, err := ParseFormat(tc.format)
+			require.NoError(t, err)
+
+			var buf bytes.Buffer
+			require.NoError(t, f.Write(&buf, tc.value))
+			require.Equal(t, tc.expected, buf.String())
+		})
+	}
+}
+
+func TestWriteErrors(t *testing.T) {
+	f, err := ParseFormat("jsonpath=$.id")
+	require.NoError(t, err)
+	require.ErrorIs(t, f.Write(&bytes.Buffer{}, testItems), ErrInvalidJSONPath)
+
+	f, err = ParseFormat("jsonpath=$[0][0]")
+	require.NoError(t, err)
+	require.ErrorIs(t, f.Write(&bytes.Buffer{}, testItems), ErrInvalidJSONPath)
+
+	f, err = ParseFormat("csv")
+	require.NoError(t, err)
+	require.ErrorIs(t, f.Write(&bytes.Buffer{}, testItems), ErrInvalidFormat)
+}
+
+func TestCSVWriter(t *testing.T) {
+	var buf bytes.Buffer
+
+	w := NewCSVWriter(&buf)
+	fmt.Fprintf(w, "%s\t%s\t%s\n", "id", "job", "labels")
+	fmt.Fprintf(w, "%d\t%s\t%s\n", 1, "homepage", "team=web,env=prod")
+	fmt.Fprintln(w)
+	fmt.Fprintf(w, "%d\t%s\t%s\n", 2, `say "hi"`, "")
+	fmt.Fprintf(w, "%d\t%s", 3, "partial")
+
+	require.NoError(t, w.Flush())
+	require.Equal(t, `id,job,labels
+1,homepage,"team=web,env=prod"
+2,"say ""hi""",
+`, buf.String())
+
+	// Incomplete lines are kept until they are terminated.
+	buf.Reset()
+	fmt.Fprintln(w, "\tdone")
+	require.NoError(t, w.Flush())
+	require.Equal(t, "3,partial,done\n", buf.String())
+}
@@ -5,7 +5,6 @@ import (
 	"encoding/base64"
 	"errors"
 	"fmt"
-	"strings"
 	"time"
 
 	sm "github.com/grafana/synthetic-monitoring-agent/pkg/pb/synthetic_monitoring"
@@ -130,10 +129,20 @@ func (c ProbesClient) listProbes(ctx *cli.Context) error {
 		return err
 	}
 
+	wide := wideOutput(ctx)
+
 	w := c.TabWriterBuilder(ctx)
-	fmt.Fprintf(w, "%s\t%s\t%s\t%s\t%s\t%s\t%s\t%s\n", "id", "name", "region", "latitude", "longitude", "public", "deprecated", "online")
+	fmt.Fprintf(w, "%s\t%s\t%s\t%s\t%s\t%s\t%s\t%s", "id", "name", "region", "latitude", "longitude", "public", "deprecated", "online")
+	if wide {
+		fmt.Fprintf(w, "\t%s\t%s\t%s\t%s", "version", "labels", "online change", "modified")
+	}
+	fmt.Fprintln(w)
 	for _, p := range probes {
-		fmt.Fprintf(w, "%d\t%s\t%s\t%.3f\t%.3f\t%t\t%t\t%t\n", p.Id, p.Name, p.Region, p.Latitude, p.Longitude, p.Public, p.Deprecated, p.Online)
+		fmt.Fprintf(w, "%d\t%s\t%s\t%.3f\t%.3f\t%t\t%t\t%t", p.Id, p.Name, p.Region, p.Latitude, p.Longitude, p.Public, p.Deprecated, p.Online)
+		if wide {
+			fmt.Fprintf(w, "\t%s\t%s\t%s\t%s", p.Version, formatLabels(p.Labels), formatSMTime(p.OnlineChange), formatSMTime(p.Modified))
+		}
+		fmt.Fprintln(w)
 	}
 
 	if err := w.Flush(); err != nil {
@@ -160,15 +169,13 @@ func (c ProbesClient) addProbe(ctx *cli.Context) error {
 		return fmt.Errorf("adding probe: %w", err)
 	}
 
-	if ctx.Bool("json") {
-		out := map[string]interface{}{
-			"probe": newProbe,
-			"token": string(newProbeToken),
-		}
-		jsonWriter := c.JsonWriterBuilder(ctx)
-		if done, err := jsonWriter(out, "marshaling probe"); err != nil || done {
-			return err
-		}
+	out := map[string]interface{}{
+		"probe": newProbe,
+		"token": string(newProbeToken),
+	}
+	jsonWriter := c.JsonWriterBuilder(ctx)
+	if done, err := jsonWriter(out, "marshaling probe"); err != nil || done {
+		return err
 	}
 
 	w := c.TabWriterBuilder(ctx)
@@ -264,19 +271,9 @@ func (c ProbesClient) updateProbe(ctx *cli.Context) error {
 	}
 
 	if ctx.IsSet("labels") {
-		labels := ctx.StringSlice("labels")
-		probe.Labels = make([]sm.Label, 0, len(labels))
-
-		for _, label := range labels {
-			const labelParts = 2
-			parts := strings.SplitN(label, "=", labelParts)
-			if len(parts) != labelParts {
-				return fmt.Errorf("%q: %w", label, errInvalidLabel)
-			}
-			probe.Labels = append(probe.Labels, sm.Label{
-				Name:  parts[0],
-				Value: parts[1],
-			})
+		probe.Labels, err = parseLabels(ctx.StringSlice("labels"))
+		if err != nil {
+			return err
 		}
 	}
 
@@ -290,15 +287,13 @@ func (c ProbesClient) updateProbe(ctx *cli.Context) error {
 		token = base64.StdEncoding.EncodeToString(newProbeToken)
 	}
 
-	if ctx.Bool("json") {
-		out := map[string]interface{}{
-			"probe": newProbe,
-			"token": token,
-		}
-		jsonWriter := c.JsonWriterBuilder(ctx)
-		if done, err := jsonWriter(out, "marshaling probe"); err != nil || done {
-			return err
-		}
+	out := map[string]interface{}{
+		"probe": newProbe,
+		"token": token,
+	}
+	jsonWriter := c.JsonWriterBuilder(ctx)
+	if done, err := jsonWriter(out, "marshaling probe"); err != nil || done {
+		return err
 	}
 
 	w := c.TabWriterBuilder(ctx)
@@ -0,0 +1,355 @@
+package cli
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"strings"
+	"time"
+
+	"github.com/grafana/synthetic-monitoring-api-go-client/model"
+	"github.com/urfave/cli/v2"
+)
+
+var (
+	errSecretValueConflict = errors.New("only one of --value and --value-file can be used")
+	errSecretValueRequired = errors.New("the value of the secret must be provided using --value or --value-file")
+)
+
+func getSecretValueFlags() []cli.Flag {
+	return []cli.Flag{
+		&cli.StringFlag{
+			Name:  "value",
+			Usage: "value of the secret (prefer --value-file to keep it out of the shell history)",
+		},
+		&cli.StringFlag{
+			Name:  "value-file",
+			Usage: "read the value of the secret from `FILE`, use - for stdin",
+		},
+	}
+}
+
+// getShowValueFlag returns the flag used to echo the value that was just
+// set. The API server never returns the value of a secret, so it's not
+// available to the other commands.
+func getShowValueFlag() cli.Flag {
+	return &cli.BoolFlag{
+		Name:  "show-value",
+		Usage: "include the value that was set in the output",
+	}
+}
+
+func GetSecretCommands(c SecretsClient) cli.Commands {
+	return cli.Commands{
+		&cli.Command{
+			Name:   "list",
+			Usage:  "list secrets",
+			Action: c.listSecrets,
+		},
+		&cli.Command{
+			Name:   "get",
+			Usage:  "get a secret",
+			Action: c.getSecret,
+			Flags: []cli.Flag{
+				&cli.StringFlag{
+					Name:     "id",
+					Usage:    "id of the secret to get",
+					Required: true,
+				},
+			},
+		},
+		&cli.Command{
+			Name:   "add",
+			Usage:  "add a secret",
+			Action: c.addSecret,
+			Flags: append([]cli.Flag{
+				&cli.StringFlag{
+					Name:     "name",
+					Usage:    "name of the secret",
+					Required: true,
+				},
+				&cli.StringFlag{
+					Name:  "description",
+					Usage: "description of the secret",
+				},
+				&cli.StringSliceFlag{
+					Name:  "label",
+					Usage: "label for the secret, as name=value (can be repeated)",
+				},
+				getShowValueFlag(),
+			}, getSecretValueFlags()...),
+		},
+		&cli.Command{
+			Name:   "update",
+			Usage:  "update a secret",
+			Action: c.updateSecret,
+			Flags: append([]cli.Flag{
+				&cli.StringFlag{
+					Name:     "id",
+					Usage:    "id of the secret to update",
+					Required: true,
+				},
+				&cli.StringFlag{
+					Name:  "name",
+					Usage: "new name of the secret",
+				},
+				&cli.StringFlag{
+					Name:  "description",
+					Usage: "new description of the secret",
+				},
+				&cli.StringSliceFlag{
+					Name:  "label",
+					Usage: "new labels for the secret, as name=value (can be repeated)",
+				},
+				getShowValueFlag(),
+			}, getSecretValueFlags()...),
+		},
+		&cli.Command{
+			Name:   "delete",
+			Usage:  "delete one or more secrets",
+			Action: c.deleteSecret,
+			Flags: []cli.Flag{
+				&cli.StringSliceFlag{
+					Name:     "id",
+					Usage:    "id of the secret to delete",
+					Required: true,
+				},
+			},
+		},
+	}
+}
+
+type SecretsClient ServiceClient
+
+func (c SecretsClient) listSecrets(ctx *cli.Context) error {
+	smClient, cleanup, err := c.ClientBuilder(ctx)
+	if err != nil {
+		return err
+	}
+	defer func() { _ = cleanup(ctx.Context) }()
+
+	secrets, err := smClient.ListSecrets(ctx.Context)
+	if err != nil {
+		return fmt.Errorf("listing secrets: %w", err)
+	}
+
+	for i := range secrets {
+		secrets[i].Plaintext = ""
+	}
+
+	jsonWriter := c.JsonWriterBuilder(ctx)
+	if done, err := jsonWriter(secrets, "marshaling secrets"); err != nil || done {
+		return err
+	}
+
+	w := c.TabWriterBuilder(ctx)
+	fmt.Fprintf(w, "%s\t%s\t%s\t%s\t%s\n", "id", "name", "description", "labels", "modified")
+	for _, s := range secrets {
+		fmt.Fprintf(w, "%s\t%s\t%s\t%s\t%s\n", s.UUID, s.Name, s.Description, formatLabels(s.Labels), formatSecretTime(s.ModifiedAt))
+	}
+
+	if err := w.Flush(); err != nil {
+		return fmt.Errorf("flushing output: %w", err)
+	}
+
+	return nil
+}
+
+func (c SecretsClient) getSecret(ctx *cli.Context) error {
+	smClient, cleanup, err := c.ClientBuilder(ctx)
+	if err != nil {
+		return err
+	}
+	defer func() { _ = cleanup(ctx.Context) }()
+
+	secret, err := smClient.GetSecret(ctx.Context, ctx.String("id"))
+	if err != nil {
+		return fmt.Errorf("getting secret: %w", err)
+	}
+
+	return c.writeSecret(ctx, secret)
+}
+
+func (c SecretsClient) addSecret(ctx *cli.Context) error {
+	value, valueSet, err := readSecretValue(ctx)
+	if err != nil {
+		return err
+	}
+
+	if !valueSet {
+		return errSecretValueRequired
+	}
+
+	labels, err := parseLabels(ctx.StringSlice("label"))
+	if err != nil {
+		return err
+	}
+
+	smClient, cleanup, err := c.ClientBuilder(ctx)
+	if err != nil {
+		return err
+	}
+	defer func() { _ = cleanup(ctx.Context) }()
+
+	secret, err := smClient.AddSecret(ctx.Context, model.Secret{
+		Name:        ctx.String("name"),
+		Description: ctx.String("description"),
+		Labels:      labels,
+		Plaintext:   value,
+	})
+	if err != nil {
+		return fmt.Errorf("adding secret: %w", err)
+	}
+
+	if secret.Plaintext == "" {
+		secret.Plaintext = value
+	}
+
+	return c.writeSecret(ctx, secret)
+}
+
+func (c SecretsClient) updateSecret(ctx *cli.Context) error {
+	value, valueSet, err := readSecretValue(ctx)
+	if err != nil {
+		return err
+	}
+
+	smClient, cleanup, err := c.ClientBuilder(ctx)
+	if err != nil {
+		return err
+	}
+	defer func() { _ = cleanup(ctx.Context) }()
+
+	secret, err := smClient.GetSecret(ctx.Context, ctx.String("id"))
+	if err != nil {
+		return fmt.Errorf("getting secret: %w", err)
+	}
+
+	if ctx.IsSet("name") {
+		secret.Name = ctx.String("name")
+	}
+
+	if ctx.IsSet("description") {
+		secret.Description = ctx.String("description")
+	}
+
+	if ctx.IsSet("label") {
+		secret.Labels, err = parseLabels(ctx.StringSlice("label"))
+		if err != nil {
+			return err
+		}
+	}
+
+	secret.Plaintext = ""
+	if valueSet {
+		secret.Plaintext = value
+	}
+
+	newSecret, err := smClient.UpdateSecret(ctx.Context, *secret)
+	if err != nil {
+		return fmt.Errorf("updating secret: %w", err)
+	}
+
+	if newSecret.Plaintext == "" {
+		newSecret.Plaintext = secret.Plaintext
+	}
+
+	return c.writeSecret(ctx, newSecret)
+}
+
+func (c SecretsClient) deleteSecret(ctx *cli.Context) error {
+	smClient, cleanup, err := c.ClientBuilder(ctx)
+	if err != nil {
+		return err
+	}
+	defer func() { _ = cleanup(ctx.Context) }()
+
+	for _, id := range ctx.StringSlice("id") {
+		if err := smClient.DeleteSecret(ctx.Context, id); err != nil {
+			return fmt.Errorf("deleting secret %s: %w", id, err)
+		}
+	}
+
+	jsonWriter := c.JsonWriterBuilder(ctx)
+	if done, err := jsonWriter(struct{}{}, "marshaling result"); err != nil || done {
+		return err
+	}
+
+	return nil
+}
+
+// writeSecret writes secret to the output. The value of the secret is
+// only included if the user asked for it, when adding or updating it.
+func (c SecretsClient) writeSecret(ctx *cli.Context, secret *model.Secret) error {
+	if !ctx.Bool("show-value") {
+		secret.Plaintext = ""
+	}
+
+	jsonWriter := c.JsonWriterBuilder(ctx)
+	if done, err := jsonWriter(secret, "marshaling secret"); err != nil || done {
+		return err
+	}
+
+	w := c.TabWriterBuilder(ctx)
+	fmt.Fprintf(w, "%s:\t%s\n", "id", secret.UUID)
+	fmt.Fprintf(w, "%s:\t%s\n", "name", secret.Name)
+	fmt.Fprintf(w, "%s:\t%s\n", "description", secret.Description)
+	fmt.Fprintf(w, "%s:\t%s\n", "labels", formatLabels(secret.Labels))
+	fmt.Fprintf(w, "%s:\t%s\n", "created", formatSecretTime(secret.CreatedAt))
+	fmt.Fprintf(w, "%s:\t%s\n", "created by", secret.CreatedBy)
+	fmt.Fprintf(w, "%s:\t%s\n", "modified", formatSecretTime(secret.ModifiedAt))
+	if secret.Plaintext != "" {
+		fmt.Fprintf(w, "%s:\t%s\n", "value", secret.Plaintext)
+	}
+
+	if err := w.Flush(); err != nil {
+		return fmt.Errorf("flushing output: %w", err)
+	}
+
+	return nil
+}
+
+// readSecretValue returns the value of the secret passed using either
+// --value or --value-file, and whether any of them was used.
+func readSecretValue(ctx *cli.Context) (string, bool, error) {
+	switch {
+	case ctx.IsSet("value") && ctx.IsSet("value-file"):
+		return "", false, errSecretValueConflict
+
+	case ctx.IsSet("value"):
+		return ctx.String("value"), true, nil
+
+	case ctx.IsSet("value-file"):
+		var (
+			buf []byte
+			err error
+		)
+
+		if filename := ctx.String("value-file"); filename == "-" {
+			buf, err = io.ReadAll(ctx.App.Reader)
+		} else {
+			buf, err = os.ReadFile(filename)
+		}
+
+		if err != nil {
+			return "", false, fmt.Errorf("reading secret value: %w", err)
+		}
+
+		// Remove the trailing newline that most editors add.
+		value := strings.TrimSuffix(strings.TrimSuffix(string(buf), "\n"), "\r")
+
+		return value, true, nil
+
+	default:
+		return "", false, nil
+	}
+}
+
+func formatSecretTime(t int64) string {
+	if t == 0 {
+		return ""
+	}
+
+	return time.Unix(t, 0).Format(time.RFC3339)
+}
@@ -0,0 +1,183 @@
+package cli
+
+import (
+	"encoding/json"
+	"net/http"
+	"os"
+	"strings"
+	"testing"
+
+	"github.com/grafana/synthetic-monitoring-api-go-client/model"
+	"github.com/stretchr/testify/require"
+	"github.com/urfave/cli/v2"
+)
+
+func TestReadSecretValue(t *testing.T) {
+	valueFile := writeTestFile(t, "value.txt", "hunter2\n")
+	crlfFile := writeTestFile(t, "crlf.txt", "hunter2\r\n")
+	multilineFile := writeTestFile(t, "multiline.txt", "line 1\nline 2\n\n")
+
+	testcases := map[string]struct {
+		args     []string
+		stdin    string
+		expected string
+		set      bool
+		err      error
+	}{
+		"no value": {},
+		"value": {
+			args:     []string{"--value", "hunter2"},
+			expected: "hunter2",
+			set:      true,
+		},
+		"empty value": {
+			args: []string{"--value", ""},
+			set:  true,
+		},
+		"value is not trimmed": {
+			args:     []string{"--value", "hunter2\n"},
+			expected: "hunter2\n",
+			set:      true,
+		},
+		"value file": {
+			args:     []string{"--value-file", valueFile},
+			expected: "hunter2",
+			set:      true,
+		},
+		"value file with CRLF": {
+			args:     []string{"--value-file", crlfFile},
+			expected: "hunter2",
+			set:      true,
+		},
+		"only the last newline is trimmed": {
+			args:     []string{"--value-file", multilineFile},
+			expected: "line 1\nline 2\n",
+			set:      true,
+		},
+		"stdin": {
+			args:     []string{"--value-file", "-"},
+			stdin:    "hunter2\n",
+			expected: "hunter2",
+			set:      true,
+		},
+		"stdin without newline": {
+			args:     []string{"--value-file", "-"},
+			stdin:    "hunter2",
+			expected: "hunter2",
+			set:      true,
+		},
+		"conflict": {
+			args: []string{"--value", "hunter2", "--value-file", valueFile},
+			err:  errSecretValueConflict,
+		},
+		"missing file": {
+			args: []string{"--value-file", valueFile + ".missing"},
+			err:  os.ErrNotExist,
+		},
+	}
+
+	for name, tc := range testcases {
+		t.Run(name, func(t *testing.T) {
+			var (
+				value string
+				set   bool
+			)
+
+			app := &cli.App{
+				Name:   "sm-client",
+				Flags:  getSecretValueFlags(),
+				Reader: strings.NewReader(tc.stdin),
+				Action: func(ctx *cli.Context) error {
+					var err error
+					value, set, err = readSecretValue(ctx)
+
+					return err
+				},
+			}
+
+			err := app.Run(append([]string{"sm-client"}, tc.args...))
+			if tc.err != nil {
+				require.ErrorIs(t, err, tc.err)
+				return
+			}
+
+			require.NoError(t, err)
+			require.Equal(t, tc.expected, value)
+			require.Equal(t, tc.set, set)
+		})
+	}
+}
+
+// newSecretsTestAPI returns the URL of a secrets API server with a
+// secret. Unlike the real one, it returns the value of the secret when
+// getting it, so that the commands can be checked not to show it.
+func newSecretsTestAPI(t *testing.T) string {
+	t.Helper()
+
+	secret := model.Secret{UUID: "secret-1", Name: "api-password"}
+
+	return newTestAPI(t, func(method, path string, body []byte) (int, any) {
+		switch {
+		case method == http.MethodGet && path == "/api/v1alpha1/secrets/secret-1":
+			leaked := secret
+			leaked.Plaintext = "hunter2"
+
+			return http.StatusOK, leaked
+
+		case method == http.MethodPost && path == "/api/v1alpha1/secrets",
+			method == http.MethodPut && path == "/api/v1alpha1/secrets/secret-1":
+			var s model.Secret
+			require.NoError(t, json.Unmarshal(body, &s))
+			require.Equal(t, "hunter2", s.Plaintext)
+
+			return http.StatusOK, secret
+
+		default:
+			return http.StatusNotFound, model.ResponseError{Msg: "not found"}
+		}
+	})
+}
+
+func TestSecretShowValue(t *testing.T) {
+	testcases := map[string]struct {
+		args  []string
+		shown bool
+	}{
+		"get": {
+			args: []string{"get", "--id", "secret-1"},
+		},
+		"add": {
+			args: []string{"add", "--name", "api-password", "--value", "hunter2"},
+		},
+		"add and show": {
+			args:  []string{"add", "--name", "api-password", "--value", "hunter2", "--show-value"},
+			shown: true,
+		},
+		"update": {
+			args: []string{"update", "--id", "secret-1", "--value", "hunter2"},
+		},
+		"update and show": {
+			args:  []string{"update", "--id", "secret-1", "--value", "hunter2", "--show-value"},
+			shown: true,
+		},
+	}
+
+	for name, tc := range testcases {
+		t.Run(name, func(t *testing.T) {
+			for _, format := range []string{"text", "json"} {
+				commands := GetSecretCommands(SecretsClient(newTestServiceClient(newSecretsTestAPI(t))))
+
+				stdout, _, err := runCommands(commands, "", append([]string{"-o", format}, tc.args...)...)
+				require.NoError(t, err)
+				require.Contains(t, stdout, "secret-1")
+				require.Equal(t, tc.shown, strings.Contains(stdout, "hunter2"), format)
+
+				if format == "json" {
+					var secret model.Secret
+					require.NoError(t, json.Unmarshal([]byte(stdout), &secret))
+					require.Equal(t, tc.shown, secret.Plaintext == "hunter2")
+				}
+			}
+		})
+	}
+}
@@ -2,39 +2,71 @@ package main
 
 import (
 	"context"
-	"encoding/json"
+	"errors"
 	"fmt"
 	"log"
 	"os"
+	"os/exec"
+	"slices"
+	"strings"
 	"text/tabwriter"
 
 	smapi "github.com/grafana/synthetic-monitoring-api-go-client"
 	smCli "github.com/grafana/synthetic-monitoring-api-go-client/cli"
+	"github.com/grafana/synthetic-monitoring-api-go-client/cli/output"
 	"github.com/urfave/cli/v2"
 )
 
+var errEmptyToken = errors.New("token command returned an empty token")
+
 func main() {
 	checksClient := smCli.ChecksClient{
 		ClientBuilder:     newClient,
-		JsonWriterBuilder: newJsonWriter,
+		JsonWriterBuilder: newOutputWriter,
 		TabWriterBuilder:  newTabWriter,
 	}
 	probesClient := smCli.ProbesClient{
 		ClientBuilder:     newClient,
-		JsonWriterBuilder: newJsonWriter,
+		JsonWriterBuilder: newOutputWriter,
 		TabWriterBuilder:  newTabWriter,
 	}
 	tenantsClient := smCli.TenantsClient{
 		ClientBuilder:     newClient,
-		JsonWriterBuilder: newJsonWriter,
+		JsonWriterBuilder: newOutputWriter,
+		TabWriterBuilder:  newTabWriter,
+	}
+	secretsClient := smCli.SecretsClient{
+		ClientBuilder:     newClient,
+		JsonWriterBuilder: newOutputWriter,
+		TabWriterBuilder:  newTabWriter,
+	}
+	manifestsClient := smCli.ManifestsClient{
+		ClientBuilder:     newClient,
+		JsonWriterBuilder: newOutputWriter,
+		TabWriterBuilder:  newTabWriter,
+	}
+	bundlesClient := smCli.BundlesClient{
+		ClientBuilder:     newClient,
+		JsonWriterBuilder: newOutputWriter,
+		TabWriterBuilder:  newTabWriter,
+	}
+	configClient := smCli.ConfigClient{
+		ClientBuilder:     newClient,
+		JsonWriterBuilder: newOutputWriter,
+		TabWriterBuilder:  newTabWriter,
+	}
+	loginClient := smCli.LoginClient{
+		ClientBuilder:     newClient,
+		JsonWriterBuilder: newOutputWriter,
 		TabWriterBuilder:  newTabWriter,
 	}
 
 	app := &cli.App{
-		Name:  "sm-client",
-		Usage: "Make requests to Synthetic Monitoring API",
-		Flags: getGlobalFlags(),
-		Commands: cli.Commands{
+		Name:   "sm-client",
+		Usage:  "Make requests to Synthetic Monitoring API",
+		Flags:  getGlobalFlags(),
+		Before: before,
+		Commands: slices.Concat(cli.Commands{
 			&cli.Command{
 				Name:        "tenant",
 				Usage:       "tenant actions",
@@ -53,7 +85,22 @@ func main() {
 				Aliases:     []string{"checks"},
 				Subcommands: smCli.GetCheckCommands(checksClient),
 			},
+			&cli.Command{
+				Name:        "secret",
+				Usage:       "secret actions",
+				Aliases:     []string{"secrets"},
+				Subcommands: smCli.GetSecretCommands(secretsClient),
+			},
+			&cli.Command{
+				Name:        "config",
+				Usage:       "manage the contexts in the configuration file",
+				Subcommands: smCli.GetConfigCommands(configClient),
+			},
 		},
+			smCli.GetManifestCommands(manifestsClient),
+			smCli.GetBundleCommands(bundlesClient),
+			smCli.GetLoginCommands(loginClient),
+		),
 	}
 
 	if err := app.Run(os.Args); err != nil {
@@ -62,7 +109,22 @@ func main() {
 }
 
 func getGlobalFlags() []cli.Flag {
+	// Without a configuration directory, e.g. if $HOME is not set, the
+	// configuration file is only used if specified explicitly.
+	configPath, _ := smCli.DefaultConfigPath()
+
 	return []cli.Flag{
+		&cli.StringFlag{
+			Name:    "config",
+			Value:   configPath,
+			Usage:   "path of the configuration file",
+			EnvVars: []string{"SM_CLIENT_CONFIG"},
+		},
+		&cli.StringFlag{
+			Name:    "context",
+			Usage:   "context of the configuration file to use instead of the current one",
+			EnvVars: []string{"SM_CLIENT_CONTEXT"},
+		},
 		&cli.StringFlag{
 			Name:  "sm-api-url",
 			Value: "https://synthetic-monitoring-api.grafana.net/",
@@ -74,6 +136,11 @@ func getGlobalFlags() []cli.Flag {
 			Usage:   "token used to access the Synthetic Monitoring API server",
 			EnvVars: []string{"SM_API_TOKEN"},
 		},
+		&cli.StringFlag{
+			Name:    "sm-api-token-command",
+			Usage:   "shell command printing the token used to access the Synthetic Monitoring API server, if no token is specified",
+			EnvVars: []string{"SM_API_TOKEN_COMMAND"},
+		},
 		&cli.Int64Flag{
 			Name:  "grafana-instance-id",
 			Value: 0,
@@ -95,23 +162,62 @@ func getGlobalFlags() []cli.Flag {
 			Usage:   "Grafana Cloud publisher token",
 			EnvVars: []string{"GRAFANA_PUBLISHER_TOKEN"},
 		},
+		&cli.StringFlag{
+			Name:    "output",
+			Aliases: []string{"o"},
+			Value:   "text",
+			Usage:   "output format: text, " + strings.Join(output.Formats(), ", "),
+		},
 		&cli.BoolFlag{
 			Name:  "json",
 			Value: false,
-			Usage: "output JSON",
+			Usage: "output JSON, same as --output json",
+		},
+		&cli.BoolFlag{
+			Name:  "debug",
+			Value: false,
+			Usage: "log API requests and responses to stderr, with secrets redacted",
 		},
 	}
 }
 
 func newClient(c *cli.Context) (*smapi.Client, func(context.Context) error, error) {
 	token := c.String("sm-api-token")
-	smClient := smapi.NewClient(c.String("sm-api-url"), token, nil)
+
+	if command := c.String("sm-api-token-command"); token == "" && command != "" {
+		var err error
+
+		token, err = runTokenCommand(c.Context, command)
+		if err != nil {
+			return nil, nil, err
+		}
+	}
+
+	if token == "" {
+		var err error
+
+		token, err = smCli.StoredToken(c)
+		if err != nil {
+			return nil, nil, err
+		}
+	}
+
+	opts := []smapi.Option{smapi.WithAccessToken(token)}
+
+	if c.Bool("debug") {
+		opts = append(opts, smapi.WithLogger(smapi.NewDebugLogger(c.App.ErrWriter)))
+	}
+
+	smClient, err := smapi.New(c.String("sm-api-url"), opts...)
+	if err != nil {
+		return nil, nil, fmt.Errorf("creating Synthetic Monitoring API client: %w", err)
+	}
 
 	if token != "" {
 		return smClient, func(context.Context) error { return nil }, nil
 	}
 
-	_, err := smClient.Install(
+	_, err = smClient.Install(
 		c.Context,
 		c.Int64("grafana-instance-id"),
 		c.Int64("metrics-instance-id"),
@@ -125,23 +231,63 @@ func newClient(c *cli.Context) (*smapi.Client, func(context.Context) error, erro
 	return smClient, smClient.DeleteToken, nil
 }
 
+// runTokenCommand runs command using the shell and returns its output,
+// without surrounding whitespace.
+func runTokenCommand(ctx context.Context, command string) (string, error) {
+	cmd := exec.CommandContext(ctx, "sh", "-c", command)
+	cmd.Stderr = os.Stderr
+
+	out, err := cmd.Output()
+	if err != nil {
+		return "", fmt.Errorf("running token command: %w", err)
+	}
+
+	token := strings.TrimSpace(string(out))
+	if token == "" {
+		return "", errEmptyToken
+	}
+
+	return token, nil
+}
+
+// before applies the configuration file and validates the output format,
+// so that an invalid one is reported before making any requests.
+func before(ctx *cli.Context) error {
+	if err := smCli.ApplyConfig(ctx); err != nil {
+		return err
+	}
+
+	_, err := smCli.OutputFormat(ctx)
+
+	return err
+}
+
 func newTabWriter(ctx *cli.Context) smCli.WriteFlusher {
 	const padding = 2
 
+	if format, err := smCli.OutputFormat(ctx); err == nil && format.Kind == output.CSV {
+		return output.NewCSVWriter(ctx.App.Writer)
+	}
+
 	return tabwriter.NewWriter(ctx.App.Writer, 0, 0, padding, ' ', 0)
 }
 
-func newJsonWriter(ctx *cli.Context) func(interface{}, string) (bool, error) {
-	if !ctx.Bool("json") {
+func newOutputWriter(ctx *cli.Context) func(interface{}, string) (bool, error) {
+	format, err := smCli.OutputFormat(ctx)
+	if err != nil {
+		return func(interface{}, string) (bool, error) {
+			return true, err
+		}
+	}
+
+	if !format.Structured() {
 		return func(interface{}, string) (bool, error) {
 			return false, nil
 		}
 	}
 
 	return func(value interface{}, errMsg string) (bool, error) {
-		enc := json.NewEncoder(ctx.App.Writer)
-
-		if err := enc.Encode(value); err != nil {
+		if err := format.Write(ctx.App.Writer, value); err != nil {
 			return true, fmt.Errorf("%s: %w", errMsg, err)
 		}
 
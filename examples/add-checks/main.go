@@ -156,12 +156,7 @@ func addChecks(ctx context.Context, client *smapi.Client, logger zerolog.Logger)
 		return err
 	}
 
-	probeIDs := make([]int64, len(probes))
-	for i, p := range probes {
-		probeIDs[i] = p.Id
-	}
-
-	for _, check := range getTestChecks(1, probeIDs) {
+	for _, check := range getTestChecks(1, probes) {
 		c, err := client.AddCheck(ctx, model.Check{Check: check})
 		if err != nil {
 			logger.Error().Err(err).Msg("adding check")
@@ -176,11 +171,52 @@ func addChecks(ctx context.Context, client *smapi.Client, logger zerolog.Logger)
 	return nil
 }
 
-func getTestChecks(groupID int, probeIDs []int64) []synthetic_monitoring.Check {
+// browserScript is a minimal k6 browser script that loads the check's
+// target and takes a screenshot.
+const browserScript = `import { browser } from 'k6/browser';
+
+export const options = {
+  scenarios: {
+    ui: {
+      executor: 'shared-iterations',
+      options: { browser: { type: 'chromium' } },
+    },
+  },
+};
+
+export default async function () {
+  const page = await browser.newPage();
+
+  try {
+    await page.goto('https://grafana.com/');
+    await page.screenshot({ path: 'screenshot.png' });
+  } finally {
+    await page.close();
+  }
+}
+`
+
+func getTestChecks(groupID int, probes []synthetic_monitoring.Probe) []synthetic_monitoring.Check {
+	var (
+		probeIDs        []int64
+		browserProbeIDs []int64
+	)
+
+	for _, p := range probes {
+		probeIDs = append(probeIDs, p.Id)
+
+		// Some probes cannot run browser checks.
+		if p.Capabilities == nil || !p.Capabilities.DisableBrowserChecks {
+			browserProbeIDs = append(browserProbeIDs, p.Id)
+		}
+	}
+
 	checkConfigs := []struct {
 		target           string
 		job              string
 		basicMetricsOnly bool
+		frequency        int64
+		timeout          int64
 		settings         synthetic_monitoring.CheckSettings
 	}{
 		{
@@ -313,18 +349,43 @@ func getTestChecks(groupID int, probeIDs []int64) []synthetic_monitoring.Check {
 				},
 			},
 		},
+
+		// browser
+		{
+			target:           "https://grafana.com/",
+			job:              "browser",
+			basicMetricsOnly: false,
+			// k6 checks cannot run more often than once a minute.
+			frequency: 60000,
+			timeout:   30000,
+			settings: synthetic_monitoring.CheckSettings{
+				Browser: &synthetic_monitoring.BrowserSettings{
+					Script: []byte(browserScript),
+				},
+			},
+		},
 	}
 
 	checks := make([]synthetic_monitoring.Check, 0, len(checkConfigs))
 
 	for _, cfg := range checkConfigs {
+		frequency, timeout := cfg.frequency, cfg.timeout
+		if frequency == 0 {
+			frequency, timeout = 10000, 2000
+		}
+
+		ids := probeIDs
+		if cfg.settings.Browser != nil {
+			ids = browserProbeIDs
+		}
+
 		checks = append(checks, synthetic_monitoring.Check{
 			Target:           cfg.target,
 			Job:              fmt.Sprintf("%s-%d", cfg.job, groupID),
-			Frequency:        10000,
-			Timeout:          2000,
+			Frequency:        frequency,
+			Timeout:          timeout,
 			Enabled:          true,
-			Probes:           probeIDs,
+			Probes:           ids,
 			Settings:         cfg.settings,
 			BasicMetricsOnly: cfg.basicMetricsOnly,
 		})
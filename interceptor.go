@@ -0,0 +1,108 @@
+package smapi
+
+import (
+	"context"
+	"net/http"
+)
+
+// Operation describes a single call to the Synthetic Monitoring API made
+// using one of the client's methods, e.g. AddCheck.
+type Operation struct {
+	// Name is the name of the operation, which is the same as the
+	// action reported in HTTPError, e.g. "check add request".
+	Name string
+
+	// Method is the HTTP method used for the request.
+	Method string
+
+	// Path is the path of the request relative to the API's base URL,
+	// including the query string, if any.
+	Path string
+
+	// CheckID is the ID of the check the operation refers to, if
+	// it's known before sending the request.
+	CheckID int64
+
+	// ProbeID is the ID of the probe the operation refers to, if
+	// it's known before sending the request.
+	ProbeID int64
+
+	// Request is the object sent to the API server, if any, e.g. a
+	// *model.Check for AddCheck.
+	Request any
+
+	// Result is a pointer to the object that the response is decoded
+	// into. It is only valid after the operation completes without
+	// error.
+	Result any
+
+	// Header contains additional headers to send with the request.
+	// Interceptors can modify it before invoking the next one in the
+	// chain.
+	Header http.Header
+
+	// StatusCode is the status code of the last response received from
+	// the API server, or zero if no response was received.
+	StatusCode int
+
+	// Attempts is the number of times the request was sent to the API
+	// server, including retries.
+	Attempts int
+}
+
+// Mutating reports whether the operation modifies resources in the API
+// server, as opposed to only reading them.
+func (op *Operation) Mutating() bool {
+	switch op.Method {
+	case http.MethodGet, http.MethodHead, http.MethodOptions:
+		return false
+
+	default:
+		return true
+	}
+}
+
+// Invoker performs an operation.
+type Invoker func(ctx context.Context, op *Operation) error
+
+// Interceptor is called for every operation performed by the client. It
+// can inspect or modify the operation and must call next in order to
+// continue with it, or return an error to abort it. The error returned
+// by next is the error that the client's method would return.
+//
+// If an interceptor returns without calling next, the API server is not
+// contacted. If it returns nil in that case, the client's method
+// returns whatever the interceptor stored in op.Result.
+type Interceptor func(ctx context.Context, op *Operation, next Invoker) error
+
+type operationKey struct{}
+
+// invoke performs the operation op by passing it through the client's
+// interceptors and finally calling call.
+func (h *Client) invoke(ctx context.Context, op *Operation, call func(ctx context.Context) error) error {
+	if op.Header == nil {
+		op.Header = make(http.Header)
+	}
+
+	invoker := func(ctx context.Context, op *Operation) error {
+		return call(context.WithValue(ctx, operationKey{}, op))
+	}
+
+	for i := len(h.interceptors) - 1; i >= 0; i-- {
+		interceptor, next := h.interceptors[i], invoker
+
+		invoker = func(ctx context.Context, op *Operation) error {
+			return interceptor(ctx, op, next)
+		}
+	}
+
+	return invoker(ctx, op)
+}
+
+// operationFromContext returns the operation that the request using ctx
+// belongs to, or nil if it's not part of one.
+func operationFromContext(ctx context.Context) *Operation {
+	op, _ := ctx.Value(operationKey{}).(*Operation)
+
+	return op
+}
@@ -0,0 +1,163 @@
+package smapi
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"sync/atomic"
+	"testing"
+
+	sm "github.com/grafana/synthetic-monitoring-agent/pkg/pb/synthetic_monitoring"
+	"github.com/grafana/synthetic-monitoring-api-go-client/model"
+	"github.com/stretchr/testify/require"
+)
+
+func TestInterceptors(t *testing.T) {
+	url, mux, cleanup := newTestServer(t)
+	defer cleanup()
+
+	var auditUser string
+
+	mux.Handle("/api/v1/check/add", http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		auditUser = r.Header.Get("X-Audit-User")
+
+		writeResponse(w, http.StatusOK, model.Check{Check: sm.Check{Id: 42, Job: "job", Target: "target"}})
+	}))
+
+	var calls []string
+
+	recorder := func(name string) Interceptor {
+		return func(ctx context.Context, op *Operation, next Invoker) error {
+			calls = append(calls, name+" before "+op.Name)
+
+			err := next(ctx, op)
+
+			calls = append(calls, name+" after "+op.Name)
+
+			return err
+		}
+	}
+
+	var seen Operation
+
+	headers := func(ctx context.Context, op *Operation, next Invoker) error {
+		op.Header.Set("X-Audit-User", "someone")
+
+		err := next(ctx, op)
+
+		seen = *op
+
+		return err
+	}
+
+	c, err := New(url,
+		WithAccessToken("token"),
+		WithInterceptors(recorder("first"), recorder("second")),
+		WithInterceptors(headers),
+	)
+	require.NoError(t, err)
+
+	check := model.Check{Check: sm.Check{Job: "job", Target: "target"}}
+
+	result, err := c.AddCheck(context.Background(), check)
+	require.NoError(t, err)
+	require.Equal(t, int64(42), result.Id)
+
+	require.Equal(t, []string{
+		"first before check add request",
+		"second before check add request",
+		"second after check add request",
+		"first after check add request",
+	}, calls)
+
+	require.Equal(t, "someone", auditUser)
+
+	require.Equal(t, "check add request", seen.Name)
+	require.Equal(t, http.MethodPost, seen.Method)
+	require.Equal(t, "/check/add", seen.Path)
+	require.True(t, seen.Mutating())
+	require.Equal(t, &check, seen.Request)
+	require.Equal(t, result, seen.Result)
+	require.Equal(t, http.StatusOK, seen.StatusCode)
+	require.Equal(t, 1, seen.Attempts)
+}
+
+func TestInterceptorsSeeErrors(t *testing.T) {
+	url, mux, cleanup := newTestServer(t)
+	defer cleanup()
+
+	mux.Handle("/api/v1/check/", http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		errorResponse(w, http.StatusNotFound, "check not found")
+	}))
+
+	var (
+		seenErr    error
+		seenStatus int
+	)
+
+	c, err := New(url, WithAccessToken("token"), WithInterceptors(func(ctx context.Context, op *Operation, next Invoker) error {
+		seenErr = next(ctx, op)
+		seenStatus = op.StatusCode
+
+		return seenErr
+	}))
+	require.NoError(t, err)
+
+	_, err = c.GetCheck(context.Background(), 1)
+	require.ErrorIs(t, err, ErrNotFound)
+	require.ErrorIs(t, seenErr, ErrNotFound)
+	require.Equal(t, http.StatusNotFound, seenStatus)
+}
+
+func TestInterceptorsShortCircuit(t *testing.T) {
+	url, mux, cleanup := newTestServer(t)
+	defer cleanup()
+
+	var requests atomic.Int64
+
+	mux.Handle("/api/v1/", http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		requests.Add(1)
+		writeResponse(w, http.StatusOK, model.Check{Check: sm.Check{Id: 1}})
+	}))
+
+	errDryRun := errors.New("dry run")
+
+	dryRun := func(ctx context.Context, op *Operation, next Invoker) error {
+		if op.Mutating() {
+			return errDryRun
+		}
+
+		return next(ctx, op)
+	}
+
+	cached := func(ctx context.Context, op *Operation, next Invoker) error {
+		if check, ok := op.Result.(*model.Check); ok && op.Path == "/check/2" {
+			check.Id = 2
+
+			return nil
+		}
+
+		return next(ctx, op)
+	}
+
+	c, err := New(url, WithAccessToken("token"), WithInterceptors(dryRun, cached))
+	require.NoError(t, err)
+
+	_, err = c.AddCheck(context.Background(), model.Check{})
+	require.ErrorIs(t, err, errDryRun)
+
+	err = c.DeleteCheck(context.Background(), 1)
+	require.ErrorIs(t, err, errDryRun)
+
+	require.Zero(t, requests.Load())
+
+	check, err := c.GetCheck(context.Background(), 2)
+	require.NoError(t, err)
+	require.Equal(t, int64(2), check.Id)
+	require.Zero(t, requests.Load())
+
+	check, err = c.GetCheck(context.Background(), 1)
+	require.NoError(t, err)
+	require.Equal(t, int64(1), check.Id)
+	require.Equal(t, int64(1), requests.Load())
+}
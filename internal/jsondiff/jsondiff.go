@@ -0,0 +1,128 @@
+// Package jsondiff compares values using their JSON representation, field
+// by field.
+package jsondiff
+
+import (
+	"encoding/json"
+	"fmt"
+	"sort"
+	"strconv"
+)
+
+// Tree returns the JSON representation of v as the generic values
+// produced by encoding/json: maps, slices, strings, float64, bool and nil.
+func Tree(v any) (any, error) {
+	buf, err := json.Marshal(v)
+	if err != nil {
+		return nil, fmt.Errorf("marshaling value: %w", err)
+	}
+
+	var tree any
+	if err := json.Unmarshal(buf, &tree); err != nil {
+		return nil, fmt.Errorf("unmarshaling value: %w", err)
+	}
+
+	return tree, nil
+}
+
+// Flatten returns the JSON representation of v as a map from the path of
+// each scalar value to its JSON encoding. Paths use dots for object keys
+// and brackets for array indexes, e.g. "settings.http.headers[0]".
+func Flatten(v any) (map[string]string, error) {
+	tree, err := Tree(v)
+	if err != nil {
+		return nil, err
+	}
+
+	out := make(map[string]string)
+	flatten("", tree, out)
+
+	return out, nil
+}
+
+func flatten(prefix string, v any, out map[string]string) {
+	switch v := v.(type) {
+	case map[string]any:
+		for key, value := range v {
+			if prefix != "" {
+				key = prefix + "." + key
+			}
+			flatten(key, value, out)
+		}
+
+	case []any:
+		for i, value := range v {
+			flatten(prefix+"["+strconv.Itoa(i)+"]", value, out)
+		}
+
+	default:
+		buf, _ := json.Marshal(v)
+		out[prefix] = string(buf)
+	}
+}
+
+// Fields returns the fields of v as sorted "path: value" lines, using the
+// paths and values returned by Flatten.
+func Fields(v any) ([]string, error) {
+	fields, err := Flatten(v)
+	if err != nil {
+		return nil, err
+	}
+
+	lines := make([]string, 0, len(fields))
+	for path, value := range fields {
+		lines = append(lines, path+": "+value)
+	}
+
+	sort.Strings(lines)
+
+	return lines, nil
+}
+
+// Changes compares the JSON representation of a and b, and returns the
+// differences between them, one per changed field, in the form
+// "field: old -> new". Fields are named using the paths returned by
+// Flatten. It returns no changes if a and b are equivalent.
+func Changes(a, b any) ([]string, error) {
+	before, err := Flatten(a)
+	if err != nil {
+		return nil, err
+	}
+
+	after, err := Flatten(b)
+	if err != nil {
+		return nil, err
+	}
+
+	fields := make(map[string]struct{}, len(before)+len(after))
+	for field := range before {
+		fields[field] = struct{}{}
+	}
+	for field := range after {
+		fields[field] = struct{}{}
+	}
+
+	var changes []string
+
+	for field := range fields {
+		oldValue, oldFound := before[field]
+		newValue, newFound := after[field]
+
+		switch {
+		case oldFound && newFound && oldValue == newValue:
+			continue
+
+		case !oldFound:
+			oldValue = "(none)"
+
+		case !newFound:
+			newValue = "(none)"
+		}
+
+		changes = append(changes, field+": "+oldValue+" -> "+newValue)
+	}
+
+	sort.Strings(changes)
+
+	return changes, nil
+}
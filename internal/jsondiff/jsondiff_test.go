@@ -0,0 +1,87 @@
+package jsondiff
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestFlatten(t *testing.T) {
+	type inner struct {
+		Method string `json:"method"`
+	}
+
+	value := struct {
+		ID      int64    `json:"id"`
+		Enabled bool     `json:"enabled"`
+		Probes  []int64  `json:"probes"`
+		HTTP    *inner   `json:"http"`
+		None    *inner   `json:"none"`
+		Empty   []string `json:"empty"`
+	}{
+		ID:      1,
+		Enabled: true,
+		Probes:  []int64{3, 4},
+		HTTP:    &inner{Method: "GET"},
+		Empty:   []string{},
+	}
+
+	out, err := Flatten(value)
+	require.NoError(t, err)
+	require.Equal(t, map[string]string{
+		"id":          "1",
+		"enabled":     "true",
+		"probes[0]":   "3",
+		"probes[1]":   "4",
+		"http.method": `"GET"`,
+		"none":        "null",
+	}, out)
+
+	_, err = Flatten(func() {})
+	require.Error(t, err)
+}
+
+func TestChanges(t *testing.T) {
+	before := map[string]any{
+		"frequency": 60000,
+		"probes":    []int{1, 2},
+		"settings":  map[string]any{"http": map[string]any{"method": "GET"}},
+	}
+
+	t.Run("no changes", func(t *testing.T) {
+		changes, err := Changes(before, before)
+		require.NoError(t, err)
+		require.Empty(t, changes)
+	})
+
+	t.Run("changes", func(t *testing.T) {
+		after := map[string]any{
+			"frequency": 120000,
+			"probes":    []int{1},
+			"settings":  map[string]any{"http": map[string]any{"method": "GET", "body": "x"}},
+		}
+
+		changes, err := Changes(before, after)
+		require.NoError(t, err)
+		require.Equal(t, []string{
+			"frequency: 60000 -> 120000",
+			"probes[1]: 2 -> (none)",
+			`settings.http.body: (none) -> "x"`,
+		}, changes)
+	})
+}
+
+func TestFields(t *testing.T) {
+	lines, err := Fields(map[string]any{
+		"target":   "www.example.org",
+		"probes":   []string{"Atlanta", "Paris"},
+		"settings": map[string]any{"ping": map[string]any{"ipVersion": "V4"}},
+	})
+	require.NoError(t, err)
+	require.Equal(t, []string{
+		`probes[0]: "Atlanta"`,
+		`probes[1]: "Paris"`,
+		`settings.ping.ipVersion: "V4"`,
+		`target: "www.example.org"`,
+	}, lines)
+}
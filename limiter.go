@@ -0,0 +1,149 @@
+package smapi
+
+import (
+	"context"
+	"fmt"
+	"sync"
+	"sync/atomic"
+
+	"golang.org/x/time/rate"
+)
+
+// LimiterStats describes the current state of the client-side limits
+// on requests sent to the API server.
+type LimiterStats struct {
+	// Rate is the number of requests per second allowed by the rate
+	// limiter, or zero if there's no rate limit.
+	Rate float64
+
+	// Burst is the maximum number of requests that can be sent at
+	// once by the rate limiter.
+	Burst int
+
+	// Tokens is the number of requests that can be sent right now
+	// without waiting for the rate limiter.
+	Tokens float64
+
+	// InFlight is the number of requests currently being processed,
+	// including reading the response.
+	InFlight int
+
+	// MaxInFlight is the maximum number of requests that can be in
+	// flight at the same time, or zero if there's no limit.
+	MaxInFlight int
+
+	// Waiting is the number of requests waiting for capacity.
+	Waiting int
+}
+
+// limiter limits the rate and concurrency of requests sent by a
+// client. A nil limiter does not impose any limits.
+type limiter struct {
+	rate     *rate.Limiter
+	slots    chan struct{}
+	waiting  atomic.Int64
+	inFlight atomic.Int64
+}
+
+func (l *limiter) setRate(r float64, burst int) {
+	if r <= 0 {
+		l.rate = nil
+
+		return
+	}
+
+	l.rate = rate.NewLimiter(rate.Limit(r), max(burst, 1))
+}
+
+func (l *limiter) setMaxInFlight(n int) {
+	if n <= 0 {
+		l.slots = nil
+
+		return
+	}
+
+	l.slots = make(chan struct{}, n)
+}
+
+// acquire waits until there's capacity to send a request or the
+// context is done. The returned function must be called once the
+// request is complete.
+func (l *limiter) acquire(ctx context.Context) (func(), error) {
+	if l == nil {
+		return func() {}, nil
+	}
+
+	if err := l.wait(ctx); err != nil {
+		return nil, err
+	}
+
+	l.inFlight.Add(1)
+
+	var once sync.Once
+
+	return func() {
+		once.Do(func() {
+			l.inFlight.Add(-1)
+
+			if l.slots != nil {
+				<-l.slots
+			}
+		})
+	}, nil
+}
+
+func (l *limiter) wait(ctx context.Context) error {
+	if l.rate == nil && l.slots == nil {
+		return nil
+	}
+
+	l.waiting.Add(1)
+	defer l.waiting.Add(-1)
+
+	if l.rate != nil {
+		if err := l.rate.Wait(ctx); err != nil {
+			return fmt.Errorf("waiting for rate limiter: %w", err)
+		}
+	}
+
+	if l.slots == nil {
+		return nil
+	}
+
+	select {
+	case l.slots <- struct{}{}:
+		return nil
+
+	case <-ctx.Done():
+		return fmt.Errorf("waiting for in-flight requests: %w", ctx.Err())
+	}
+}
+
+func (l *limiter) stats() LimiterStats {
+	var stats LimiterStats
+
+	if l == nil {
+		return stats
+	}
+
+	if l.rate != nil {
+		stats.Rate = float64(l.rate.Limit())
+		stats.Burst = l.rate.Burst()
+		stats.Tokens = l.rate.Tokens()
+	}
+
+	if l.slots != nil {
+		stats.MaxInFlight = cap(l.slots)
+	}
+
+	stats.InFlight = int(l.inFlight.Load())
+	stats.Waiting = int(l.waiting.Load())
+
+	return stats
+}
+
+// LimiterStats returns the current state of the client-side limits
+// configured using WithRateLimit and WithMaxInFlight.
+func (h *Client) LimiterStats() LimiterStats {
+	return h.limiter.stats()
+}
@@ -0,0 +1,162 @@
+package smapi
+
+import (
+	"context"
+	"net/http"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestRateLimit(t *testing.T) {
+	url, mux, cleanup := newTestServer(t)
+	defer cleanup()
+
+	mux.Handle("/api/v1/test", http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusOK)
+	}))
+
+	const requestsPerSecond = 20
+
+	c, err := New(url, WithRateLimit(requestsPerSecond, 1))
+	require.NoError(t, err)
+
+	stats := c.LimiterStats()
+	require.Equal(t, float64(requestsPerSecond), stats.Rate)
+	require.Equal(t, 1, stats.Burst)
+
+	start := time.Now()
+
+	for range 5 {
+		resp, err := c.Get(context.Background(), "/test", false, nil)
+		require.NoError(t, err)
+		resp.Body.Close()
+	}
+
+	// The first request goes through right away, the remaining four
+	// have to wait for 50 ms each.
+	require.GreaterOrEqual(t, time.Since(start), 150*time.Millisecond)
+}
+
+func TestRateLimitContextCanceled(t *testing.T) {
+	url, mux, cleanup := newTestServer(t)
+	defer cleanup()
+
+	var requests atomic.Int64
+	mux.Handle("/api/v1/test", http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		requests.Add(1)
+		w.WriteHeader(http.StatusOK)
+	}))
+
+	c, err := New(url, WithRateLimit(0.001, 1))
+	require.NoError(t, err)
+
+	resp, err := c.Get(context.Background(), "/test", false, nil)
+	require.NoError(t, err)
+	resp.Body.Close()
+
+	ctx, cancel := context.WithTimeout(context.Background(), 50*time.Millisecond)
+	defer cancel()
+
+	resp, err = c.Get(ctx, "/test", false, nil) //nolint:bodyclose // resp is nil
+	require.Error(t, err)
+	require.Nil(t, resp)
+	require.Equal(t, int64(1), requests.Load())
+}
+
+func TestMaxInFlight(t *testing.T) {
+	url, mux, cleanup := newTestServer(t)
+	defer cleanup()
+
+	const maxInFlight = 2
+
+	var (
+		current     atomic.Int64
+		maxObserved atomic.Int64
+		unblock     = make(chan struct{})
+	)
+
+	mux.Handle("/api/v1/test", http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		n := current.Add(1)
+		defer current.Add(-1)
+
+		for {
+			m := maxObserved.Load()
+			if n <= m || maxObserved.CompareAndSwap(m, n) {
+				break
+			}
+		}
+
+		<-unblock
+		w.WriteHeader(http.StatusOK)
+	}))
+
+	c, err := New(url, WithMaxInFlight(maxInFlight))
+	require.NoError(t, err)
+
+	var wg sync.WaitGroup
+
+	for range 5 {
+		wg.Add(1)
+
+		go func() {
+			defer wg.Done()
+
+			resp, err := c.Get(context.Background(), "/test", false, nil)
+			if err == nil {
+				resp.Body.Close()
+			}
+		}()
+	}
+
+	require.Eventually(t, func() bool {
+		stats := c.LimiterStats()
+
+		return stats.InFlight == maxInFlight && stats.Waiting == 3
+	}, 5*time.Second, 10*time.Millisecond)
+
+	require.Equal(t, maxInFlight, c.LimiterStats().MaxInFlight)
+
+	close(unblock)
+	wg.Wait()
+
+	require.LessOrEqual(t, maxObserved.Load(), int64(maxInFlight))
+
+	stats := c.LimiterStats()
+	require.Zero(t, stats.InFlight)
+	require.Zero(t, stats.Waiting)
+}
+
+func TestMaxInFlightReleasedOnClose(t *testing.T) {
+	url, mux, cleanup := newTestServer(t)
+	defer cleanup()
+
+	mux.Handle("/api/v1/test", http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusOK)
+	}))
+
+	c, err := New(url, WithMaxInFlight(1))
+	require.NoError(t, err)
+
+	resp, err := c.Get(context.Background(), "/test", false, nil)
+	require.NoError(t, err)
+
+	// The slot is held until the body is closed.
+	require.Equal(t, 1, c.LimiterStats().InFlight)
+
+	ctx, cancel := context.WithTimeout(context.Background(), 50*time.Millisecond)
+	defer cancel()
+
+	_, err = c.Get(ctx, "/test", false, nil) //nolint:bodyclose // there's no response
+	require.ErrorIs(t, err, context.DeadlineExceeded)
+
+	resp.Body.Close()
+	require.Zero(t, c.LimiterStats().InFlight)
+
+	resp, err = c.Get(context.Background(), "/test", false, nil)
+	require.NoError(t, err)
+	resp.Body.Close()
+}
@@ -0,0 +1,246 @@
+package smapi
+
+import (
+	"bytes"
+	"encoding/json"
+	"io"
+	"log/slog"
+	"net/http"
+	"strings"
+	"time"
+)
+
+// redacted replaces secrets in logged requests and responses.
+const redacted = "REDACTED"
+
+// redactedFields lists the (lowercase) names of JSON fields whose values
+// are never logged. These cover access tokens, probe tokens, credentials
+// in check settings and the values of secrets.
+var redactedFields = map[string]struct{}{
+	"accesstoken": {},
+	"bearertoken": {},
+	"password":    {},
+	"plaintext":   {},
+	"token":       {},
+}
+
+// redactedHeaders lists the (canonical) names of headers whose values are
+// never logged.
+var redactedHeaders = map[string]struct{}{
+	"Authorization": {},
+	"Cookie":        {},
+	"Set-Cookie":    {},
+}
+
+// logExchange logs the result of sending req. Successful requests are
+// logged at debug level, so that they don't flood production logs, and
+// failed ones at info or warn level. At debug level, the request and
+// response bodies are logged, too, with secrets redacted. In that case
+// resp's body is read and replaced.
+func (h *Client) logExchange(req *http.Request, resp *http.Response, err error, duration time.Duration) {
+	ctx := req.Context()
+	logger := h.getLogger()
+
+	attrs := []slog.Attr{
+		slog.String("method", req.Method),
+		slog.String("path", req.URL.Path),
+		slog.Duration("duration", duration),
+	}
+
+	if op := operationFromContext(ctx); op != nil {
+		attrs = append(attrs, slog.String("operation", op.Name), slog.Int("attempts", op.Attempts))
+	}
+
+	if err != nil {
+		logger.LogAttrs(ctx, slog.LevelWarn, "API request failed", append(attrs, slog.Any("error", err))...)
+
+		return
+	}
+
+	attrs = append(attrs, slog.Int("status", resp.StatusCode))
+
+	logger.LogAttrs(ctx, exchangeLevel(resp.StatusCode), "API request", attrs...)
+
+	if !logger.Enabled(ctx, slog.LevelDebug) {
+		return
+	}
+
+	logger.LogAttrs(ctx, slog.LevelDebug, "API request details",
+		slog.Any("request_headers", redactHeaders(req.Header)),
+		slog.String("request_body", requestBody(req)),
+		slog.Any("response_headers", redactHeaders(resp.Header)),
+		slog.String("response_body", responseBody(resp)),
+	)
+}
+
+// exchangeLevel returns the level used to log a request that received a
+// response with the specified status code.
+func exchangeLevel(status int) slog.Level {
+	switch {
+	case status >= http.StatusInternalServerError:
+		return slog.LevelWarn
+
+	case status >= http.StatusBadRequest:
+		return slog.LevelInfo
+
+	default:
+		return slog.LevelDebug
+	}
+}
+
+// requestBody returns the body of req for logging purposes, without
+// consuming it.
+func requestBody(req *http.Request) string {
+	if req.GetBody == nil {
+		return ""
+	}
+
+	body, err := req.GetBody()
+	if err != nil {
+		return ""
+	}
+	defer body.Close()
+
+	buf, err := io.ReadAll(body)
+	if err != nil {
+		return ""
+	}
+
+	return redactBody(buf)
+}
+
+// responseBody reads the body of resp for logging purposes, and replaces
+// it so that the caller can still read it.
+func responseBody(resp *http.Response) string {
+	buf, err := io.ReadAll(resp.Body)
+
+	resp.Body = struct {
+		io.Reader
+		io.Closer
+	}{
+		Reader: io.MultiReader(bytes.NewReader(buf), errReader{err: err}),
+		Closer: resp.Body,
+	}
+
+	return redactBody(buf)
+}
+
+// errReader returns err when read. A nil err is reported as io.EOF.
+type errReader struct {
+	err error
+}
+
+func (r errReader) Read([]byte) (int, error) {
+	if r.err == nil {
+		return 0, io.EOF
+	}
+
+	return 0, r.err
+}
+
+// redactBody returns body with the values of sensitive fields replaced.
+// Bodies that are not JSON are not logged, as it's not possible to
+// tell whether they contain secrets.
+func redactBody(body []byte) string {
+	if len(bytes.TrimSpace(body)) == 0 {
+		return ""
+	}
+
+	var v any
+	if err := json.Unmarshal(body, &v); err != nil {
+		return "[non-JSON body omitted]"
+	}
+
+	buf, err := json.Marshal(redactValue(v))
+	if err != nil {
+		return "[non-JSON body omitted]"
+	}
+
+	return string(buf)
+}
+
+func redactValue(v any) any {
+	switch v := v.(type) {
+	case map[string]any:
+		for key, value := range v {
+			switch _, found := redactedFields[strings.ToLower(key)]; {
+			case found:
+				if value != nil && value != "" {
+					v[key] = redacted
+				}
+
+			case strings.HasSuffix(strings.ToLower(key), "headers"):
+				// HTTP check settings include headers as a list of
+				// "Name: value" strings.
+				v[key] = redactHeaderList(value)
+
+			default:
+				v[key] = redactValue(value)
+			}
+		}
+
+	case []any:
+		for i := range v {
+			v[i] = redactValue(v[i])
+		}
+	}
+
+	return v
+}
+
+func redactHeaderList(v any) any {
+	list, ok := v.([]any)
+	if !ok {
+		return redactValue(v)
+	}
+
+	for i, item := range list {
+		switch header := item.(type) {
+		case string:
+			// "Name: value"
+			name, _, found := strings.Cut(header, ":")
+			if found && isRedactedHeader(name) {
+				list[i] = name + ": " + redacted
+			}
+
+		case map[string]any:
+			// {"name": "Name", "value": "value"}
+			if name, ok := header["name"].(string); ok && isRedactedHeader(name) {
+				header["value"] = redacted
+			}
+
+		default:
+			list[i] = redactValue(item)
+		}
+	}
+
+	return list
+}
+
+func isRedactedHeader(name string) bool {
+	_, found := redactedHeaders[http.CanonicalHeaderKey(strings.TrimSpace(name))]
+
+	return found
+}
+
+func redactHeaders(headers http.Header) map[string]string {
+	out := make(map[string]string, len(headers))
+
+	for name, values := range headers {
+		if isRedactedHeader(name) {
+			out[name] = redacted
+
+			continue
+		}
+
+		out[name] = strings.Join(values, ", ")
+	}
+
+	return out
+}
+
+// NewDebugLogger returns a logger suitable for WithLogger that writes
+// every request, including redacted bodies, to w in text format.
+func NewDebugLogger(w io.Writer) *slog.Logger {
+	return slog.New(slog.NewTextHandler(w, &slog.HandlerOptions{Level: slog.LevelDebug}))
+}
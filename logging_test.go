@@ -0,0 +1,199 @@
+package smapi
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"io"
+	"log/slog"
+	"net/http"
+	"strings"
+	"testing"
+
+	sm "github.com/grafana/synthetic-monitoring-agent/pkg/pb/synthetic_monitoring"
+	"github.com/grafana/synthetic-monitoring-api-go-client/model"
+	"github.com/stretchr/testify/require"
+)
+
+func TestRedactBody(t *testing.T) {
+	testcases := map[string]struct {
+		input    string
+		expected string
+	}{
+		"empty": {
+			input:    "",
+			expected: "",
+		},
+		"not json": {
+			input:    "accessToken=secret",
+			expected: "[non-JSON body omitted]",
+		},
+		"access token": {
+			input:    `{"accessToken":"secret","tenantInfo":{"id":1}}`,
+			expected: `{"accessToken":"REDACTED","tenantInfo":{"id":1}}`,
+		},
+		"probe token": {
+			input:    `{"probe":{"id":1,"name":"probe"},"token":"c2VjcmV0"}`,
+			expected: `{"probe":{"id":1,"name":"probe"},"token":"REDACTED"}`,
+		},
+		"secret value": {
+			input:    `{"name":"secret","plaintext":"hunter2"}`,
+			expected: `{"name":"secret","plaintext":"REDACTED"}`,
+		},
+		"empty token": {
+			input:    `{"token":""}`,
+			expected: `{"token":""}`,
+		},
+		"http settings": {
+			input: `{"settings":{"http":{"bearerToken":"secret","basicAuth":{"username":"user","password":"secret"},` +
+				`"headers":["Authorization: Basic c2VjcmV0","X-Test: value"]}}}`,
+			expected: `{"settings":{"http":{"basicAuth":{"password":"REDACTED","username":"user"},"bearerToken":"REDACTED",` +
+				`"headers":["Authorization: REDACTED","X-Test: value"]}}}`,
+		},
+		"multihttp headers": {
+			input:    `[{"request":{"headers":[{"name":"authorization","value":"secret"},{"name":"x-test","value":"value"}]}}]`,
+			expected: `[{"request":{"headers":[{"name":"authorization","value":"REDACTED"},{"name":"x-test","value":"value"}]}}]`,
+		},
+	}
+
+	for name, tc := range testcases {
+		t.Run(name, func(t *testing.T) {
+			require.Equal(t, tc.expected, redactBody([]byte(tc.input)))
+		})
+	}
+}
+
+func TestRedactHeaders(t *testing.T) {
+	headers := http.Header{
+		"Authorization": []string{"Bearer secret"},
+		"Content-Type":  []string{"application/json"},
+	}
+
+	require.Equal(t, map[string]string{
+		"Authorization": "REDACTED",
+		"Content-Type":  "application/json",
+	}, redactHeaders(headers))
+}
+
+func TestLogger(t *testing.T) {
+	url, mux, cleanup := newTestServer(t)
+	defer cleanup()
+
+	mux.Handle("/api/v1/probe/add", http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var probe sm.Probe
+		_ = json.NewDecoder(r.Body).Decode(&probe)
+		probe.Id = 1
+
+		writeResponse(w, http.StatusOK, struct {
+			Probe sm.Probe `json:"probe"`
+			Token []byte   `json:"token"`
+		}{Probe: probe, Token: []byte("probe-secret")})
+	}))
+
+	var buf bytes.Buffer
+
+	logger := slog.New(slog.NewJSONHandler(&buf, &slog.HandlerOptions{Level: slog.LevelDebug}))
+
+	c, err := New(url, WithAccessToken("api-secret"), WithLogger(logger))
+	require.NoError(t, err)
+
+	probe, token, err := c.AddProbe(context.Background(), sm.Probe{Name: "test-probe"})
+	require.NoError(t, err)
+
+	// The response is still available to the caller.
+	require.Equal(t, int64(1), probe.Id)
+	require.Equal(t, []byte("probe-secret"), token)
+
+	output := buf.String()
+	require.NotContains(t, output, "api-secret")
+	require.NotContains(t, output, "cHJvYmUtc2VjcmV0") // base64 of the probe token
+
+	var records []map[string]any
+
+	dec := json.NewDecoder(strings.NewReader(output))
+
+	for {
+		var record map[string]any
+
+		err := dec.Decode(&record)
+		if err == io.EOF {
+			break
+		}
+
+		require.NoError(t, err)
+
+		records = append(records, record)
+	}
+
+	require.Len(t, records, 2)
+
+	require.Equal(t, "API request", records[0]["msg"])
+	require.Equal(t, "DEBUG", records[0]["level"])
+	require.Equal(t, http.MethodPost, records[0]["method"])
+	require.Equal(t, "/api/v1/probe/add", records[0]["path"])
+	require.Equal(t, "probe add request", records[0]["operation"])
+	require.EqualValues(t, http.StatusOK, records[0]["status"])
+	require.Contains(t, records[0], "duration")
+
+	require.Equal(t, "API request details", records[1]["msg"])
+	require.Contains(t, records[1]["request_body"], "test-probe")
+	require.Contains(t, records[1]["response_body"], `"token":"REDACTED"`)
+}
+
+func TestLoggerLevels(t *testing.T) {
+	url, mux, cleanup := newTestServer(t)
+	defer cleanup()
+
+	mux.Handle("/api/v1/check/1", http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		writeResponse(w, http.StatusOK, &model.Check{})
+	}))
+	mux.Handle("/api/v1/check/2", http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		errorResponse(w, http.StatusNotFound, "not found")
+	}))
+	mux.Handle("/api/v1/check/3", http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		errorResponse(w, http.StatusInternalServerError, "broken")
+	}))
+
+	testcases := map[string]struct {
+		id       int64
+		expected string
+	}{
+		"success":      {id: 1, expected: ""},
+		"client error": {id: 2, expected: "level=INFO"},
+		"server error": {id: 3, expected: "level=WARN"},
+	}
+
+	for name, tc := range testcases {
+		t.Run(name, func(t *testing.T) {
+			var buf bytes.Buffer
+
+			// A typical production logger, logging at info level.
+			logger := slog.New(slog.NewTextHandler(&buf, nil))
+
+			c, err := New(url, WithAccessToken("token"), WithLogger(logger))
+			require.NoError(t, err)
+
+			_, _ = c.GetCheck(context.Background(), tc.id)
+
+			if tc.expected == "" {
+				require.Empty(t, buf.String())
+				return
+			}
+
+			require.Contains(t, buf.String(), tc.expected)
+			require.Contains(t, buf.String(), `msg="API request"`)
+		})
+	}
+
+	t.Run("transport error", func(t *testing.T) {
+		var buf bytes.Buffer
+
+		c, err := New("http://127.0.0.1:1", WithAccessToken("token"), WithLogger(slog.New(slog.NewTextHandler(&buf, nil))))
+		require.NoError(t, err)
+
+		_, err = c.GetCheck(context.Background(), 1)
+		require.Error(t, err)
+		require.Contains(t, buf.String(), "level=WARN")
+		require.Contains(t, buf.String(), `msg="API request failed"`)
+	})
+}
@@ -0,0 +1,251 @@
+package manifest
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/grafana/synthetic-monitoring-api-go-client/internal/jsondiff"
+)
+
+// diffContext is the number of unchanged lines shown around changes.
+const diffContext = 3
+
+// Diff returns a unified diff between the live checks and probes and the
+// ones in the manifests, for every change in the plan, with one line per
+// field. The diff is computed on the canonical representation of the
+// checks and probes, so server-managed fields and default values are not
+// shown, and probes are referred to by name.
+func (p *Plan) Diff() (string, error) {
+	var sb strings.Builder
+
+	for _, change := range p.Probes {
+		name := fmt.Sprintf("probe %q", change.Name)
+
+		if err := writeDiff(&sb, name, optional(change.Current), optional(change.Desired)); err != nil {
+			return "", err
+		}
+	}
+
+	for _, change := range p.Checks {
+		name := "check " + change.Key.String()
+
+		if err := writeDiff(&sb, name, optional(change.Current), optional(change.Desired)); err != nil {
+			return "", err
+		}
+	}
+
+	return sb.String(), nil
+}
+
+// optional returns the value pointed to by v, or nil if v is nil, so that
+// a missing check or probe is not mistaken for an empty one.
+func optional[T any](v *T) any {
+	if v == nil {
+		return nil
+	}
+
+	return *v
+}
+
+func writeDiff(sb *strings.Builder, name string, current, desired any) error {
+	a, err := canonicalLines(current)
+	if err != nil {
+		return fmt.Errorf("%s: %w", name, err)
+	}
+
+	b, err := canonicalLines(desired)
+	if err != nil {
+		return fmt.Errorf("%s: %w", name, err)
+	}
+
+	fmt.Fprintf(sb, "--- live: %s\n", name)
+	fmt.Fprintf(sb, "+++ manifest: %s\n", name)
+	sb.WriteString(unifiedDiff(a, b, diffContext))
+
+	return nil
+}
+
+// canonicalLines returns the fields of the canonical representation of v,
+// one "path: value" line per field, sorted by path.
+func canonicalLines(v any) ([]string, error) {
+	if v == nil {
+		return nil, nil
+	}
+
+	c, err := canonical(v)
+	if err != nil {
+		return nil, err
+	}
+
+	if c == nil {
+		return nil, nil
+	}
+
+	return jsondiff.Fields(c)
+}
+
+type diffOp struct {
+	kind byte // ' ', '-' or '+'
+	line string
+	// ai and bi are the number of lines of a and b before the line.
+	ai, bi int
+}
+
+// lineDiff returns the edit script turning a into b, based on their
+// longest common subsequence.
+func lineDiff(a, b []string) []diffOp {
+	// lcs[i][j] is the length of the longest common subsequence of a[i:]
+	// and b[j:].
+	lcs := make([][]int, len(a)+1)
+	for i := range lcs {
+		lcs[i] = make([]int, len(b)+1)
+	}
+
+	for i := len(a) - 1; i >= 0; i-- {
+		for j := len(b) - 1; j >= 0; j-- {
+			if a[i] == b[j] {
+				lcs[i][j] = lcs[i+1][j+1] + 1
+			} else {
+				lcs[i][j] = max(lcs[i+1][j], lcs[i][j+1])
+			}
+		}
+	}
+
+	ops := make([]diffOp, 0, len(a)+len(b))
+	i, j := 0, 0
+
+	for i < len(a) || j < len(b) {
+		switch {
+		case i < len(a) && j < len(b) && a[i] == b[j]:
+			ops = append(ops, diffOp{kind: ' ', line: a[i]})
+			i++
+			j++
+
+		case j < len(b) && (i == len(a) || lcs[i][j+1] >= lcs[i+1][j]):
+			ops = append(ops, diffOp{kind: '+', line: b[j]})
+			j++
+
+		default:
+			ops = append(ops, diffOp{kind: '-', line: a[i]})
+			i++
+		}
+	}
+
+	// Show deletions before insertions within each run of changes, as
+	// diff tools usually do.
+	for start := 0; start < len(ops); {
+		if ops[start].kind == ' ' {
+			start++
+			continue
+		}
+
+		end := start
+		for end < len(ops) && ops[end].kind != ' ' {
+			end++
+		}
+
+		run := make([]diffOp, 0, end-start)
+		for _, kind := range []byte{'-', '+'} {
+			for _, op := range ops[start:end] {
+				if op.kind == kind {
+					run = append(run, op)
+				}
+			}
+		}
+
+		copy(ops[start:end], run)
+		start = end
+	}
+
+	i, j = 0, 0
+
+	for k := range ops {
+		ops[k].ai, ops[k].bi = i, j
+
+		if ops[k].kind != '+' {
+			i++
+		}
+
+		if ops[k].kind != '-' {
+			j++
+		}
+	}
+
+	return ops
+}
+
+// unifiedDiff returns the hunks of the unified diff between a and b, with
+// n lines of context. It returns an empty string if a and b are equal.
+func unifiedDiff(a, b []string, n int) string {
+	ops := lineDiff(a, b)
+
+	var sb strings.Builder
+
+	for start := 0; start < len(ops); {
+		// Find the next change.
+		first := start
+		for first < len(ops) && ops[first].kind == ' ' {
+			first++
+		}
+
+		if first == len(ops) {
+			break
+		}
+
+		// Extend the hunk until there are more than 2*n unchanged
+		// lines in a row, or the end is reached.
+		last := first
+		for k := first; k < len(ops); k++ {
+			if ops[k].kind != ' ' {
+				last = k
+			} else if k-last > 2*n {
+				break
+			}
+		}
+
+		lo := max(first-n, start)
+		hi := min(last+n+1, len(ops))
+
+		writeHunk(&sb, ops[lo:hi])
+		start = hi
+	}
+
+	return sb.String()
+}
+
+func writeHunk(sb *strings.Builder, ops []diffOp) {
+	var aLen, bLen int
+
+	for _, op := range ops {
+		if op.kind != '+' {
+			aLen++
+		}
+
+		if op.kind != '-' {
+			bLen++
+		}
+	}
+
+	fmt.Fprintf(sb, "@@ -%s +%s @@\n", hunkRange(ops[0].ai, aLen), hunkRange(ops[0].bi, bLen))
+
+	for _, op := range ops {
+		sb.WriteByte(op.kind)
+		sb.WriteString(op.line)
+		sb.WriteByte('\n')
+	}
+}
+
+// hunkRange formats a range of lines the way unified diffs do: the first
+// line is numbered from 1, except for empty ranges, where it's the line
+// before the range.
+func hunkRange(start, length int) string {
+	if length == 0 {
+		return fmt.Sprintf("%d,0", start)
+	}
+
+	if length == 1 {
+		return fmt.Sprint(start + 1)
+	}
+
+	return fmt.Sprintf("%d,%d", start+1, length)
+}
@@ -0,0 +1,115 @@
+package manifest
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestUnifiedDiff(t *testing.T) {
+	lines := func(s string) []string {
+		if s == "" {
+			return nil
+		}
+
+		return strings.Split(s, " ")
+	}
+
+	testcases := map[string]struct {
+		a, b     string
+		expected string
+	}{
+		"equal": {
+			a:        "a b c",
+			b:        "a b c",
+			expected: "",
+		},
+		"change": {
+			a:        "a b c",
+			b:        "a x c",
+			expected: "@@ -1,3 +1,3 @@\n a\n-b\n+x\n c\n",
+		},
+		"create": {
+			a:        "",
+			b:        "a b",
+			expected: "@@ -0,0 +1,2 @@\n+a\n+b\n",
+		},
+		"delete": {
+			a:        "a",
+			b:        "",
+			expected: "@@ -1 +0,0 @@\n-a\n",
+		},
+		"context": {
+			a:        "1 2 3 4 5 6 7 8 9",
+			b:        "1 2 3 4 x 6 7 8 9",
+			expected: "@@ -2,7 +2,7 @@\n 2\n 3\n 4\n-5\n+x\n 6\n 7\n 8\n",
+		},
+		"hunks": {
+			a:        "a 1 2 3 4 5 6 7 b",
+			b:        "x 1 2 3 4 5 6 7 y",
+			expected: "@@ -1,4 +1,4 @@\n-a\n+x\n 1\n 2\n 3\n@@ -6,4 +6,4 @@\n 5\n 6\n 7\n-b\n+y\n",
+		},
+		"merged hunks": {
+			a:        "a 1 2 3 4 5 6 b",
+			b:        "x 1 2 3 4 5 6 y",
+			expected: "@@ -1,8 +1,8 @@\n-a\n+x\n 1\n 2\n 3\n 4\n 5\n 6\n-b\n+y\n",
+		},
+	}
+
+	for name, tc := range testcases {
+		t.Run(name, func(t *testing.T) {
+			require.Equal(t, tc.expected, unifiedDiff(lines(tc.a), lines(tc.b), 3))
+		})
+	}
+}
+
+func TestPlanDiff(t *testing.T) {
+	m, err := Parse([]byte(testManifest))
+	require.NoError(t, err)
+
+	m.Checks[0].Timeout = 5000
+
+	plan, err := NewPlan(m, testChecks(), testProbes(), true)
+	require.NoError(t, err)
+
+	diff, err := plan.Diff()
+	require.NoError(t, err)
+
+	// Server-managed fields are not shown, and probes are referred to
+	// by name.
+	expected := `--- live: probe "lab"
++++ manifest: probe "lab"
+@@ -1,2 +0,0 @@
+-name: "lab"
+-region: "AMER"
+--- live: check job="homepage" target="https://www.example.org/"
++++ manifest: check job="homepage" target="https://www.example.org/"
+@@ -6,4 +6,4 @@
+ settings.http.ipVersion: "Any"
+ settings.http.method: "GET"
+ target: "https://www.example.org/"
+-timeout: 3000
++timeout: 5000
+--- live: check job="legacy" target="www.example.org"
++++ manifest: check job="legacy" target="www.example.org"
+@@ -1,6 +0,0 @@
+-frequency: 60000
+-job: "legacy"
+-probes[0]: "Atlanta"
+-settings.ping.ipVersion: "Any"
+-target: "www.example.org"
+-timeout: 3000
+--- live: check job="ping" target="www.example.org"
++++ manifest: check job="ping" target="www.example.org"
+@@ -0,0 +1,6 @@
++frequency: 60000
++job: "ping"
++probes[0]: "Atlanta"
++settings.ping.ipVersion: "Any"
++target: "www.example.org"
++timeout: 3000
+`
+
+	require.Equal(t, expected, diff)
+}
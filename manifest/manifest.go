@@ -0,0 +1,378 @@
+// Package manifest implements declarative management of Synthetic
+// Monitoring checks and private probes.
+//
+// Manifests are YAML or JSON documents listing checks and probes:
+//
+//	probes:
+//	  - name: office
+//	    latitude: 51.5
+//	    longitude: -0.12
+//	    region: EMEA
+//	checks:
+//	  - job: homepage
+//	    target: https://www.example.org/
+//	    frequency: 60000
+//	    timeout: 3000
+//	    enabled: true
+//	    probes: [office, Atlanta]
+//	    settings:
+//	      http:
+//	        method: GET
+//
+// Checks use the same representation as the API, except that probes are
+// referred to by name. Checks are identified by their job and target, and
+// probes by their name.
+//
+// A plan is computed by comparing manifests with the checks and probes in
+// the tenant, and then applied using the API client.
+package manifest
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	sm "github.com/grafana/synthetic-monitoring-agent/pkg/pb/synthetic_monitoring"
+	"github.com/grafana/synthetic-monitoring-api-go-client/internal/jsondiff"
+	"github.com/grafana/synthetic-monitoring-api-go-client/model"
+	"go.yaml.in/yaml/v3"
+)
+
+var (
+	ErrDuplicateCheck = errors.New("duplicate check")
+	ErrDuplicateProbe = errors.New("duplicate probe")
+	ErrInvalidCheck   = errors.New("invalid check")
+	ErrInvalidProbe   = errors.New("invalid probe")
+)
+
+// Manifest lists the checks and probes to manage.
+type Manifest struct {
+	Checks []Check `json:"checks"`
+	Probes []Probe `json:"probes"`
+}
+
+// Check is a check in a manifest.
+type Check struct {
+	model.Check
+
+	// ProbeNames lists the names of the probes where the check runs.
+	// In the JSON representation, it replaces the list of probe IDs.
+	ProbeNames []string `json:"probes"`
+}
+
+// Key returns the key identifying c.
+func (c Check) Key() CheckKey {
+	return CheckKey{Job: c.Job, Target: c.Target}
+}
+
+// CheckKey identifies a check.
+type CheckKey struct {
+	Job    string `json:"job"`
+	Target string `json:"target"`
+}
+
+func (k CheckKey) String() string {
+	return fmt.Sprintf("job=%q target=%q", k.Job, k.Target)
+}
+
+// Probe is a private probe in a manifest. Only the fields that can be
+// set by users are included.
+type Probe struct {
+	Name         string                 `json:"name"`
+	Latitude     float32                `json:"latitude"`
+	Longitude    float32                `json:"longitude"`
+	Region       string                 `json:"region"`
+	Labels       []sm.Label             `json:"labels"`
+	Capabilities *sm.Probe_Capabilities `json:"capabilities,omitempty"`
+}
+
+// Parse parses manifests written in YAML or JSON. YAML input can contain
+// multiple documents, which are merged.
+func Parse(data []byte) (*Manifest, error) {
+	var m Manifest
+
+	dec := yaml.NewDecoder(bytes.NewReader(data))
+
+	for {
+		var doc any
+
+		switch err := dec.Decode(&doc); {
+		case errors.Is(err, io.EOF):
+			if err := m.validate(); err != nil {
+				return nil, err
+			}
+
+			return &m, nil
+
+		case err != nil:
+			return nil, fmt.Errorf("parsing manifest: %w", err)
+		}
+
+		if doc == nil {
+			continue
+		}
+
+		// The types in the manifest only have JSON tags, so convert
+		// the document to JSON in order to decode it.
+		buf, err := json.Marshal(doc)
+		if err != nil {
+			return nil, fmt.Errorf("parsing manifest: %w", err)
+		}
+
+		jsonDec := json.NewDecoder(bytes.NewReader(buf))
+		jsonDec.DisallowUnknownFields()
+
+		var part Manifest
+		if err := jsonDec.Decode(&part); err != nil {
+			return nil, fmt.Errorf("parsing manifest: %w", err)
+		}
+
+		m.Checks = append(m.Checks, part.Checks...)
+		m.Probes = append(m.Probes, part.Probes...)
+	}
+}
+
+// Load reads the manifests in the specified files. For directories, all
+// the files with a .yaml, .yml or .json extension are read, recursively.
+func Load(paths ...string) (*Manifest, error) {
+	var (
+		m     Manifest
+		files []string
+	)
+
+	for _, root := range paths {
+		err := filepath.WalkDir(root, func(path string, d fs.DirEntry, err error) error {
+			switch {
+			case err != nil:
+				return err
+
+			case d.IsDir():
+				return nil
+
+			// Files named explicitly are read even if they
+			// don't have the expected extension.
+			case path == root || isManifestFile(path):
+				files = append(files, path)
+			}
+
+			return nil
+		})
+		if err != nil {
+			return nil, fmt.Errorf("reading manifests: %w", err)
+		}
+	}
+
+	for _, file := range files {
+		buf, err := os.ReadFile(file)
+		if err != nil {
+			return nil, fmt.Errorf("reading manifests: %w", err)
+		}
+
+		part, err := Parse(buf)
+		if err != nil {
+			return nil, fmt.Errorf("%s: %w", file, err)
+		}
+
+		m.Checks = append(m.Checks, part.Checks...)
+		m.Probes = append(m.Probes, part.Probes...)
+	}
+
+	if err := m.validate(); err != nil {
+		return nil, err
+	}
+
+	return &m, nil
+}
+
+func isManifestFile(path string) bool {
+	switch strings.ToLower(filepath.Ext(path)) {
+	case ".yaml", ".yml", ".json":
+		return true
+
+	default:
+		return false
+	}
+}
+
+// validate verifies that every check and probe has a unique key.
+func (m *Manifest) validate() error {
+	checks := make(map[CheckKey]struct{}, len(m.Checks))
+
+	for _, c := range m.Checks {
+		key := c.Key()
+
+		if key.Job == "" || key.Target == "" {
+			return fmt.Errorf("%s: job and target are required: %w", key, ErrInvalidCheck)
+		}
+
+		if _, found := checks[key]; found {
+			return fmt.Errorf("%s: %w", key, ErrDuplicateCheck)
+		}
+
+		checks[key] = struct{}{}
+	}
+
+	probes := make(map[string]struct{}, len(m.Probes))
+
+	for _, p := range m.Probes {
+		if p.Name == "" {
+			return fmt.Errorf("name is required: %w", ErrInvalidProbe)
+		}
+
+		if _, found := probes[p.Name]; found {
+			return fmt.Errorf("%q: %w", p.Name, ErrDuplicateProbe)
+		}
+
+		probes[p.Name] = struct{}{}
+	}
+
+	return nil
+}
+
+// checkFromAPI returns the manifest representation of c, using names to
+// map the probe IDs to names. Probes that are not found are referred to
+// by ID.
+func checkFromAPI(c model.Check, names map[int64]string) Check {
+	out := Check{Check: c}
+
+	for _, id := range c.Probes {
+		name, found := names[id]
+		if !found {
+			name = fmt.Sprint(id)
+		}
+
+		out.ProbeNames = append(out.ProbeNames, name)
+	}
+
+	return out
+}
+
+func probeFromAPI(p sm.Probe) Probe {
+	return Probe{
+		Name:         p.Name,
+		Latitude:     p.Latitude,
+		Longitude:    p.Longitude,
+		Region:       p.Region,
+		Labels:       p.Labels,
+		Capabilities: p.Capabilities,
+	}
+}
+
+// toAPI returns the API representation of p.
+func (p Probe) toAPI() sm.Probe {
+	return sm.Probe{
+		Name:         p.Name,
+		Latitude:     p.Latitude,
+		Longitude:    p.Longitude,
+		Region:       p.Region,
+		Labels:       p.Labels,
+		Capabilities: p.Capabilities,
+	}
+}
+
+// canonical returns a representation of v suitable for comparisons. Fields
+// managed by the server are removed, as well as fields with zero values,
+// so that a field missing in a manifest is the same as one with its
+// default value. Probes and labels are sorted, as their order doesn't
+// matter.
+func canonical(v any) (any, error) {
+	switch v := v.(type) {
+	case Check:
+		v.Id = 0
+		v.TenantId = 0
+		v.Created = 0
+		v.Modified = 0
+		// The offset is computed by the API server.
+		v.Offset = 0
+		v.Check.Check.Probes = nil
+		v.ProbeNames = sortedCopy(v.ProbeNames)
+		v.Labels = sortedLabels(v.Labels)
+
+		return canonicalJSON(v)
+
+	case Probe:
+		v.Labels = sortedLabels(v.Labels)
+
+		return canonicalJSON(v)
+
+	default:
+		return canonicalJSON(v)
+	}
+}
+
+func canonicalJSON(v any) (any, error) {
+	tree, err := jsondiff.Tree(v)
+	if err != nil {
+		return nil, err
+	}
+
+	return prune(tree), nil
+}
+
+// prune removes zero values from v. It returns nil if v itself is a zero
+// value.
+func prune(v any) any {
+	switch v := v.(type) {
+	case map[string]any:
+		for key, value := range v {
+			if value = prune(value); value == nil {
+				delete(v, key)
+			} else {
+				v[key] = value
+			}
+		}
+
+		if len(v) == 0 {
+			return nil
+		}
+
+	case []any:
+		if len(v) == 0 {
+			return nil
+		}
+
+		for i := range v {
+			v[i] = prune(v[i])
+		}
+
+	case bool:
+		if !v {
+			return nil
+		}
+
+	case float64:
+		if v == 0 {
+			return nil
+		}
+
+	case string:
+		if v == "" {
+			return nil
+		}
+	}
+
+	return v
+}
+
+func sortedCopy(s []string) []string {
+	out := append([]string(nil), s...)
+	sort.Strings(out)
+
+	return out
+}
+
+func sortedLabels(labels []sm.Label) []sm.Label {
+	out := append([]sm.Label(nil), labels...)
+	sort.Slice(out, func(i, j int) bool {
+		return out[i].Name < out[j].Name
+	})
+
+	return out
+}
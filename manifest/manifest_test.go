@@ -0,0 +1,113 @@
+package manifest
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+const testManifest = `
+probes:
+  - name: office
+    latitude: 51.5
+    longitude: -0.12
+    region: EMEA
+checks:
+  - job: homepage
+    target: https://www.example.org/
+    frequency: 60000
+    timeout: 3000
+    enabled: true
+    probes: [office, Atlanta]
+    settings:
+      http:
+        method: GET
+---
+checks:
+  - job: ping
+    target: www.example.org
+    frequency: 60000
+    timeout: 3000
+    probes: [Atlanta]
+    settings:
+      ping: {}
+`
+
+func TestParse(t *testing.T) {
+	m, err := Parse([]byte(testManifest))
+	require.NoError(t, err)
+
+	require.Len(t, m.Probes, 1)
+	require.Equal(t, "office", m.Probes[0].Name)
+	require.Equal(t, float32(51.5), m.Probes[0].Latitude)
+
+	require.Len(t, m.Checks, 2)
+	require.Equal(t, CheckKey{Job: "homepage", Target: "https://www.example.org/"}, m.Checks[0].Key())
+	require.Equal(t, []string{"office", "Atlanta"}, m.Checks[0].ProbeNames)
+	require.Empty(t, m.Checks[0].Check.Check.Probes)
+	require.NotNil(t, m.Checks[0].Settings.Http)
+	require.NotNil(t, m.Checks[1].Settings.Ping)
+}
+
+func TestParseJSON(t *testing.T) {
+	m, err := Parse([]byte(`{"checks": [{"job": "j", "target": "t", "probes": ["p"]}]}`))
+	require.NoError(t, err)
+	require.Len(t, m.Checks, 1)
+	require.Equal(t, []string{"p"}, m.Checks[0].ProbeNames)
+}
+
+func TestParseErrors(t *testing.T) {
+	testcases := map[string]struct {
+		input    string
+		expected error
+	}{
+		"duplicate check": {
+			input:    "checks: [{job: j, target: t}]\n---\nchecks: [{job: j, target: t}]\n",
+			expected: ErrDuplicateCheck,
+		},
+		"duplicate probe": {
+			input:    "probes: [{name: p}, {name: p}]\n",
+			expected: ErrDuplicateProbe,
+		},
+		"missing target": {
+			input:    "checks: [{job: j}]\n",
+			expected: ErrInvalidCheck,
+		},
+		"missing probe name": {
+			input:    "probes: [{region: EMEA}]\n",
+			expected: ErrInvalidProbe,
+		},
+	}
+
+	for name, tc := range testcases {
+		t.Run(name, func(t *testing.T) {
+			_, err := Parse([]byte(tc.input))
+			require.ErrorIs(t, err, tc.expected)
+		})
+	}
+
+	_, err := Parse([]byte("checks: [{job: j, target: t, unknown: 1}]\n"))
+	require.Error(t, err)
+}
+
+func TestLoad(t *testing.T) {
+	dir := t.TempDir()
+
+	require.NoError(t, os.MkdirAll(filepath.Join(dir, "sub"), 0o755))
+	require.NoError(t, os.WriteFile(filepath.Join(dir, "a.yaml"), []byte("checks: [{job: a, target: t}]\n"), 0o600))
+	require.NoError(t, os.WriteFile(filepath.Join(dir, "sub", "b.json"), []byte(`{"checks": [{"job": "b", "target": "t"}]}`), 0o600))
+	require.NoError(t, os.WriteFile(filepath.Join(dir, "README.md"), []byte("not a manifest"), 0o600))
+
+	m, err := Load(dir)
+	require.NoError(t, err)
+	require.Len(t, m.Checks, 2)
+
+	// Files named explicitly are read regardless of their extension.
+	other := filepath.Join(t.TempDir(), "checks.txt")
+	require.NoError(t, os.WriteFile(other, []byte("checks: [{job: a, target: t}]\n"), 0o600))
+
+	_, err = Load(dir, other)
+	require.ErrorIs(t, err, ErrDuplicateCheck)
+}
@@ -0,0 +1,333 @@
+package manifest
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sort"
+
+	sm "github.com/grafana/synthetic-monitoring-agent/pkg/pb/synthetic_monitoring"
+	smapi "github.com/grafana/synthetic-monitoring-api-go-client"
+	"github.com/grafana/synthetic-monitoring-api-go-client/internal/jsondiff"
+	"github.com/grafana/synthetic-monitoring-api-go-client/model"
+)
+
+var (
+	ErrPublicProbe  = errors.New("public probes cannot be managed")
+	ErrUnknownProbe = errors.New("unknown probe")
+)
+
+// Action is the kind of change made to a check or probe.
+type Action string
+
+const (
+	ActionCreate Action = "create"
+	ActionUpdate Action = "update"
+	ActionDelete Action = "delete"
+)
+
+// CheckChange describes a change to a check.
+type CheckChange struct {
+	Action Action   `json:"action"`
+	Key    CheckKey `json:"key"`
+	// ID is the ID of the existing check, for updates and deletions.
+	ID int64 `json:"id,omitempty"`
+	// Current is the existing check, nil for creations.
+	Current *Check `json:"current,omitempty"`
+	// Desired is the check in the manifest, nil for deletions.
+	Desired *Check `json:"desired,omitempty"`
+}
+
+// ProbeChange describes a change to a private probe.
+type ProbeChange struct {
+	Action Action `json:"action"`
+	Name   string `json:"name"`
+	// ID is the ID of the existing probe, for updates and deletions.
+	ID int64 `json:"id,omitempty"`
+	// Current is the existing probe, nil for creations.
+	Current *Probe `json:"current,omitempty"`
+	// Desired is the probe in the manifest, nil for deletions.
+	Desired *Probe `json:"desired,omitempty"`
+}
+
+// Plan lists the changes needed to make a tenant match a manifest.
+type Plan struct {
+	Probes []ProbeChange `json:"probes"`
+	Checks []CheckChange `json:"checks"`
+}
+
+// Empty reports whether the plan has no changes.
+func (p *Plan) Empty() bool {
+	return len(p.Probes) == 0 && len(p.Checks) == 0
+}
+
+// Counts returns the number of creations, updates and deletions.
+func (p *Plan) Counts() (create, update, del int) {
+	count := func(a Action) {
+		switch a {
+		case ActionCreate:
+			create++
+		case ActionUpdate:
+			update++
+		case ActionDelete:
+			del++
+		}
+	}
+
+	for _, c := range p.Probes {
+		count(c.Action)
+	}
+
+	for _, c := range p.Checks {
+		count(c.Action)
+	}
+
+	return create, update, del
+}
+
+// NewPlan returns the changes needed to make the existing checks and
+// probes match m. Checks and private probes that are not in m are only
+// deleted if prune is true.
+func NewPlan(m *Manifest, checks []model.Check, probes []sm.Probe, prune bool) (*Plan, error) {
+	var plan Plan
+
+	probesByName := make(map[string]sm.Probe, len(probes))
+	probeNames := make(map[int64]string, len(probes))
+
+	for _, p := range probes {
+		probesByName[p.Name] = p
+		probeNames[p.Id] = p.Name
+	}
+
+	wantedProbes := make(map[string]struct{}, len(m.Probes))
+
+	for i := range m.Probes {
+		desired := &m.Probes[i]
+		wantedProbes[desired.Name] = struct{}{}
+
+		existing, found := probesByName[desired.Name]
+
+		switch {
+		case !found:
+			plan.Probes = append(plan.Probes, ProbeChange{Action: ActionCreate, Name: desired.Name, Desired: desired})
+
+		case existing.Public:
+			return nil, fmt.Errorf("%q: %w", desired.Name, ErrPublicProbe)
+
+		default:
+			current := probeFromAPI(existing)
+
+			equal, err := equivalent(current, *desired)
+			if err != nil {
+				return nil, err
+			}
+
+			if !equal {
+				plan.Probes = append(plan.Probes, ProbeChange{
+					Action:  ActionUpdate,
+					Name:    desired.Name,
+					ID:      existing.Id,
+					Current: &current,
+					Desired: desired,
+				})
+			}
+		}
+	}
+
+	wantedChecks := make(map[CheckKey]struct{}, len(m.Checks))
+	checksByKey := make(map[CheckKey]model.Check, len(checks))
+
+	for _, c := range checks {
+		checksByKey[CheckKey{Job: c.Job, Target: c.Target}] = c
+	}
+
+	for i := range m.Checks {
+		desired := &m.Checks[i]
+		key := desired.Key()
+		wantedChecks[key] = struct{}{}
+
+		for _, name := range desired.ProbeNames {
+			_, existing := probesByName[name]
+			_, wanted := wantedProbes[name]
+
+			if !existing && !wanted {
+				return nil, fmt.Errorf("%s: %q: %w", key, name, ErrUnknownProbe)
+			}
+		}
+
+		existing, found := checksByKey[key]
+		if !found {
+			plan.Checks = append(plan.Checks, CheckChange{Action: ActionCreate, Key: key, Desired: desired})
+			continue
+		}
+
+		current := checkFromAPI(existing, probeNames)
+
+		equal, err := equivalent(current, *desired)
+		if err != nil {
+			return nil, err
+		}
+
+		if !equal {
+			plan.Checks = append(plan.Checks, CheckChange{
+				Action:  ActionUpdate,
+				Key:     key,
+				ID:      existing.Id,
+				Current: &current,
+				Desired: desired,
+			})
+		}
+	}
+
+	if prune {
+		for _, c := range checks {
+			key := CheckKey{Job: c.Job, Target: c.Target}
+			if _, found := wantedChecks[key]; found {
+				continue
+			}
+
+			current := checkFromAPI(c, probeNames)
+			plan.Checks = append(plan.Checks, CheckChange{Action: ActionDelete, Key: key, ID: c.Id, Current: &current})
+		}
+
+		for _, p := range probes {
+			if _, found := wantedProbes[p.Name]; found || p.Public {
+				continue
+			}
+
+			current := probeFromAPI(p)
+			plan.Probes = append(plan.Probes, ProbeChange{Action: ActionDelete, Name: p.Name, ID: p.Id, Current: &current})
+		}
+	}
+
+	sort.SliceStable(plan.Probes, func(i, j int) bool { return plan.Probes[i].Name < plan.Probes[j].Name })
+	sort.SliceStable(plan.Checks, func(i, j int) bool {
+		a, b := plan.Checks[i].Key, plan.Checks[j].Key
+		if a.Job != b.Job {
+			return a.Job < b.Job
+		}
+
+		return a.Target < b.Target
+	})
+
+	return &plan, nil
+}
+
+// equivalent reports whether a and b are the same once server-managed
+// fields and default values are ignored.
+func equivalent(a, b any) (bool, error) {
+	ca, err := canonical(a)
+	if err != nil {
+		return false, err
+	}
+
+	cb, err := canonical(b)
+	if err != nil {
+		return false, err
+	}
+
+	changes, err := jsondiff.Changes(ca, cb)
+	if err != nil {
+		return false, err
+	}
+
+	return len(changes) == 0, nil
+}
+
+// Apply makes the changes in the plan using c. Probes are created and
+// updated first, so that checks can refer to them, and deleted last.
+//
+// The tokens of the created probes are returned, keyed by probe name, as
+// they are needed to run the probes and cannot be retrieved later. In
+// case of error, the tokens of the probes created until then are
+// returned.
+func (p *Plan) Apply(ctx context.Context, c *smapi.Client) (map[string][]byte, error) {
+	tokens := make(map[string][]byte)
+
+	for _, change := range p.Probes {
+		switch change.Action {
+		case ActionCreate:
+			_, token, err := c.AddProbe(ctx, change.Desired.toAPI())
+			if err != nil {
+				return tokens, fmt.Errorf("creating probe %q: %w", change.Name, err)
+			}
+
+			tokens[change.Name] = token
+
+		case ActionUpdate:
+			probe := change.Desired.toAPI()
+			probe.Id = change.ID
+
+			if _, err := c.UpdateProbe(ctx, probe); err != nil {
+				return tokens, fmt.Errorf("updating probe %q: %w", change.Name, err)
+			}
+		}
+	}
+
+	probes, err := c.ListProbes(ctx)
+	if err != nil {
+		return tokens, fmt.Errorf("listing probes: %w", err)
+	}
+
+	probeIDs := make(map[string]int64, len(probes))
+	for _, probe := range probes {
+		probeIDs[probe.Name] = probe.Id
+	}
+
+	for _, change := range p.Checks {
+		if err := applyCheckChange(ctx, c, change, probeIDs); err != nil {
+			return tokens, err
+		}
+	}
+
+	for _, change := range p.Probes {
+		if change.Action != ActionDelete {
+			continue
+		}
+
+		if err := c.DeleteProbe(ctx, change.ID); err != nil {
+			return tokens, fmt.Errorf("deleting probe %q: %w", change.Name, err)
+		}
+	}
+
+	return tokens, nil
+}
+
+func applyCheckChange(ctx context.Context, c *smapi.Client, change CheckChange, probeIDs map[string]int64) error {
+	if change.Action == ActionDelete {
+		if err := c.DeleteCheck(ctx, change.ID); err != nil {
+			return fmt.Errorf("deleting check %s: %w", change.Key, err)
+		}
+
+		return nil
+	}
+
+	check := change.Desired.Check
+	check.Id = change.ID
+	check.Probes = make([]int64, 0, len(change.Desired.ProbeNames))
+
+	for _, name := range change.Desired.ProbeNames {
+		id, found := probeIDs[name]
+		if !found {
+			return fmt.Errorf("check %s: %q: %w", change.Key, name, ErrUnknownProbe)
+		}
+
+		check.Probes = append(check.Probes, id)
+	}
+
+	if change.Action == ActionCreate {
+		if _, err := c.AddCheck(ctx, check); err != nil {
+			return fmt.Errorf("creating check %s: %w", change.Key, err)
+		}
+
+		return nil
+	}
+
+	check.TenantId = change.Current.TenantId
+
+	if _, err := c.UpdateCheck(ctx, check); err != nil {
+		return fmt.Errorf("updating check %s: %w", change.Key, err)
+	}
+
+	return nil
+}
@@ -0,0 +1,238 @@
+package manifest
+
+import (
+	"context"
+	"encoding/json"
+	"maps"
+	"net/http"
+	"net/http/httptest"
+	"slices"
+	"strings"
+	"sync"
+	"testing"
+
+	sm "github.com/grafana/synthetic-monitoring-agent/pkg/pb/synthetic_monitoring"
+	smapi "github.com/grafana/synthetic-monitoring-api-go-client"
+	"github.com/grafana/synthetic-monitoring-api-go-client/model"
+	"github.com/stretchr/testify/require"
+)
+
+func testProbes() []sm.Probe {
+	return []sm.Probe{
+		{Id: 1, Name: "Atlanta", Public: true},
+		{Id: 2, Name: "office", Region: "EMEA", Latitude: 51.5, Longitude: -0.12},
+		{Id: 3, Name: "lab", Region: "AMER"},
+	}
+}
+
+func testChecks() []model.Check {
+	return []model.Check{
+		{Check: sm.Check{
+			Id:        10,
+			TenantId:  100,
+			Job:       "homepage",
+			Target:    "https://www.example.org/",
+			Frequency: 60000,
+			Offset:    1234,
+			Timeout:   3000,
+			Enabled:   true,
+			Probes:    []int64{2, 1},
+			Created:   1,
+			Modified:  2,
+			Settings:  sm.CheckSettings{Http: &sm.HttpSettings{Method: sm.HttpMethod_GET}},
+		}},
+		{Check: sm.Check{
+			Id:        11,
+			TenantId:  100,
+			Job:       "legacy",
+			Target:    "www.example.org",
+			Frequency: 60000,
+			Timeout:   3000,
+			Probes:    []int64{1},
+			Settings:  sm.CheckSettings{Ping: &sm.PingSettings{}},
+		}},
+	}
+}
+
+func TestNewPlan(t *testing.T) {
+	m, err := Parse([]byte(testManifest))
+	require.NoError(t, err)
+
+	plan, err := NewPlan(m, testChecks(), testProbes(), false)
+	require.NoError(t, err)
+
+	// The homepage check and the office probe are unchanged, and the
+	// legacy check and lab probe are kept as prune is false.
+	require.Empty(t, plan.Probes)
+	require.Len(t, plan.Checks, 1)
+	require.Equal(t, ActionCreate, plan.Checks[0].Action)
+	require.Equal(t, CheckKey{Job: "ping", Target: "www.example.org"}, plan.Checks[0].Key)
+
+	create, update, del := plan.Counts()
+	require.Equal(t, [3]int{1, 0, 0}, [3]int{create, update, del})
+}
+
+func TestNewPlanUpdate(t *testing.T) {
+	m, err := Parse([]byte(testManifest))
+	require.NoError(t, err)
+
+	m.Probes[0].Region = "APAC"
+	m.Checks[0].Timeout = 5000
+
+	plan, err := NewPlan(m, testChecks(), testProbes(), false)
+	require.NoError(t, err)
+
+	require.Len(t, plan.Probes, 1)
+	require.Equal(t, ProbeChange{
+		Action:  ActionUpdate,
+		Name:    "office",
+		ID:      2,
+		Current: &Probe{Name: "office", Region: "EMEA", Latitude: 51.5, Longitude: -0.12},
+		Desired: &m.Probes[0],
+	}, plan.Probes[0])
+
+	require.Len(t, plan.Checks, 2)
+	require.Equal(t, ActionUpdate, plan.Checks[0].Action)
+	require.Equal(t, int64(10), plan.Checks[0].ID)
+	require.Equal(t, ActionCreate, plan.Checks[1].Action)
+}
+
+func TestNewPlanPrune(t *testing.T) {
+	m, err := Parse([]byte(testManifest))
+	require.NoError(t, err)
+
+	plan, err := NewPlan(m, testChecks(), testProbes(), true)
+	require.NoError(t, err)
+
+	// Public probes are never deleted.
+	require.Len(t, plan.Probes, 1)
+	require.Equal(t, ActionDelete, plan.Probes[0].Action)
+	require.Equal(t, "lab", plan.Probes[0].Name)
+	require.Equal(t, int64(3), plan.Probes[0].ID)
+
+	require.Len(t, plan.Checks, 2)
+	require.Equal(t, ActionDelete, plan.Checks[0].Action)
+	require.Equal(t, int64(11), plan.Checks[0].ID)
+	require.Equal(t, ActionCreate, plan.Checks[1].Action)
+
+	create, update, del := plan.Counts()
+	require.Equal(t, [3]int{1, 0, 2}, [3]int{create, update, del})
+}
+
+func TestPlanJSON(t *testing.T) {
+	m, err := Parse([]byte(testManifest))
+	require.NoError(t, err)
+
+	plan, err := NewPlan(m, testChecks(), testProbes(), true)
+	require.NoError(t, err)
+
+	buf, err := json.Marshal(plan)
+	require.NoError(t, err)
+
+	var out struct {
+		Probes []map[string]json.RawMessage `json:"probes"`
+		Checks []map[string]json.RawMessage `json:"checks"`
+	}
+	require.NoError(t, json.Unmarshal(buf, &out))
+
+	// Field names are in camelCase, as in the checks and probes, and
+	// missing values are omitted.
+	require.Len(t, out.Probes, 1)
+	require.Equal(t, []string{"action", "current", "id", "name"}, sortedKeys(out.Probes[0]))
+	require.JSONEq(t, `"delete"`, string(out.Probes[0]["action"]))
+
+	require.Len(t, out.Checks, 2)
+	require.Equal(t, []string{"action", "current", "id", "key"}, sortedKeys(out.Checks[0]))
+	require.JSONEq(t, `{"job":"legacy","target":"www.example.org"}`, string(out.Checks[0]["key"]))
+	require.Equal(t, []string{"action", "desired", "key"}, sortedKeys(out.Checks[1]))
+}
+
+func sortedKeys(m map[string]json.RawMessage) []string {
+	return slices.Sorted(maps.Keys(m))
+}
+
+func TestNewPlanErrors(t *testing.T) {
+	m, err := Parse([]byte("probes: [{name: Atlanta}]\n"))
+	require.NoError(t, err)
+
+	_, err = NewPlan(m, nil, testProbes(), false)
+	require.ErrorIs(t, err, ErrPublicProbe)
+
+	m, err = Parse([]byte("checks: [{job: j, target: t, probes: [nowhere]}]\n"))
+	require.NoError(t, err)
+
+	_, err = NewPlan(m, nil, testProbes(), false)
+	require.ErrorIs(t, err, ErrUnknownProbe)
+}
+
+func TestPlanApply(t *testing.T) {
+	var (
+		mu       sync.Mutex
+		requests []string
+		probes   = testProbes()
+	)
+
+	mux := http.NewServeMux()
+	mux.HandleFunc("/api/v1/", func(w http.ResponseWriter, r *http.Request) {
+		mu.Lock()
+		defer mu.Unlock()
+
+		path := strings.TrimPrefix(r.URL.Path, "/api/v1")
+		requests = append(requests, r.Method+" "+path)
+
+		var resp any
+
+		switch path {
+		case "/probe/add":
+			var probe sm.Probe
+			require.NoError(t, json.NewDecoder(r.Body).Decode(&probe))
+			probe.Id = 4
+			probes = append(probes, probe)
+			resp = model.ProbeAddResponse{Probe: probe, Token: []byte("secret")}
+
+		case "/probe/list":
+			resp = probes
+
+		case "/check/add", "/check/update":
+			var check model.Check
+			require.NoError(t, json.NewDecoder(r.Body).Decode(&check))
+
+			if check.Job == "ping" {
+				require.Equal(t, []int64{1, 4}, check.Probes)
+			}
+
+			resp = check
+
+		default:
+			resp = struct{}{}
+		}
+
+		w.Header().Set("Content-Type", "application/json")
+		require.NoError(t, json.NewEncoder(w).Encode(resp))
+	})
+
+	server := httptest.NewServer(mux)
+	defer server.Close()
+
+	client, err := smapi.New(server.URL, smapi.WithAccessToken("token"))
+	require.NoError(t, err)
+
+	m, err := Parse([]byte(testManifest + "---\nprobes: [{name: new}]\n"))
+	require.NoError(t, err)
+	m.Checks[1].ProbeNames = []string{"Atlanta", "new"}
+
+	plan, err := NewPlan(m, testChecks(), testProbes(), true)
+	require.NoError(t, err)
+
+	tokens, err := plan.Apply(context.Background(), client)
+	require.NoError(t, err)
+	require.Equal(t, map[string][]byte{"new": []byte("secret")}, tokens)
+
+	require.Equal(t, []string{
+		"POST /probe/add",
+		"GET /probe/list",
+		"DELETE /check/delete/11",
+		"POST /check/add",
+		"DELETE /probe/delete/3",
+	}, requests)
+}
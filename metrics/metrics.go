@@ -0,0 +1,134 @@
+// Package metrics provides Prometheus metrics for the Synthetic Monitoring
+// API client.
+//
+// The metrics are fed by an interceptor and exposed by a collector that
+// can be registered with any prometheus.Registerer:
+//
+//	collector := metrics.NewCollector()
+//	prometheus.MustRegister(collector)
+//
+//	client, err := smapi.New(url,
+//		smapi.WithAccessToken(token),
+//		smapi.WithInterceptors(collector.Interceptor()),
+//	)
+package metrics
+
+import (
+	"context"
+	"strconv"
+	"time"
+
+	smapi "github.com/grafana/synthetic-monitoring-api-go-client"
+	"github.com/prometheus/client_golang/prometheus"
+)
+
+const namespace = "sm_client"
+
+// Labels used by the metrics.
+const (
+	// OperationLabel is the name of the operation, e.g. "check add
+	// request". These are the same names reported in
+	// smapi.HTTPError.
+	OperationLabel = "operation"
+
+	// StatusLabel is the status code of the response, "error" if no
+	// response was received, or "none" if the operation was completed
+	// without contacting the API server.
+	StatusLabel = "status"
+)
+
+// Collector collects metrics about the operations performed by clients
+// using its interceptor. It implements prometheus.Collector.
+type Collector struct {
+	duration *prometheus.HistogramVec
+	requests *prometheus.CounterVec
+	retries  *prometheus.CounterVec
+	inFlight *prometheus.GaugeVec
+}
+
+var _ prometheus.Collector = (*Collector)(nil)
+
+// NewCollector returns a new Collector.
+func NewCollector() *Collector {
+	return &Collector{
+		duration: prometheus.NewHistogramVec(prometheus.HistogramOpts{
+			Namespace: namespace,
+			Name:      "request_duration_seconds",
+			Help:      "Duration of Synthetic Monitoring API operations, including retries.",
+			Buckets:   prometheus.DefBuckets,
+		}, []string{OperationLabel, StatusLabel}),
+
+		requests: prometheus.NewCounterVec(prometheus.CounterOpts{
+			Namespace: namespace,
+			Name:      "requests_total",
+			Help:      "Total number of Synthetic Monitoring API operations.",
+		}, []string{OperationLabel, StatusLabel}),
+
+		retries: prometheus.NewCounterVec(prometheus.CounterOpts{
+			Namespace: namespace,
+			Name:      "request_retries_total",
+			Help:      "Total number of times requests to the Synthetic Monitoring API were retried.",
+		}, []string{OperationLabel}),
+
+		inFlight: prometheus.NewGaugeVec(prometheus.GaugeOpts{
+			Namespace: namespace,
+			Name:      "requests_in_flight",
+			Help:      "Number of Synthetic Monitoring API operations in progress.",
+		}, []string{OperationLabel}),
+	}
+}
+
+// Describe implements prometheus.Collector.
+func (c *Collector) Describe(ch chan<- *prometheus.Desc) {
+	c.duration.Describe(ch)
+	c.requests.Describe(ch)
+	c.retries.Describe(ch)
+	c.inFlight.Describe(ch)
+}
+
+// Collect implements prometheus.Collector.
+func (c *Collector) Collect(ch chan<- prometheus.Metric) {
+	c.duration.Collect(ch)
+	c.requests.Collect(ch)
+	c.retries.Collect(ch)
+	c.inFlight.Collect(ch)
+}
+
+// Interceptor returns a smapi.Interceptor that records metrics for every
+// operation performed by the client. The same interceptor can be shared
+// by several clients.
+func (c *Collector) Interceptor() smapi.Interceptor {
+	return func(ctx context.Context, op *smapi.Operation, next smapi.Invoker) error {
+		inFlight := c.inFlight.WithLabelValues(op.Name)
+		inFlight.Inc()
+		defer inFlight.Dec()
+
+		start := time.Now()
+
+		err := next(ctx, op)
+
+		status := statusLabel(op, err)
+
+		c.duration.WithLabelValues(op.Name, status).Observe(time.Since(start).Seconds())
+		c.requests.WithLabelValues(op.Name, status).Inc()
+
+		if op.Attempts > 1 {
+			c.retries.WithLabelValues(op.Name).Add(float64(op.Attempts - 1))
+		}
+
+		return err
+	}
+}
+
+func statusLabel(op *smapi.Operation, err error) string {
+	switch {
+	case op.StatusCode != 0:
+		return strconv.Itoa(op.StatusCode)
+
+	case err != nil:
+		return "error"
+
+	default:
+		return "none"
+	}
+}
@@ -0,0 +1,93 @@
+package metrics
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	sm "github.com/grafana/synthetic-monitoring-agent/pkg/pb/synthetic_monitoring"
+	smapi "github.com/grafana/synthetic-monitoring-api-go-client"
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/testutil"
+	"github.com/stretchr/testify/require"
+)
+
+func TestCollector(t *testing.T) {
+	var requests int
+
+	mux := http.NewServeMux()
+
+	mux.HandleFunc("/api/v1/probe/list", func(w http.ResponseWriter, r *http.Request) {
+		requests++
+		if requests == 1 {
+			w.WriteHeader(http.StatusServiceUnavailable)
+			return
+		}
+
+		w.Header().Set("Content-Type", "application/json")
+		_ = json.NewEncoder(w).Encode([]sm.Probe{})
+	})
+
+	mux.HandleFunc("/api/v1/probe/", func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		w.WriteHeader(http.StatusNotFound)
+		_, _ = w.Write([]byte(`{"msg":"probe not found"}`))
+	})
+
+	srv := httptest.NewServer(mux)
+	defer srv.Close()
+
+	collector := NewCollector()
+
+	registry := prometheus.NewPedanticRegistry()
+	require.NoError(t, registry.Register(collector))
+
+	c, err := smapi.New(srv.URL,
+		smapi.WithAccessToken("token"),
+		smapi.WithInterceptors(collector.Interceptor()),
+		smapi.WithRetryPolicy(smapi.RetryPolicy{
+			MaxAttempts: 3,
+			MinBackoff:  time.Millisecond,
+			MaxBackoff:  time.Millisecond,
+		}),
+	)
+	require.NoError(t, err)
+
+	_, err = c.ListProbes(context.Background())
+	require.NoError(t, err)
+
+	_, err = c.GetProbe(context.Background(), 1)
+	require.ErrorIs(t, err, smapi.ErrNotFound)
+
+	expected := `
+# HELP sm_client_request_retries_total Total number of times requests to the Synthetic Monitoring API were retried.
+# TYPE sm_client_request_retries_total counter
+sm_client_request_retries_total{operation="probe list request"} 1
+# HELP sm_client_requests_in_flight Number of Synthetic Monitoring API operations in progress.
+# TYPE sm_client_requests_in_flight gauge
+sm_client_requests_in_flight{operation="probe get request"} 0
+sm_client_requests_in_flight{operation="probe list request"} 0
+# HELP sm_client_requests_total Total number of Synthetic Monitoring API operations.
+# TYPE sm_client_requests_total counter
+sm_client_requests_total{operation="probe get request",status="404"} 1
+sm_client_requests_total{operation="probe list request",status="200"} 1
+`
+
+	require.NoError(t, testutil.GatherAndCompare(registry, strings.NewReader(expected),
+		"sm_client_request_retries_total",
+		"sm_client_requests_in_flight",
+		"sm_client_requests_total",
+	))
+
+	require.Equal(t, 2, testutil.CollectAndCount(collector, "sm_client_request_duration_seconds"))
+}
+
+func TestStatusLabel(t *testing.T) {
+	require.Equal(t, "201", statusLabel(&smapi.Operation{StatusCode: http.StatusCreated}, nil))
+	require.Equal(t, "error", statusLabel(&smapi.Operation{}, context.Canceled))
+	require.Equal(t, "none", statusLabel(&smapi.Operation{}, nil))
+}
@@ -0,0 +1,108 @@
+package smapi
+
+import (
+	"context"
+	"errors"
+	"fmt"
+
+	"github.com/grafana/synthetic-monitoring-api-go-client/model"
+
+	"github.com/grafana/synthetic-monitoring-agent/pkg/pb/synthetic_monitoring"
+)
+
+// ErrUnknownProbe is returned by Migrate when the probe map refers to a
+// probe that doesn't exist in the destination tenant.
+var ErrUnknownProbe = errors.New("unknown probe")
+
+// MigrateOptions modifies how Migrate maps probes.
+type MigrateOptions struct {
+	// ProbeMap maps the names of probes in the source tenant to the
+	// names of probes in the destination tenant. It's used for probes
+	// that don't exist in the destination tenant, or to override the
+	// automatic mapping.
+	ProbeMap map[string]string
+}
+
+// Migrate copies the checks of the tenant accessed using src, along with
+// their alerts, to the tenant accessed using dst, e.g. in order to move
+// checks to a stack in a different region.
+//
+// Probes are mapped using opts.ProbeMap, and otherwise to the probe with
+// the same name in the destination tenant. If several probes have the
+// same name, the one in the same region is used. Probes are not created.
+//
+// As with ImportBundle, checks that already exist in the destination
+// tenant or whose probes cannot be mapped are recorded in the returned
+// report, which also lists the IDs of the new checks.
+func Migrate(ctx context.Context, src, dst *Client, opts MigrateOptions) (*model.MigrationReport, error) {
+	bundle, err := src.ExportBundle(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("reading source tenant: %w", err)
+	}
+
+	tenant, err := dst.GetTenant(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("reading destination tenant: %w", err)
+	}
+
+	dstProbes, err := dst.ListProbes(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("reading destination tenant: %w", err)
+	}
+
+	for _, name := range opts.ProbeMap {
+		if findProbe(dstProbes, name, "") == nil {
+			return nil, fmt.Errorf("%q: %w", name, ErrUnknownProbe)
+		}
+	}
+
+	var report model.MigrationReport
+
+	probeIDs := make(map[string]int64, len(bundle.Probes))
+
+	for _, p := range bundle.Probes {
+		mapping := model.ProbeMapping{Source: p.Name, SourceRegion: p.Region}
+
+		name, found := opts.ProbeMap[p.Name]
+		if !found {
+			name = p.Name
+		}
+
+		if probe := findProbe(dstProbes, name, p.Region); probe != nil {
+			mapping.Destination = probe.Name
+			mapping.ID = probe.Id
+			probeIDs[p.Name] = probe.Id
+		}
+
+		report.Probes = append(report.Probes, mapping)
+	}
+
+	report.Checks, err = dst.importChecks(ctx, tenant.Id, bundle.Checks, probeIDs)
+	if err != nil {
+		return &report, fmt.Errorf("creating checks: %w", err)
+	}
+
+	return &report, nil
+}
+
+// findProbe returns the probe with the specified name, preferring the one
+// in region if there are several. It returns nil if there's none.
+func findProbe(probes []synthetic_monitoring.Probe, name, region string) *synthetic_monitoring.Probe {
+	var found *synthetic_monitoring.Probe
+
+	for i := range probes {
+		if probes[i].Name != name {
+			continue
+		}
+
+		if probes[i].Region == region {
+			return &probes[i]
+		}
+
+		if found == nil {
+			found = &probes[i]
+		}
+	}
+
+	return found
+}
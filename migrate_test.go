@@ -0,0 +1,118 @@
+package smapi
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"testing"
+	"time"
+
+	"github.com/grafana/synthetic-monitoring-agent/pkg/pb/synthetic_monitoring"
+	"github.com/grafana/synthetic-monitoring-api-go-client/model"
+	"github.com/stretchr/testify/require"
+)
+
+func TestMigrate(t *testing.T) {
+	srcURL, srcMux, srcCleanup := newTestServer(t)
+	defer srcCleanup()
+
+	srcMux.Handle("/api/v1/tenant", http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		writeResponse(w, http.StatusOK, synthetic_monitoring.Tenant{Id: 1})
+	}))
+
+	srcMux.Handle("/api/v1/probe/list", http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		writeResponse(w, http.StatusOK, []synthetic_monitoring.Probe{
+			{Id: 1, Name: "Atlanta", Region: "AMER", Public: true},
+			{Id: 2, Name: "office", Region: "EMEA"},
+			{Id: 3, Name: "lab", Region: "EMEA"},
+		})
+	}))
+
+	srcMux.Handle("/api/v1/check/list", http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		writeResponse(w, http.StatusOK, []model.CheckWithAlerts{
+			{
+				Check: model.Check{Check: synthetic_monitoring.Check{Id: 10, TenantId: 1, Job: "j1", Target: "t1", Probes: []int64{1, 2, 3}}},
+				Alerts: []model.CheckAlertWithStatus{
+					{CheckAlert: model.CheckAlert{Name: model.AlertProbeFailedExecutionsTooHigh, Threshold: 2}},
+				},
+			},
+			{
+				Check: model.Check{Check: synthetic_monitoring.Check{Id: 11, TenantId: 1, Job: "j2", Target: "t2", Probes: []int64{3}}},
+			},
+		})
+	}))
+
+	dstURL, dstMux, dstCleanup := newTestServer(t)
+	defer dstCleanup()
+
+	var (
+		addedChecks []model.Check
+		alerts      []model.CheckAlert
+	)
+
+	dstMux.Handle("/api/v1/tenant", http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		writeResponse(w, http.StatusOK, synthetic_monitoring.Tenant{Id: 2})
+	}))
+
+	dstMux.Handle("/api/v1/probe/list", http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		writeResponse(w, http.StatusOK, []synthetic_monitoring.Probe{
+			{Id: 100, Name: "Atlanta", Region: "EMEA"},
+			{Id: 101, Name: "Atlanta", Region: "AMER", Public: true},
+			{Id: 102, Name: "office-eu"},
+		})
+	}))
+
+	dstMux.Handle("/api/v1/check/list", http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		writeResponse(w, http.StatusOK, []model.Check{})
+	}))
+
+	dstMux.Handle("/api/v1/check/add", http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var check model.Check
+		require.NoError(t, json.NewDecoder(r.Body).Decode(&check))
+
+		check.Id = int64(200 + len(addedChecks))
+		addedChecks = append(addedChecks, check)
+
+		writeResponse(w, http.StatusOK, check)
+	}))
+
+	dstMux.Handle("/api/v1/check/200/alerts", http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var req struct {
+			Alerts []model.CheckAlert `json:"alerts"`
+		}
+		require.NoError(t, json.NewDecoder(r.Body).Decode(&req))
+
+		alerts = req.Alerts
+
+		writeResponse(w, http.StatusOK, req)
+	}))
+
+	src := NewClient(srcURL, "src-token", http.DefaultClient)
+	dst := NewClient(dstURL, "dst-token", http.DefaultClient)
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	report, err := Migrate(ctx, src, dst, MigrateOptions{ProbeMap: map[string]string{"office": "office-eu"}})
+	require.NoError(t, err)
+
+	require.Equal(t, &model.MigrationReport{
+		Probes: []model.ProbeMapping{
+			{Source: "Atlanta", SourceRegion: "AMER", Destination: "Atlanta", ID: 101},
+			{Source: "lab", SourceRegion: "EMEA"},
+			{Source: "office", SourceRegion: "EMEA", Destination: "office-eu", ID: 102},
+		},
+		Checks: []model.BundleCheckImport{
+			{Job: "j1", Target: "t1", SourceID: 10, ID: 200, UnmappedProbes: []string{"lab"}},
+			{Job: "j2", Target: "t2", SourceID: 11, UnmappedProbes: []string{"lab"}, Error: ErrNoProbes.Error()},
+		},
+	}, report)
+
+	require.Len(t, addedChecks, 1)
+	require.Equal(t, int64(2), addedChecks[0].TenantId)
+	require.Equal(t, []int64{101, 102}, addedChecks[0].Probes)
+	require.Equal(t, []model.CheckAlert{{Name: model.AlertProbeFailedExecutionsTooHigh, Threshold: 2}}, alerts)
+
+	_, err = Migrate(ctx, src, dst, MigrateOptions{ProbeMap: map[string]string{"lab": "nowhere"}})
+	require.ErrorIs(t, err, ErrUnknownProbe)
+}
@@ -0,0 +1,132 @@
+package model
+
+import (
+	"errors"
+	"fmt"
+
+	"github.com/grafana/synthetic-monitoring-agent/pkg/pb/synthetic_monitoring"
+)
+
+// BundleVersion is the version of the bundle format described by Bundle.
+// It's increased whenever a change to the format is not backwards
+// compatible.
+const BundleVersion = 1
+
+var ErrUnsupportedBundleVersion = errors.New("unsupported bundle version")
+
+// Bundle is a portable representation of the configuration of a tenant:
+// its settings, private probes and checks, including their alerts. It's
+// meant to back up a tenant and to copy its configuration to another
+// tenant, possibly in a different region.
+//
+// Probes are identified by name, as their IDs differ between tenants and
+// regions. Probe tokens are not included.
+//
+// The JSON representation of a bundle is stable: fields are only added,
+// and the version is increased for any other change.
+type Bundle struct {
+	// Version is the version of the format, BundleVersion when the
+	// bundle is created.
+	Version int `json:"version"`
+	// Created is the time when the bundle was created, in seconds since
+	// the epoch.
+	Created int64         `json:"created"`
+	Tenant  BundleTenant  `json:"tenant"`
+	Probes  []BundleProbe `json:"probes"`
+	Checks  []BundleCheck `json:"checks"`
+}
+
+// Validate verifies that the version of b is supported.
+func (b Bundle) Validate() error {
+	if b.Version != BundleVersion {
+		return fmt.Errorf("%d: %w", b.Version, ErrUnsupportedBundleVersion)
+	}
+
+	return nil
+}
+
+// BundleTenant holds the settings of a tenant that can be set by users.
+type BundleTenant struct {
+	CostAttributionLabels []string                       `json:"costAttributionLabels,omitempty"`
+	LabelMode             synthetic_monitoring.LabelMode `json:"labelMode"`
+}
+
+// BundleProbe is a probe in a bundle.
+//
+// Public probes are only included in order to describe the probes that
+// checks run on; they are not created when importing a bundle.
+type BundleProbe struct {
+	Name         string                                   `json:"name"`
+	Public       bool                                     `json:"public,omitempty"`
+	Latitude     float32                                  `json:"latitude"`
+	Longitude    float32                                  `json:"longitude"`
+	Region       string                                   `json:"region"`
+	Labels       []synthetic_monitoring.Label             `json:"labels,omitempty"`
+	Capabilities *synthetic_monitoring.Probe_Capabilities `json:"capabilities,omitempty"`
+}
+
+// BundleCheck is a check in a bundle, along with its alerts.
+type BundleCheck struct {
+	Check
+
+	// ProbeNames lists the names of the probes the check runs on. In the
+	// JSON representation, it replaces the list of probe IDs.
+	ProbeNames []string     `json:"probes"`
+	Alerts     []CheckAlert `json:"alerts,omitempty"`
+}
+
+// BundleImportReport describes the result of importing a bundle.
+type BundleImportReport struct {
+	// TenantUpdated is true if the settings of the tenant were changed.
+	TenantUpdated bool                `json:"tenantUpdated"`
+	Probes        []BundleProbeImport `json:"probes"`
+	Checks        []BundleCheckImport `json:"checks"`
+}
+
+// BundleProbeImport describes the result of importing a private probe.
+type BundleProbeImport struct {
+	Name string `json:"name"`
+	// ID is the ID of the probe in the destination tenant.
+	ID int64 `json:"id"`
+	// Created is false if a probe with the same name already existed.
+	Created bool `json:"created"`
+	// Token is the access token of the probe, if it was created.
+	Token []byte `json:"token,omitempty"`
+}
+
+// BundleCheckImport describes the result of importing a check.
+type BundleCheckImport struct {
+	Job    string `json:"job"`
+	Target string `json:"target"`
+	// SourceID is the ID of the check in the bundle.
+	SourceID int64 `json:"sourceId"`
+	// ID is the ID of the check in the destination tenant, or 0 if the
+	// check was not imported.
+	ID int64 `json:"id"`
+	// UnmappedProbes lists the probes of the check that were not found
+	// in the destination tenant. If ID is not 0, the check was created
+	// without them.
+	UnmappedProbes []string `json:"unmappedProbes,omitempty"`
+	// Error explains why the check or its alerts were not imported.
+	Error string `json:"error,omitempty"`
+}
+
+// MigrationReport describes the result of migrating checks from one
+// tenant to another.
+type MigrationReport struct {
+	Probes []ProbeMapping      `json:"probes"`
+	Checks []BundleCheckImport `json:"checks"`
+}
+
+// ProbeMapping describes how a probe in the source tenant of a migration
+// was mapped to a probe in the destination tenant.
+type ProbeMapping struct {
+	Source       string `json:"source"`
+	SourceRegion string `json:"sourceRegion,omitempty"`
+	// Destination is the name of the probe in the destination tenant, or
+	// empty if the probe could not be mapped.
+	Destination string `json:"destination,omitempty"`
+	// ID is the ID of the probe in the destination tenant, or 0 if the
+	// probe could not be mapped.
+	ID int64 `json:"id,omitempty"`
+}
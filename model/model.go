@@ -2,7 +2,9 @@ package model
 
 import (
 	"encoding/json"
+	"errors"
 	"fmt"
+	"slices"
 
 	"github.com/grafana/synthetic-monitoring-agent/pkg/pb/synthetic_monitoring"
 )
@@ -12,6 +14,39 @@ const (
 	InstanceTypeLogs       = "logs"
 )
 
+// Names of the per-check alerts supported by the API.
+const (
+	AlertProbeFailedExecutionsTooHigh        = "ProbeFailedExecutionsTooHigh"
+	AlertTLSTargetCertificateCloseToExpiring = "TLSTargetCertificateCloseToExpiring"
+	AlertHTTPRequestDurationTooHighAvg       = "HTTPRequestDurationTooHighAvg"
+	AlertPingRequestDurationTooHighAvg       = "PingRequestDurationTooHighAvg"
+	AlertDNSRequestDurationTooHighAvg        = "DNSRequestDurationTooHighAvg"
+)
+
+var (
+	ErrInvalidAlertName      = errors.New("invalid alert name")
+	ErrInvalidAlertThreshold = errors.New("invalid alert threshold")
+	ErrInvalidAlertPeriod    = errors.New("invalid alert period")
+)
+
+// AlertNames returns the names of the per-check alerts supported by the
+// API.
+func AlertNames() []string {
+	return []string{
+		AlertProbeFailedExecutionsTooHigh,
+		AlertTLSTargetCertificateCloseToExpiring,
+		AlertHTTPRequestDurationTooHighAvg,
+		AlertPingRequestDurationTooHighAvg,
+		AlertDNSRequestDurationTooHighAvg,
+	}
+}
+
+// AlertPeriods returns the periods accepted by per-check alerts that are
+// evaluated over a period of time.
+func AlertPeriods() []string {
+	return []string{"5m", "10m", "15m", "20m", "30m", "1h"}
+}
+
 type ResponseError struct {
 	Msg string `json:"msg,omitempty"`
 	Err error  `json:"err,omitempty"`
@@ -94,6 +129,31 @@ type CheckAlert struct {
 	Modified   int64   `json:"modified"`
 }
 
+// Validate checks that the alert has a known name, a positive threshold
+// and, if set, a valid period. The period of alerts about certificate
+// expiration must not be set.
+func (a CheckAlert) Validate() error {
+	if !slices.Contains(AlertNames(), a.Name) {
+		return fmt.Errorf("%q: %w", a.Name, ErrInvalidAlertName)
+	}
+
+	if a.Threshold <= 0 {
+		return fmt.Errorf("%s: %w", a.Name, ErrInvalidAlertThreshold)
+	}
+
+	switch {
+	case a.Period == "":
+
+	case a.Name == AlertTLSTargetCertificateCloseToExpiring:
+		return fmt.Errorf("%s: period not supported: %w", a.Name, ErrInvalidAlertPeriod)
+
+	case !slices.Contains(AlertPeriods(), a.Period):
+		return fmt.Errorf("%s: %q: %w", a.Name, a.Period, ErrInvalidAlertPeriod)
+	}
+
+	return nil
+}
+
 type CheckAlertWithStatus struct {
 	CheckAlert
 	Status string `json:"status"`
@@ -112,6 +172,27 @@ type CheckWithAlerts struct {
 	Alerts []CheckAlertWithStatus `json:"alerts"`
 }
 
+// Secret is a value stored in the secrets manager which checks can
+// reference instead of including it in their settings, e.g. passwords
+// and bearer tokens.
+//
+// The API server never returns the value of a secret. Plaintext is only
+// used to set it when creating or updating a secret.
+type Secret struct {
+	UUID        string                       `json:"uuid,omitempty"`
+	Name        string                       `json:"name"`
+	Description string                       `json:"description,omitempty"`
+	Labels      []synthetic_monitoring.Label `json:"labels,omitempty"`
+	Plaintext   string                       `json:"plaintext,omitempty"`
+	CreatedAt   int64                        `json:"created_at,omitempty"`
+	CreatedBy   string                       `json:"created_by,omitempty"`
+	ModifiedAt  int64                        `json:"modified_at,omitempty"`
+}
+
+type SecretListResponse struct {
+	Secrets []Secret `json:"secrets"`
+}
+
 func (e *ResponseError) Error() string {
 	switch {
 	case e == nil:
@@ -0,0 +1,85 @@
+package model
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestCheckAlertValidate(t *testing.T) {
+	testcases := map[string]struct {
+		alert CheckAlert
+		err   error
+	}{
+		"valid": {
+			alert: CheckAlert{Name: AlertProbeFailedExecutionsTooHigh, Threshold: 1, Period: "5m"},
+		},
+		"valid without period": {
+			alert: CheckAlert{Name: AlertHTTPRequestDurationTooHighAvg, Threshold: 300},
+		},
+		"valid fractional threshold": {
+			alert: CheckAlert{Name: AlertPingRequestDurationTooHighAvg, Threshold: 0.5, Period: "1h"},
+		},
+		"valid certificate": {
+			alert: CheckAlert{Name: AlertTLSTargetCertificateCloseToExpiring, Threshold: 7},
+		},
+		"empty name": {
+			alert: CheckAlert{Threshold: 1, Period: "5m"},
+			err:   ErrInvalidAlertName,
+		},
+		"unknown name": {
+			alert: CheckAlert{Name: "ProbeTooSlow", Threshold: 1, Period: "5m"},
+			err:   ErrInvalidAlertName,
+		},
+		"name case": {
+			alert: CheckAlert{Name: "probefailedexecutionstoohigh", Threshold: 1, Period: "5m"},
+			err:   ErrInvalidAlertName,
+		},
+		"zero threshold": {
+			alert: CheckAlert{Name: AlertProbeFailedExecutionsTooHigh, Period: "5m"},
+			err:   ErrInvalidAlertThreshold,
+		},
+		"negative threshold": {
+			alert: CheckAlert{Name: AlertDNSRequestDurationTooHighAvg, Threshold: -1, Period: "5m"},
+			err:   ErrInvalidAlertThreshold,
+		},
+		"unknown period": {
+			alert: CheckAlert{Name: AlertProbeFailedExecutionsTooHigh, Threshold: 1, Period: "7m"},
+			err:   ErrInvalidAlertPeriod,
+		},
+		"malformed period": {
+			alert: CheckAlert{Name: AlertProbeFailedExecutionsTooHigh, Threshold: 1, Period: "5 minutes"},
+			err:   ErrInvalidAlertPeriod,
+		},
+		"certificate with period": {
+			alert: CheckAlert{Name: AlertTLSTargetCertificateCloseToExpiring, Threshold: 7, Period: "5m"},
+			err:   ErrInvalidAlertPeriod,
+		},
+	}
+
+	for name, tc := range testcases {
+		t.Run(name, func(t *testing.T) {
+			err := tc.alert.Validate()
+			if tc.err != nil {
+				require.ErrorIs(t, err, tc.err)
+				return
+			}
+
+			require.NoError(t, err)
+		})
+	}
+}
+
+func TestAlertPeriods(t *testing.T) {
+	periods := AlertPeriods()
+	require.Equal(t, []string{"5m", "10m", "15m", "20m", "30m", "1h"}, periods)
+
+	for _, period := range periods {
+		alert := CheckAlert{Name: AlertProbeFailedExecutionsTooHigh, Threshold: 1, Period: period}
+		require.NoError(t, alert.Validate(), period)
+	}
+
+	// Callers must not be able to change the accepted periods.
+	periods[0] = "1m"
+	require.Equal(t, "5m", AlertPeriods()[0])
+}
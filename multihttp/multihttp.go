@@ -0,0 +1,310 @@
+// Package multihttp builds Synthetic Monitoring MultiHTTP checks from a
+// description of the requests to make, written in YAML or JSON.
+//
+// A description looks like this:
+//
+//	logResponses: false
+//	steps:
+//	  - method: POST
+//	    url: https://api.example.com/login
+//	    headers:
+//	      Accept: application/json
+//	    body:
+//	      contentType: application/json
+//	      payload: '{"user": "alice"}'
+//	    variables:
+//	      - name: token
+//	        type: jsonpath
+//	        expression: $.token
+//	    assertions:
+//	      - type: text
+//	        subject: status
+//	        condition: equals
+//	        value: "200"
+//	  - url: https://api.example.com/profile
+//	    headers:
+//	      Authorization: Bearer ${token}
+//	    assertions:
+//	      - type: jsonpath-value
+//	        expression: $.name
+//	        condition: equals
+//	        value: alice
+//
+// Variables extracted in one step can be used in the URL, headers, query
+// fields and body of the following ones as ${name}.
+package multihttp
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"io"
+	"sort"
+	"strings"
+
+	sm "github.com/grafana/synthetic-monitoring-agent/pkg/pb/synthetic_monitoring"
+	"github.com/grafana/synthetic-monitoring-api-go-client/model"
+	"go.yaml.in/yaml/v3"
+)
+
+var (
+	ErrEmptyDefinition           = errors.New("empty definition")
+	ErrNoSteps                   = errors.New("at least one step is required")
+	ErrInvalidMethod             = errors.New("invalid HTTP method")
+	ErrInvalidVariableType       = errors.New("invalid variable type")
+	ErrInvalidAssertionType      = errors.New("invalid assertion type")
+	ErrInvalidAssertionSubject   = errors.New("invalid assertion subject")
+	ErrInvalidAssertionCondition = errors.New("invalid assertion condition")
+)
+
+// Definition describes the requests made by a MultiHTTP check.
+type Definition struct {
+	// LogResponses controls whether the response bodies are logged.
+	LogResponses bool   `yaml:"logResponses"`
+	Steps        []Step `yaml:"steps"`
+}
+
+// Step describes a single request, the variables to extract from its
+// response and the assertions to check against it.
+type Step struct {
+	// Method defaults to GET.
+	Method     string            `yaml:"method"`
+	URL        string            `yaml:"url"`
+	Headers    map[string]string `yaml:"headers"`
+	Query      map[string]string `yaml:"query"`
+	Body       *Body             `yaml:"body"`
+	Variables  []Variable        `yaml:"variables"`
+	Assertions []Assertion       `yaml:"assertions"`
+}
+
+// Body is the body of a request.
+type Body struct {
+	ContentType     string `yaml:"contentType"`
+	ContentEncoding string `yaml:"contentEncoding"`
+	Payload         string `yaml:"payload"`
+}
+
+// Variable describes a value extracted from a response.
+//
+// Type is one of "jsonpath" (the default), "regex" or "css". For "regex",
+// the value of the variable is the first capture group, or the whole
+// match if there isn't one. For "css", Attribute selects an attribute of
+// the first matching element instead of its HTML content.
+type Variable struct {
+	Name       string `yaml:"name"`
+	Type       string `yaml:"type"`
+	Expression string `yaml:"expression"`
+	Attribute  string `yaml:"attribute"`
+}
+
+// Assertion describes a condition the response must meet.
+//
+// Type is one of "text" (the default), "jsonpath-value", "jsonpath" or
+// "regex". Subject is one of "body", "headers" or "status", and Condition
+// is one of "contains", "not-contains", "equals", "starts-with",
+// "ends-with" or "type-of". Which of them are needed depends on the type,
+// as described in the Synthetic Monitoring documentation.
+type Assertion struct {
+	Type       string `yaml:"type"`
+	Subject    string `yaml:"subject"`
+	Condition  string `yaml:"condition"`
+	Expression string `yaml:"expression"`
+	Value      string `yaml:"value"`
+}
+
+var variableTypes = map[string]sm.MultiHttpEntryVariableType{
+	"":         sm.MultiHttpEntryVariableType_JSON_PATH,
+	"jsonpath": sm.MultiHttpEntryVariableType_JSON_PATH,
+	"regex":    sm.MultiHttpEntryVariableType_REGEX,
+	"css":      sm.MultiHttpEntryVariableType_CSS_SELECTOR,
+}
+
+var assertionTypes = map[string]sm.MultiHttpEntryAssertionType{
+	"":               sm.MultiHttpEntryAssertionType_TEXT,
+	"text":           sm.MultiHttpEntryAssertionType_TEXT,
+	"jsonpath-value": sm.MultiHttpEntryAssertionType_JSON_PATH_VALUE,
+	"jsonpath":       sm.MultiHttpEntryAssertionType_JSON_PATH_ASSERTION,
+	"regex":          sm.MultiHttpEntryAssertionType_REGEX_ASSERTION,
+}
+
+var assertionSubjects = map[string]sm.MultiHttpEntryAssertionSubjectVariant{
+	"":        sm.MultiHttpEntryAssertionSubjectVariant_DEFAULT_SUBJECT,
+	"body":    sm.MultiHttpEntryAssertionSubjectVariant_RESPONSE_BODY,
+	"headers": sm.MultiHttpEntryAssertionSubjectVariant_RESPONSE_HEADERS,
+	"status":  sm.MultiHttpEntryAssertionSubjectVariant_HTTP_STATUS_CODE,
+}
+
+var assertionConditions = map[string]sm.MultiHttpEntryAssertionConditionVariant{
+	"":             sm.MultiHttpEntryAssertionConditionVariant_DEFAULT_CONDITION,
+	"contains":     sm.MultiHttpEntryAssertionConditionVariant_CONTAINS,
+	"not-contains": sm.MultiHttpEntryAssertionConditionVariant_NOT_CONTAINS,
+	"equals":       sm.MultiHttpEntryAssertionConditionVariant_EQUALS,
+	"starts-with":  sm.MultiHttpEntryAssertionConditionVariant_STARTS_WITH,
+	"ends-with":    sm.MultiHttpEntryAssertionConditionVariant_ENDS_WITH,
+	"type-of":      sm.MultiHttpEntryAssertionConditionVariant_TYPE_OF,
+}
+
+// Parse parses a definition written in YAML or JSON. Unknown fields are
+// rejected.
+func Parse(data []byte) (*Definition, error) {
+	dec := yaml.NewDecoder(bytes.NewReader(data))
+	dec.KnownFields(true)
+
+	var d Definition
+
+	switch err := dec.Decode(&d); {
+	case errors.Is(err, io.EOF):
+		return nil, ErrEmptyDefinition
+
+	case err != nil:
+		return nil, fmt.Errorf("parsing definition: %w", err)
+	}
+
+	return &d, nil
+}
+
+// Settings returns the MultiHTTP settings described by d. It returns
+// ErrNoSteps if d has no steps.
+func (d Definition) Settings() (*sm.MultiHttpSettings, error) {
+	if len(d.Steps) == 0 {
+		return nil, ErrNoSteps
+	}
+
+	settings := &sm.MultiHttpSettings{
+		LogResponses: d.LogResponses,
+		Entries:      make([]*sm.MultiHttpEntry, 0, len(d.Steps)),
+	}
+
+	for i, step := range d.Steps {
+		entry, err := step.entry()
+		if err != nil {
+			return nil, fmt.Errorf("step %d: %w", i+1, err)
+		}
+
+		settings.Entries = append(settings.Entries, entry)
+	}
+
+	if err := settings.Validate(); err != nil {
+		return nil, fmt.Errorf("invalid settings: %w", err)
+	}
+
+	return settings, nil
+}
+
+// Check returns a copy of base using the MultiHTTP settings described by
+// d. The resulting check is validated, so base must have a tenant ID, a
+// job, a target, probes, and a valid frequency and timeout for MultiHTTP
+// checks.
+func (d Definition) Check(base model.Check) (*model.Check, error) {
+	settings, err := d.Settings()
+	if err != nil {
+		return nil, err
+	}
+
+	check := base
+	check.Settings = sm.CheckSettings{Multihttp: settings}
+
+	if err := check.Validate(); err != nil {
+		return nil, fmt.Errorf("invalid check: %w", err)
+	}
+
+	return &check, nil
+}
+
+func (s Step) entry() (*sm.MultiHttpEntry, error) {
+	method := sm.HttpMethod_GET
+
+	if s.Method != "" {
+		value, found := sm.HttpMethod_value[strings.ToUpper(s.Method)]
+		if !found {
+			return nil, fmt.Errorf("%q: %w", s.Method, ErrInvalidMethod)
+		}
+
+		method = sm.HttpMethod(value)
+	}
+
+	entry := &sm.MultiHttpEntry{
+		Request: &sm.MultiHttpEntryRequest{
+			Method: method,
+			Url:    s.URL,
+		},
+	}
+
+	for _, name := range sortedKeys(s.Headers) {
+		entry.Request.Headers = append(entry.Request.Headers, &sm.HttpHeader{Name: name, Value: s.Headers[name]})
+	}
+
+	for _, name := range sortedKeys(s.Query) {
+		entry.Request.QueryFields = append(entry.Request.QueryFields, &sm.QueryField{Name: name, Value: s.Query[name]})
+	}
+
+	if s.Body != nil {
+		entry.Request.Body = &sm.HttpRequestBody{
+			ContentType:     s.Body.ContentType,
+			ContentEncoding: s.Body.ContentEncoding,
+			Payload:         []byte(s.Body.Payload),
+		}
+	}
+
+	for _, v := range s.Variables {
+		variableType, found := variableTypes[strings.ToLower(v.Type)]
+		if !found {
+			return nil, fmt.Errorf("variable %q: %q: %w", v.Name, v.Type, ErrInvalidVariableType)
+		}
+
+		entry.Variables = append(entry.Variables, &sm.MultiHttpEntryVariable{
+			Type:       variableType,
+			Name:       v.Name,
+			Expression: v.Expression,
+			Attribute:  v.Attribute,
+		})
+	}
+
+	for i, a := range s.Assertions {
+		assertion, err := a.assertion()
+		if err != nil {
+			return nil, fmt.Errorf("assertion %d: %w", i+1, err)
+		}
+
+		entry.Assertions = append(entry.Assertions, assertion)
+	}
+
+	return entry, nil
+}
+
+func (a Assertion) assertion() (*sm.MultiHttpEntryAssertion, error) {
+	assertionType, found := assertionTypes[strings.ToLower(a.Type)]
+	if !found {
+		return nil, fmt.Errorf("%q: %w", a.Type, ErrInvalidAssertionType)
+	}
+
+	subject, found := assertionSubjects[strings.ToLower(a.Subject)]
+	if !found {
+		return nil, fmt.Errorf("%q: %w", a.Subject, ErrInvalidAssertionSubject)
+	}
+
+	condition, found := assertionConditions[strings.ToLower(a.Condition)]
+	if !found {
+		return nil, fmt.Errorf("%q: %w", a.Condition, ErrInvalidAssertionCondition)
+	}
+
+	return &sm.MultiHttpEntryAssertion{
+		Type:       assertionType,
+		Subject:    subject,
+		Condition:  condition,
+		Expression: a.Expression,
+		Value:      a.Value,
+	}, nil
+}
+
+func sortedKeys(m map[string]string) []string {
+	keys := make([]string, 0, len(m))
+
+	for key := range m {
+		keys = append(keys, key)
+	}
+
+	sort.Strings(keys)
+
+	return keys
+}
@@ -0,0 +1,248 @@
+package multihttp
+
+import (
+	"testing"
+
+	sm "github.com/grafana/synthetic-monitoring-agent/pkg/pb/synthetic_monitoring"
+	"github.com/grafana/synthetic-monitoring-api-go-client/model"
+	"github.com/stretchr/testify/require"
+)
+
+const testYAML = `
+logResponses: true
+steps:
+  - method: post
+    url: https://api.example.com/login
+    headers:
+      X-B: b
+      X-A: a
+    query:
+      debug: "1"
+    body:
+      contentType: application/json
+      payload: '{"user": "alice"}'
+    variables:
+      - name: token
+        expression: $.token
+      - name: session
+        type: regex
+        expression: 'session=(\w+)'
+      - name: csrf
+        type: css
+        expression: 'input[name="csrf"]'
+        attribute: value
+    assertions:
+      - subject: status
+        condition: equals
+        value: "200"
+  - url: https://api.example.com/profile
+    headers:
+      Authorization: Bearer ${token}
+    assertions:
+      - type: jsonpath-value
+        expression: $.name
+        condition: equals
+        value: alice
+      - type: jsonpath
+        expression: $.id
+      - type: regex
+        subject: headers
+        expression: 'Content-Type: .*json'
+`
+
+func TestParse(t *testing.T) {
+	def, err := Parse([]byte(testYAML))
+	require.NoError(t, err)
+
+	settings, err := def.Settings()
+	require.NoError(t, err)
+
+	expected := &sm.MultiHttpSettings{
+		LogResponses: true,
+		Entries: []*sm.MultiHttpEntry{
+			{
+				Request: &sm.MultiHttpEntryRequest{
+					Method: sm.HttpMethod_POST,
+					Url:    "https://api.example.com/login",
+					Headers: []*sm.HttpHeader{
+						{Name: "X-A", Value: "a"},
+						{Name: "X-B", Value: "b"},
+					},
+					QueryFields: []*sm.QueryField{
+						{Name: "debug", Value: "1"},
+					},
+					Body: &sm.HttpRequestBody{
+						ContentType: "application/json",
+						Payload:     []byte(`{"user": "alice"}`),
+					},
+				},
+				Variables: []*sm.MultiHttpEntryVariable{
+					{Type: sm.MultiHttpEntryVariableType_JSON_PATH, Name: "token", Expression: "$.token"},
+					{Type: sm.MultiHttpEntryVariableType_REGEX, Name: "session", Expression: `session=(\w+)`},
+					{Type: sm.MultiHttpEntryVariableType_CSS_SELECTOR, Name: "csrf", Expression: `input[name="csrf"]`, Attribute: "value"},
+				},
+				Assertions: []*sm.MultiHttpEntryAssertion{
+					{
+						Type:      sm.MultiHttpEntryAssertionType_TEXT,
+						Subject:   sm.MultiHttpEntryAssertionSubjectVariant_HTTP_STATUS_CODE,
+						Condition: sm.MultiHttpEntryAssertionConditionVariant_EQUALS,
+						Value:     "200",
+					},
+				},
+			},
+			{
+				Request: &sm.MultiHttpEntryRequest{
+					Method: sm.HttpMethod_GET,
+					Url:    "https://api.example.com/profile",
+					Headers: []*sm.HttpHeader{
+						{Name: "Authorization", Value: "Bearer ${token}"},
+					},
+				},
+				Assertions: []*sm.MultiHttpEntryAssertion{
+					{
+						Type:       sm.MultiHttpEntryAssertionType_JSON_PATH_VALUE,
+						Condition:  sm.MultiHttpEntryAssertionConditionVariant_EQUALS,
+						Expression: "$.name",
+						Value:      "alice",
+					},
+					{
+						Type:       sm.MultiHttpEntryAssertionType_JSON_PATH_ASSERTION,
+						Expression: "$.id",
+					},
+					{
+						Type:       sm.MultiHttpEntryAssertionType_REGEX_ASSERTION,
+						Subject:    sm.MultiHttpEntryAssertionSubjectVariant_RESPONSE_HEADERS,
+						Expression: "Content-Type: .*json",
+					},
+				},
+			},
+		},
+	}
+
+	require.Equal(t, expected, settings)
+}
+
+func TestParseJSON(t *testing.T) {
+	def, err := Parse([]byte(`{"steps": [{"url": "https://www.example.org/"}]}`))
+	require.NoError(t, err)
+	require.Len(t, def.Steps, 1)
+	require.Equal(t, "https://www.example.org/", def.Steps[0].URL)
+}
+
+func TestParseErrors(t *testing.T) {
+	testcases := map[string]struct {
+		input    string
+		expected error
+	}{
+		"empty": {
+			input:    "",
+			expected: ErrEmptyDefinition,
+		},
+	}
+
+	for name, tc := range testcases {
+		t.Run(name, func(t *testing.T) {
+			_, err := Parse([]byte(tc.input))
+			require.ErrorIs(t, err, tc.expected)
+		})
+	}
+
+	// Unknown fields are rejected, to catch typos.
+	_, err := Parse([]byte("steps:\n  - uri: https://www.example.org/\n"))
+	require.Error(t, err)
+}
+
+func TestSettingsErrors(t *testing.T) {
+	testcases := map[string]struct {
+		step     Step
+		expected error
+	}{
+		"invalid method": {
+			step:     Step{Method: "FETCH", URL: "https://www.example.org/"},
+			expected: ErrInvalidMethod,
+		},
+		"invalid variable type": {
+			step: Step{
+				URL:       "https://www.example.org/",
+				Variables: []Variable{{Name: "x", Type: "xpath", Expression: "//x"}},
+			},
+			expected: ErrInvalidVariableType,
+		},
+		"invalid assertion type": {
+			step: Step{
+				URL:        "https://www.example.org/",
+				Assertions: []Assertion{{Type: "xpath"}},
+			},
+			expected: ErrInvalidAssertionType,
+		},
+		"invalid assertion subject": {
+			step: Step{
+				URL:        "https://www.example.org/",
+				Assertions: []Assertion{{Subject: "cookies"}},
+			},
+			expected: ErrInvalidAssertionSubject,
+		},
+		"invalid assertion condition": {
+			step: Step{
+				URL:        "https://www.example.org/",
+				Assertions: []Assertion{{Condition: "matches"}},
+			},
+			expected: ErrInvalidAssertionCondition,
+		},
+		"invalid url": {
+			step:     Step{URL: "www.example.org"},
+			expected: sm.ErrInvalidHttpUrl,
+		},
+		"missing assertion value": {
+			step: Step{
+				URL:        "https://www.example.org/",
+				Assertions: []Assertion{{Subject: "body", Condition: "contains"}},
+			},
+			expected: sm.ErrInvalidMultiHttpAssertionMissingValue,
+		},
+	}
+
+	for name, tc := range testcases {
+		t.Run(name, func(t *testing.T) {
+			_, err := Definition{Steps: []Step{tc.step}}.Settings()
+			require.ErrorIs(t, err, tc.expected)
+		})
+	}
+
+	_, err := Definition{}.Settings()
+	require.ErrorIs(t, err, ErrNoSteps)
+
+	_, err = Definition{LogResponses: true, Steps: []Step{}}.Settings()
+	require.ErrorIs(t, err, ErrNoSteps)
+}
+
+func TestCheck(t *testing.T) {
+	def, err := Parse([]byte(testYAML))
+	require.NoError(t, err)
+
+	base := model.Check{
+		Check: sm.Check{
+			TenantId:  1,
+			Job:       "login",
+			Target:    "https://api.example.com/",
+			Frequency: 60000,
+			Timeout:   10000,
+			Enabled:   true,
+			Probes:    []int64{1},
+		},
+		FolderUid: "folder",
+	}
+
+	check, err := def.Check(base)
+	require.NoError(t, err)
+	require.Equal(t, sm.CheckTypeMultiHttp, check.Type())
+	require.Equal(t, "folder", check.FolderUid)
+	require.Len(t, check.Settings.Multihttp.Entries, 2)
+
+	// The base check is not modified.
+	require.Nil(t, base.Settings.Multihttp)
+
+	base.Probes = nil
+	_, err = def.Check(base)
+	require.ErrorIs(t, err, sm.ErrInvalidCheckProbes)
+}
@@ -0,0 +1,189 @@
+package smapi
+
+import (
+	"log/slog"
+	"net/http"
+	"strings"
+	"time"
+)
+
+// Option configures a Client created using New.
+type Option func(*Client)
+
+// TransportMiddleware wraps the http.RoundTripper used by the client
+// to send requests to the API server.
+//
+// Middlewares can be used to observe or modify requests and responses
+// at the HTTP level, e.g. to add headers or to record requests.
+type TransportMiddleware func(http.RoundTripper) http.RoundTripper
+
+// WithAccessToken sets the access token used to authenticate requests.
+//
+// If no access token is provided, it's necessary to use one of the
+// registration calls to obtain one, e.g. Install.
+func WithAccessToken(accessToken string) Option {
+	return func(c *Client) {
+		c.tokens = NewStaticTokenSource(accessToken)
+	}
+}
+
+// WithTokenSource sets the source of the access token used to
+// authenticate requests. The token source is consulted for every
+// request. This option replaces WithAccessToken.
+func WithTokenSource(tokens TokenSource) Option {
+	return func(c *Client) {
+		c.tokens = tokens
+	}
+}
+
+// WithHTTPClient sets the HTTP client used to send requests to the
+// API server. If client is nil, http.DefaultClient is used.
+func WithHTTPClient(client *http.Client) Option {
+	return func(c *Client) {
+		c.client = client
+	}
+}
+
+// WithTimeout sets the default timeout for each API call.
+//
+// The timeout is only applied if the context passed to the call does
+// not already have a deadline. A zero value disables the timeout.
+func WithTimeout(timeout time.Duration) Option {
+	return func(c *Client) {
+		c.timeout = timeout
+	}
+}
+
+// WithUserAgent sets the User-Agent header sent with every request.
+func WithUserAgent(userAgent string) Option {
+	return func(c *Client) {
+		c.userAgent = userAgent
+	}
+}
+
+// WithClientID sets a custom X-Client-ID header value that will
+// override the go-client's default.
+func WithClientID(clientID string) Option {
+	return func(c *Client) {
+		c.customClientID = clientID
+	}
+}
+
+// WithClientVersion sets a custom X-Client-Version header value that
+// will override the go-client's default.
+func WithClientVersion(version string) Option {
+	return func(c *Client) {
+		c.customClientVersion = version
+	}
+}
+
+// WithTokenRefresh enables refreshing the access token when the API
+// server rejects it. If a request fails with 401 Unauthorized, the
+// refresher is called in order to obtain a new token and the request
+// is sent again exactly once using the new token.
+//
+// Concurrent requests that fail at the same time trigger a single
+// refresh.
+func WithTokenRefresh(refresher TokenRefresher) Option {
+	return func(c *Client) {
+		c.tokenRefresher = refresher
+	}
+}
+
+// WithRetryPolicy sets the policy used to retry failed requests. By
+// default requests are not retried.
+func WithRetryPolicy(policy RetryPolicy) Option {
+	return func(c *Client) {
+		c.retryPolicy = policy
+	}
+}
+
+// WithRateLimit limits the rate of requests sent to the API server to
+// requestsPerSecond, allowing bursts of up to burst requests. Calls
+// wait until the rate limiter allows the request to proceed or the
+// context is done. Each retry counts as a separate request.
+//
+// A value of zero or less for requestsPerSecond disables the limit.
+func WithRateLimit(requestsPerSecond float64, burst int) Option {
+	return func(c *Client) {
+		c.limiter.setRate(requestsPerSecond, burst)
+	}
+}
+
+// WithMaxInFlight limits the number of requests to the API server that
+// can be in flight at the same time. A request is in flight until its
+// response has been read. Calls wait until there's capacity or the
+// context is done.
+//
+// A value of zero or less disables the limit.
+func WithMaxInFlight(n int) Option {
+	return func(c *Client) {
+		c.limiter.setMaxInFlight(n)
+	}
+}
+
+// WithLogger sets the logger used by the client. By default nothing is
+// logged.
+//
+// Every request is logged, including its method, path, status and
+// duration: successful requests at debug level, requests rejected by the
+// API server at info level, and server errors and requests that could not
+// be sent at warn level. At debug level, the request and response headers
+// and bodies are logged as well, with secrets such as access tokens and
+// passwords redacted.
+//
+// Loggers from other libraries can be used as long as they provide a
+// slog.Handler, e.g. for zerolog:
+//
+//	smapi.WithLogger(slog.New(zerolog.NewSlogHandler(logger)))
+func WithLogger(logger *slog.Logger) Option {
+	return func(c *Client) {
+		c.logger = logger
+	}
+}
+
+// WithTransportMiddleware adds middlewares around the transport of the
+// HTTP client. The first middleware is the outermost one, i.e. it sees
+// the request first and the response last.
+//
+// The HTTP client passed using WithHTTPClient is not modified, a copy
+// is made instead.
+func WithTransportMiddleware(middlewares ...TransportMiddleware) Option {
+	return func(c *Client) {
+		c.transportMiddlewares = append(c.transportMiddlewares, middlewares...)
+	}
+}
+
+// WithSecretsURL sets the base URL of the secrets API, including the
+// version, e.g. "https://example.com/api/v1alpha1". By default it's
+// derived from the base URL passed to New.
+func WithSecretsURL(secretsURL string) Option {
+	return func(c *Client) {
+		c.secretsURL = strings.TrimSuffix(secretsURL, "/")
+	}
+}
+
+// WithInterceptors adds interceptors that are called for every
+// operation performed by the client, e.g. in order to add headers to
+// requests, to audit changes or to prevent them. The first interceptor
+// is the outermost one, i.e. it sees the operation first and its result
+// last.
+//
+// Unlike transport middlewares, interceptors see each operation once,
+// regardless of retries, and have access to the decoded request and
+// response.
+func WithInterceptors(interceptors ...Interceptor) Option {
+	return func(c *Client) {
+		c.interceptors = append(c.interceptors, interceptors...)
+	}
+}
+
+// WithDatasourceProxy indicates that the base URL passed to New is
+// the URL of a Grafana datasource proxy for the Synthetic Monitoring
+// API, and it should be used as is. In this case the access token
+// should be a Grafana access token.
+func WithDatasourceProxy() Option {
+	return func(c *Client) {
+		c.datasourceProxy = true
+	}
+}
@@ -0,0 +1,221 @@
+package smapi
+
+import (
+	"context"
+	"net/http"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestNew(t *testing.T) {
+	url, _, cleanup := newTestServer(t)
+	defer cleanup()
+
+	httpClient := &http.Client{}
+
+	testcases := map[string]struct {
+		url             string
+		opts            []Option
+		expectedBaseURL string
+		expectError     bool
+	}{
+		"trivial": {
+			url:             url,
+			expectedBaseURL: url + "/api/v1",
+		},
+		"extra slash": {
+			url:             url + "/",
+			expectedBaseURL: url + "/api/v1",
+		},
+		"path prefix": {
+			url:             url + "/sm/",
+			expectedBaseURL: url + "/sm/api/v1",
+		},
+		"datasource proxy": {
+			url:             url + "/api/datasources/proxy/1/",
+			opts:            []Option{WithDatasourceProxy()},
+			expectedBaseURL: url + "/api/datasources/proxy/1",
+		},
+		"all options": {
+			url: url,
+			opts: []Option{
+				WithAccessToken("123"),
+				WithHTTPClient(httpClient),
+				WithTimeout(time.Second),
+				WithUserAgent("test-agent"),
+				WithClientID("test-client"),
+				WithClientVersion("1.2.3"),
+				WithRetryPolicy(DefaultRetryPolicy()),
+			},
+			expectedBaseURL: url + "/api/v1",
+		},
+		"invalid url": {
+			url:         "http://[::1",
+			expectError: true,
+		},
+		"missing scheme": {
+			url:         "localhost/sm",
+			expectError: true,
+		},
+		"empty url": {
+			url:         "",
+			expectError: true,
+		},
+	}
+
+	for name, testcase := range testcases {
+		t.Run(name, func(t *testing.T) {
+			c, err := New(testcase.url, testcase.opts...)
+			if testcase.expectError {
+				require.Error(t, err)
+				require.Nil(t, c)
+
+				return
+			}
+
+			require.NoError(t, err)
+			require.NotNil(t, c)
+			require.NotNil(t, c.client)
+			require.Equal(t, testcase.expectedBaseURL, c.baseURL)
+		})
+	}
+}
+
+func TestNewClientInvalidURL(t *testing.T) {
+	require.Nil(t, NewClient("http://[::1", "", nil))
+	require.Nil(t, NewDatasourceClient("http://[::1", "", nil))
+}
+
+func TestNewClientLegacyURL(t *testing.T) {
+	// The legacy constructors accept base URLs that New rejects.
+	testcases := map[string]struct {
+		url                       string
+		expectedBaseURL           string
+		expectedDatasourceBaseURL string
+	}{
+		"empty url": {
+			url:                       "",
+			expectedBaseURL:           "/api/v1",
+			expectedDatasourceBaseURL: "",
+		},
+		"relative url": {
+			url:                       "sm/",
+			expectedBaseURL:           "sm/api/v1",
+			expectedDatasourceBaseURL: "sm",
+		},
+	}
+
+	for name, testcase := range testcases {
+		t.Run(name, func(t *testing.T) {
+			_, err := New(testcase.url)
+			require.ErrorIs(t, err, ErrInvalidBaseURL)
+
+			c := NewClient(testcase.url, "token", nil)
+			require.NotNil(t, c)
+			require.Equal(t, testcase.expectedBaseURL, c.baseURL)
+
+			c = NewDatasourceClient(testcase.url, "token", nil)
+			require.NotNil(t, c)
+			require.Equal(t, testcase.expectedDatasourceBaseURL, c.baseURL)
+		})
+	}
+}
+
+func TestWithTimeout(t *testing.T) {
+	url, mux, cleanup := newTestServer(t)
+	defer cleanup()
+
+	mux.Handle("/api/v1/slow", http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		select {
+		case <-r.Context().Done():
+		case <-time.After(5 * time.Second):
+		}
+		w.WriteHeader(http.StatusOK)
+	}))
+
+	mux.Handle("/api/v1/fast", http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusOK)
+		_, _ = w.Write([]byte("{}"))
+	}))
+
+	c, err := New(url, WithTimeout(50*time.Millisecond))
+	require.NoError(t, err)
+
+	resp, err := c.Get(context.Background(), "/slow", false, nil) //nolint:bodyclose // resp is nil
+	require.ErrorIs(t, err, context.DeadlineExceeded)
+	require.Nil(t, resp)
+
+	// The timeout must not affect reading the body of a successful
+	// response.
+	resp, err = c.Get(context.Background(), "/fast", false, nil)
+	require.NoError(t, err)
+
+	var result struct{}
+	require.NoError(t, ValidateResponse("fast request", resp, &result))
+}
+
+func TestWithUserAgent(t *testing.T) {
+	url, mux, cleanup := newTestServer(t)
+	defer cleanup()
+
+	var userAgent string
+	mux.Handle("/api/v1/test", http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		userAgent = r.Header.Get("User-Agent")
+		w.WriteHeader(http.StatusOK)
+	}))
+
+	c, err := New(url, WithUserAgent("test-agent/1.0"))
+	require.NoError(t, err)
+
+	resp, err := c.Get(context.Background(), "/test", false, nil)
+	require.NoError(t, err)
+	resp.Body.Close()
+
+	require.Equal(t, "test-agent/1.0", userAgent)
+}
+
+type roundTripperFunc func(*http.Request) (*http.Response, error)
+
+func (f roundTripperFunc) RoundTrip(req *http.Request) (*http.Response, error) {
+	return f(req)
+}
+
+func TestWithTransportMiddleware(t *testing.T) {
+	url, mux, cleanup := newTestServer(t)
+	defer cleanup()
+
+	var received []string
+	mux.Handle("/api/v1/test", http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		received = r.Header.Values("X-Middleware")
+		w.WriteHeader(http.StatusOK)
+	}))
+
+	newMiddleware := func(name string) TransportMiddleware {
+		return func(next http.RoundTripper) http.RoundTripper {
+			return roundTripperFunc(func(req *http.Request) (*http.Response, error) {
+				req.Header.Add("X-Middleware", name)
+
+				return next.RoundTrip(req)
+			})
+		}
+	}
+
+	httpClient := &http.Client{}
+
+	c, err := New(url,
+		WithHTTPClient(httpClient),
+		WithTransportMiddleware(newMiddleware("first"), newMiddleware("second")),
+	)
+	require.NoError(t, err)
+
+	// The original client must not be modified.
+	require.Nil(t, httpClient.Transport)
+
+	resp, err := c.Get(context.Background(), "/test", false, nil)
+	require.NoError(t, err)
+	resp.Body.Close()
+
+	require.Equal(t, []string{"first", "second"}, received)
+}
@@ -0,0 +1,240 @@
+package smapi
+
+import (
+	"context"
+	"io"
+	"math"
+	"math/rand/v2"
+	"net/http"
+	"strconv"
+	"time"
+)
+
+const (
+	defaultMinBackoff = 100 * time.Millisecond
+	defaultMaxBackoff = 5 * time.Second
+)
+
+// RetryPolicy describes how failed requests are retried.
+//
+// Only idempotent requests (GET, HEAD, PUT and DELETE) are retried by
+// default. POST requests are retried only if the context passed to the
+// call has been marked using EnablePOSTRetries. In all cases the
+// request body must be replayable, which is true for all the requests
+// made by the methods in this package.
+//
+// A request is retried if it fails with a transport error or if the
+// server responds with 429, 502, 503 or 504.
+type RetryPolicy struct {
+	// MaxAttempts is the maximum number of attempts, including the
+	// first one. Values lower than 2 disable retries.
+	MaxAttempts int
+
+	// MinBackoff is the time to wait before the first retry. The
+	// wait time doubles with every retry.
+	MinBackoff time.Duration
+
+	// MaxBackoff caps the time to wait between attempts, including
+	// the time requested by the server using Retry-After.
+	MaxBackoff time.Duration
+
+	// Jitter is the fraction, between 0 and 1, of the wait time
+	// that is randomized in order to avoid synchronized retries
+	// from multiple clients.
+	Jitter float64
+}
+
+// DefaultRetryPolicy returns a RetryPolicy with reasonable defaults.
+func DefaultRetryPolicy() RetryPolicy {
+	const (
+		defaultMaxAttempts = 3
+		defaultJitter      = 0.2
+	)
+
+	return RetryPolicy{
+		MaxAttempts: defaultMaxAttempts,
+		MinBackoff:  defaultMinBackoff,
+		MaxBackoff:  defaultMaxBackoff,
+		Jitter:      defaultJitter,
+	}
+}
+
+type postRetriesKey struct{}
+
+// EnablePOSTRetries returns a context that allows POST requests made
+// with it to be retried according to the client's retry policy.
+//
+// This should be used only with calls that are safe to repeat, e.g.
+// UpdateCheck, but not AddCheck.
+func EnablePOSTRetries(ctx context.Context) context.Context {
+	return context.WithValue(ctx, postRetriesKey{}, true)
+}
+
+// maxAttempts returns the number of attempts allowed for req.
+func (p RetryPolicy) maxAttempts(req *http.Request) int {
+	if p.MaxAttempts < 2 {
+		return 1
+	}
+
+	// The body is consumed by the first attempt, and it's only
+	// possible to retry if it can be obtained again.
+	if req.Body != nil && req.Body != http.NoBody && req.GetBody == nil {
+		return 1
+	}
+
+	switch req.Method {
+	case http.MethodGet, http.MethodHead, http.MethodPut, http.MethodDelete:
+		return p.MaxAttempts
+
+	case http.MethodPost:
+		if enabled, _ := req.Context().Value(postRetriesKey{}).(bool); enabled {
+			return p.MaxAttempts
+		}
+
+		return 1
+
+	default:
+		return 1
+	}
+}
+
+// backoff returns the time to wait after the specified attempt
+// (starting at 1) failed.
+func (p RetryPolicy) backoff(attempt int) time.Duration {
+	minBackoff := p.MinBackoff
+	if minBackoff <= 0 {
+		minBackoff = defaultMinBackoff
+	}
+
+	maxBackoff := p.maxBackoff()
+
+	d := minBackoff
+	for i := 1; i < attempt && d < maxBackoff; i++ {
+		d *= 2
+	}
+
+	d = min(d, maxBackoff)
+
+	if jitter := min(max(p.Jitter, 0), 1); jitter > 0 {
+		d -= time.Duration(jitter * rand.Float64() * float64(d)) //nolint:gosec // no need for a secure random number here
+	}
+
+	return d
+}
+
+func (p RetryPolicy) maxBackoff() time.Duration {
+	if p.MaxBackoff <= 0 {
+		return defaultMaxBackoff
+	}
+
+	return p.MaxBackoff
+}
+
+// delay returns the time to wait before retrying after the specified
+// attempt failed, honoring the Retry-After header sent by the server,
+// if any. The server cannot ask for a wait longer than MaxBackoff.
+func (p RetryPolicy) delay(attempt int, resp *http.Response) time.Duration {
+	if d, found := retryAfter(resp, time.Now()); found {
+		return min(d, p.maxBackoff())
+	}
+
+	return p.backoff(attempt)
+}
+
+// retryAfter parses the Retry-After header of the response, which can
+// be either a number of seconds or an HTTP date.
+func retryAfter(resp *http.Response, now time.Time) (time.Duration, bool) {
+	if resp == nil {
+		return 0, false
+	}
+
+	value := resp.Header.Get("Retry-After")
+	if value == "" {
+		return 0, false
+	}
+
+	if seconds, err := strconv.ParseInt(value, 10, 64); err == nil {
+		// Avoid overflowing time.Duration.
+		seconds = min(max(seconds, 0), int64(math.MaxInt64/time.Second))
+
+		return time.Duration(seconds) * time.Second, true
+	}
+
+	if t, err := http.ParseTime(value); err == nil {
+		return max(t.Sub(now), 0), true
+	}
+
+	return 0, false
+}
+
+func shouldRetry(resp *http.Response, err error) bool {
+	if err != nil {
+		return true
+	}
+
+	switch resp.StatusCode {
+	case http.StatusTooManyRequests, http.StatusBadGateway, http.StatusServiceUnavailable, http.StatusGatewayTimeout:
+		return true
+
+	default:
+		return false
+	}
+}
+
+// canWait reports whether it's possible to wait for d without
+// exceeding the deadline of ctx.
+func canWait(ctx context.Context, d time.Duration) bool {
+	deadline, found := ctx.Deadline()
+
+	return !found || time.Until(deadline) > d
+}
+
+type attemptsKey struct{}
+
+// withAttemptsCounter returns a context that keeps track of the
+// number of attempts made to send a request.
+func withAttemptsCounter(ctx context.Context) (context.Context, *int) {
+	var attempts int
+
+	return context.WithValue(ctx, attemptsKey{}, &attempts), &attempts
+}
+
+// attemptsFromResponse returns the number of attempts that were made to
+// obtain resp, or 0 if unknown.
+func attemptsFromResponse(resp *http.Response) int {
+	if resp.Request == nil {
+		return 0
+	}
+
+	if attempts, ok := resp.Request.Context().Value(attemptsKey{}).(*int); ok {
+		return *attempts
+	}
+
+	return 0
+}
+
+// discardResponse drains and closes the body of a response that is
+// not going to be used, so that the connection can be reused.
+func discardResponse(resp *http.Response) {
+	if resp == nil || resp.Body == nil {
+		return
+	}
+
+	_, _ = io.Copy(io.Discard, resp.Body)
+	_ = resp.Body.Close()
+}
+
+// sleep waits for the specified duration or until the context is
+// done, whichever happens first.
+func sleep(ctx context.Context, d time.Duration) error {
+	t := time.NewTimer(d)
+	defer t.Stop()
+
+	select {
+	case <-ctx.Done():
+		return ctx.Err()
+
+	case <-t.C:
+		return nil
+	}
+}
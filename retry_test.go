@@ -0,0 +1,296 @@
+package smapi
+
+import (
+	"context"
+	"io"
+	"net/http"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/grafana/synthetic-monitoring-agent/pkg/pb/synthetic_monitoring"
+	"github.com/grafana/synthetic-monitoring-api-go-client/model"
+	"github.com/stretchr/testify/require"
+)
+
+func TestRetryPolicyBackoff(t *testing.T) {
+	p := RetryPolicy{MinBackoff: 100 * time.Millisecond, MaxBackoff: time.Second}
+
+	require.Equal(t, 100*time.Millisecond, p.backoff(1))
+	require.Equal(t, 200*time.Millisecond, p.backoff(2))
+	require.Equal(t, 400*time.Millisecond, p.backoff(3))
+	require.Equal(t, 800*time.Millisecond, p.backoff(4))
+	require.Equal(t, time.Second, p.backoff(5))
+	require.Equal(t, time.Second, p.backoff(50))
+}
+
+func TestRetries(t *testing.T) {
+	testcases := map[string]struct {
+		method           string
+		body             string
+		failures         int
+		expectedStatus   int
+		expectedRequests int
+	}{
+		"get succeeds after failures": {
+			method:           http.MethodGet,
+			failures:         2,
+			expectedStatus:   http.StatusOK,
+			expectedRequests: 3,
+		},
+		"get gives up": {
+			method:           http.MethodGet,
+			failures:         5,
+			expectedStatus:   http.StatusServiceUnavailable,
+			expectedRequests: 3,
+		},
+		"delete is retried": {
+			method:           http.MethodDelete,
+			failures:         1,
+			expectedStatus:   http.StatusOK,
+			expectedRequests: 2,
+		},
+		"put is retried": {
+			method:           http.MethodPut,
+			body:             "{}",
+			failures:         1,
+			expectedStatus:   http.StatusOK,
+			expectedRequests: 2,
+		},
+		"post is not retried": {
+			method:           http.MethodPost,
+			body:             "{}",
+			failures:         1,
+			expectedStatus:   http.StatusServiceUnavailable,
+			expectedRequests: 1,
+		},
+	}
+
+	for name, tc := range testcases {
+		t.Run(name, func(t *testing.T) {
+			url, mux, cleanup := newTestServer(t)
+			defer cleanup()
+
+			var requests int
+			mux.Handle("/api/v1/test", http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				requests++
+				if requests <= tc.failures {
+					w.WriteHeader(http.StatusServiceUnavailable)
+					return
+				}
+				w.WriteHeader(http.StatusOK)
+			}))
+
+			c, err := New(url, WithRetryPolicy(RetryPolicy{MaxAttempts: 3, MinBackoff: time.Millisecond}))
+			require.NoError(t, err)
+
+			var body io.Reader
+			if tc.body != "" {
+				body = strings.NewReader(tc.body)
+			}
+
+			resp, err := c.do(context.Background(), c.baseURL+"/test", tc.method, false, nil, body)
+			require.NoError(t, err)
+			resp.Body.Close()
+
+			require.Equal(t, tc.expectedStatus, resp.StatusCode)
+			require.Equal(t, tc.expectedRequests, requests)
+		})
+	}
+}
+
+func TestRetriesContextDeadline(t *testing.T) {
+	url, mux, cleanup := newTestServer(t)
+	defer cleanup()
+
+	var requests int
+	mux.Handle("/api/v1/test", http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		requests++
+		w.WriteHeader(http.StatusServiceUnavailable)
+	}))
+
+	c, err := New(url, WithRetryPolicy(RetryPolicy{MaxAttempts: 10, MinBackoff: time.Hour, MaxBackoff: time.Hour}))
+	require.NoError(t, err)
+
+	t.Run("deadline before next attempt", func(t *testing.T) {
+		requests = 0
+
+		ctx, cancel := context.WithTimeout(context.Background(), time.Minute)
+		defer cancel()
+
+		// The next attempt would happen after the deadline, so
+		// the last response is returned right away.
+		resp, err := c.Get(ctx, "/test", false, nil)
+		require.NoError(t, err)
+		resp.Body.Close()
+		require.Equal(t, http.StatusServiceUnavailable, resp.StatusCode)
+		require.Equal(t, 1, requests)
+	})
+
+	t.Run("canceled while waiting", func(t *testing.T) {
+		requests = 0
+
+		ctx, cancel := context.WithCancel(context.Background())
+		time.AfterFunc(50*time.Millisecond, cancel)
+
+		resp, err := c.Get(ctx, "/test", false, nil) //nolint:bodyclose // resp is nil
+		require.ErrorIs(t, err, context.Canceled)
+		require.Nil(t, resp)
+		require.Equal(t, 1, requests)
+	})
+}
+
+func TestRetryAfter(t *testing.T) {
+	now := time.Date(2020, 1, 1, 0, 0, 0, 0, time.UTC)
+
+	testcases := map[string]struct {
+		value         string
+		expected      time.Duration
+		expectedFound bool
+	}{
+		"missing":    {},
+		"seconds":    {value: "3", expected: 3 * time.Second, expectedFound: true},
+		"date":       {value: now.Add(time.Minute).Format(http.TimeFormat), expected: time.Minute, expectedFound: true},
+		"past date":  {value: now.Add(-time.Minute).Format(http.TimeFormat), expected: 0, expectedFound: true},
+		"negative":   {value: "-3", expected: 0, expectedFound: true},
+		"invalid":    {value: "soon"},
+		"whitespace": {value: " "},
+	}
+
+	for name, tc := range testcases {
+		t.Run(name, func(t *testing.T) {
+			resp := &http.Response{Header: make(http.Header)}
+			if tc.value != "" {
+				resp.Header.Set("Retry-After", tc.value)
+			}
+
+			actual, found := retryAfter(resp, now)
+			require.Equal(t, tc.expectedFound, found)
+			require.Equal(t, tc.expected, actual)
+		})
+	}
+}
+
+func TestRetryPolicyDelayCapsRetryAfter(t *testing.T) {
+	p := RetryPolicy{MaxAttempts: 2, MinBackoff: time.Second, MaxBackoff: 10 * time.Second}
+
+	testcases := map[string]struct {
+		policy   RetryPolicy
+		value    string
+		expected time.Duration
+	}{
+		"short":        {policy: p, value: "3", expected: 3 * time.Second},
+		"one day":      {policy: p, value: "86400", expected: 10 * time.Second},
+		"overflow":     {policy: p, value: "9223372036854775807", expected: 10 * time.Second},
+		"far date":     {policy: p, value: time.Now().AddDate(1, 0, 0).Format(http.TimeFormat), expected: 10 * time.Second},
+		"default max":  {policy: RetryPolicy{MaxAttempts: 2}, value: "86400", expected: defaultMaxBackoff},
+		"no wait":      {policy: p, value: "0", expected: 0},
+		"invalid date": {policy: RetryPolicy{MaxAttempts: 2, MinBackoff: time.Second}, value: "later", expected: time.Second},
+	}
+
+	for name, tc := range testcases {
+		t.Run(name, func(t *testing.T) {
+			resp := &http.Response{Header: make(http.Header)}
+			resp.Header.Set("Retry-After", tc.value)
+
+			require.Equal(t, tc.expected, tc.policy.delay(1, resp))
+		})
+	}
+}
+
+func TestRetriesHonorRetryAfter(t *testing.T) {
+	url, mux, cleanup := newTestServer(t)
+	defer cleanup()
+
+	var requests int
+	mux.Handle("/api/v1/check/1", http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		requests++
+		if requests == 1 {
+			// The policy would wait for an hour, the server asks
+			// for a retry right away.
+			w.Header().Set("Retry-After", "0")
+			w.WriteHeader(http.StatusTooManyRequests)
+			return
+		}
+		writeResponse(w, http.StatusOK, &model.Check{})
+	}))
+
+	c, err := New(url, WithAccessToken("token"), WithRetryPolicy(RetryPolicy{MaxAttempts: 2, MinBackoff: time.Hour, MaxBackoff: time.Hour}))
+	require.NoError(t, err)
+
+	_, err = c.GetCheck(context.Background(), 1)
+	require.NoError(t, err)
+	require.Equal(t, 2, requests)
+}
+
+func TestRetriesReplayBody(t *testing.T) {
+	url, mux, cleanup := newTestServer(t)
+	defer cleanup()
+
+	var bodies []string
+	mux.Handle("/api/v1/check/update", http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		buf, _ := io.ReadAll(r.Body)
+		bodies = append(bodies, string(buf))
+		if len(bodies) == 1 {
+			w.WriteHeader(http.StatusBadGateway)
+			return
+		}
+		writeResponse(w, http.StatusOK, &model.Check{})
+	}))
+
+	c, err := New(url, WithAccessToken("token"), WithRetryPolicy(RetryPolicy{MaxAttempts: 3, MinBackoff: time.Millisecond}))
+	require.NoError(t, err)
+
+	check := model.Check{Check: synthetic_monitoring.Check{Id: 42, Job: "job", Target: "target"}}
+
+	// POST requests are not retried unless requested.
+	_, err = c.UpdateCheck(context.Background(), check)
+	require.Error(t, err)
+	require.Len(t, bodies, 1)
+
+	bodies = nil
+
+	_, err = c.UpdateCheck(EnablePOSTRetries(context.Background()), check)
+	require.NoError(t, err)
+	require.Len(t, bodies, 2)
+	require.NotEmpty(t, bodies[0])
+	require.Equal(t, bodies[0], bodies[1])
+}
+
+func TestRetriesReportAttempts(t *testing.T) {
+	url, mux, cleanup := newTestServer(t)
+	defer cleanup()
+
+	mux.Handle("/api/v1/probe/list", http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		errorResponse(w, http.StatusServiceUnavailable, "try again later")
+	}))
+
+	c, err := New(url, WithAccessToken("token"), WithRetryPolicy(RetryPolicy{MaxAttempts: 3, MinBackoff: time.Millisecond}))
+	require.NoError(t, err)
+
+	_, err = c.ListProbes(context.Background())
+	require.Error(t, err)
+
+	var httpErr *HTTPError
+	require.ErrorAs(t, err, &httpErr)
+	require.Equal(t, 3, httpErr.Attempts)
+	require.Contains(t, err.Error(), "attempts=3")
+
+	// Transport errors report the number of attempts, too.
+	c, err = New("http://127.0.0.1:1", WithAccessToken("token"), WithRetryPolicy(RetryPolicy{MaxAttempts: 2, MinBackoff: time.Millisecond}))
+	require.NoError(t, err)
+
+	_, err = c.ListProbes(context.Background())
+	require.Error(t, err)
+	require.Contains(t, err.Error(), "2 attempts")
+}
+
+func TestRetryPolicyJitter(t *testing.T) {
+	p := RetryPolicy{MinBackoff: time.Second, MaxBackoff: time.Second, Jitter: 0.5}
+
+	for range 100 {
+		d := p.backoff(1)
+		require.GreaterOrEqual(t, d, 500*time.Millisecond)
+		require.LessOrEqual(t, d, time.Second)
+	}
+}
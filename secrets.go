@@ -0,0 +1,209 @@
+package smapi
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"path"
+	"strings"
+
+	"github.com/grafana/synthetic-monitoring-api-go-client/model"
+)
+
+// secretsURL returns the base URL of the secrets API given the already
+// adjusted base URL u for the rest of the API.
+//
+// The secrets API is versioned separately ("/api/v1alpha1"). When using
+// a datasource proxy, the "/v1" suffix, if any, is replaced; otherwise
+// the proxy URL is used as is.
+func secretsURL(u url.URL, datasourceProxy bool) string {
+	switch {
+	case !datasourceProxy:
+		u.Path = path.Join(path.Dir(u.Path), "v1alpha1")
+
+	case strings.HasSuffix(u.Path, "/v1"):
+		u.Path = strings.TrimSuffix(u.Path, "/v1") + "/v1alpha1"
+	}
+
+	return u.String()
+}
+
+// ListSecrets returns the list of secrets for the authenticated tenant.
+// The values of the secrets are not included.
+func (h *Client) ListSecrets(ctx context.Context) ([]model.Secret, error) {
+	if err := h.requireAuthToken(); err != nil {
+		return nil, err
+	}
+
+	var result model.SecretListResponse
+
+	op := Operation{
+		Name:   "secret list request",
+		Method: http.MethodGet,
+		Path:   "/secrets",
+		Result: &result,
+	}
+
+	err := h.invoke(ctx, &op, func(ctx context.Context) error {
+		resp, err := h.secretsRequest(ctx, op.Method, op.Path, nil)
+		if err != nil {
+			return fmt.Errorf("sending secret list request: %w", err)
+		}
+
+		return ValidateResponse(op.Name, resp, &result)
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return result.Secrets, nil
+}
+
+// GetSecret returns the secret identified by id. The value of the secret
+// is not included.
+func (h *Client) GetSecret(ctx context.Context, id string) (*model.Secret, error) {
+	if err := h.requireAuthToken(); err != nil {
+		return nil, err
+	}
+
+	var result model.Secret
+
+	op := Operation{
+		Name:   "secret get request",
+		Method: http.MethodGet,
+		Path:   "/secrets/" + url.PathEscape(id),
+		Result: &result,
+	}
+
+	err := h.invoke(ctx, &op, func(ctx context.Context) error {
+		resp, err := h.secretsRequest(ctx, op.Method, op.Path, nil)
+		if err != nil {
+			return fmt.Errorf("sending secret get request: %w", err)
+		}
+
+		return ValidateResponse(op.Name, resp, &result)
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return &result, nil
+}
+
+// AddSecret creates a new secret. The secret's Plaintext field holds
+// the value to store.
+//
+// The return value contains the assigned UUID, without the value.
+func (h *Client) AddSecret(ctx context.Context, secret model.Secret) (*model.Secret, error) {
+	if err := h.requireAuthToken(); err != nil {
+		return nil, err
+	}
+
+	var result model.Secret
+
+	op := Operation{
+		Name:    "secret add request",
+		Method:  http.MethodPost,
+		Path:    "/secrets",
+		Request: &secret,
+		Result:  &result,
+	}
+
+	err := h.invoke(ctx, &op, func(ctx context.Context) error {
+		resp, err := h.secretsRequest(ctx, op.Method, op.Path, &secret)
+		if err != nil {
+			return fmt.Errorf("sending secret add request: %w", err)
+		}
+
+		return ValidateResponse(op.Name, resp, &result)
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return &result, nil
+}
+
+// UpdateSecret updates the secret identified by secret.UUID. If the
+// secret's Plaintext field is empty, the stored value is not changed.
+//
+// The return value contains the updated secret, without the value.
+func (h *Client) UpdateSecret(ctx context.Context, secret model.Secret) (*model.Secret, error) {
+	if err := h.requireAuthToken(); err != nil {
+		return nil, err
+	}
+
+	var result model.Secret
+
+	op := Operation{
+		Name:    "secret update request",
+		Method:  http.MethodPut,
+		Path:    "/secrets/" + url.PathEscape(secret.UUID),
+		Request: &secret,
+		Result:  &result,
+	}
+
+	err := h.invoke(ctx, &op, func(ctx context.Context) error {
+		resp, err := h.secretsRequest(ctx, op.Method, op.Path, &secret)
+		if err != nil {
+			return fmt.Errorf("sending secret update request: %w", err)
+		}
+
+		return ValidateResponse(op.Name, resp, &result)
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return &result, nil
+}
+
+// DeleteSecret deletes the secret identified by id.
+func (h *Client) DeleteSecret(ctx context.Context, id string) error {
+	if err := h.requireAuthToken(); err != nil {
+		return err
+	}
+
+	var result struct{}
+
+	op := Operation{
+		Name:   "secret delete request",
+		Method: http.MethodDelete,
+		Path:   "/secrets/" + url.PathEscape(id),
+		Result: &result,
+	}
+
+	return h.invoke(ctx, &op, func(ctx context.Context) error {
+		resp, err := h.secretsRequest(ctx, op.Method, op.Path, nil)
+		if err != nil {
+			return fmt.Errorf("sending secret delete request: %w", err)
+		}
+
+		return validateDeleteResponse(op.Name, resp, &result)
+	})
+}
+
+// secretsRequest sends a request to the secrets API, encoding req as
+// JSON if it's not nil.
+func (h *Client) secretsRequest(ctx context.Context, method, path string, req any) (*http.Response, error) {
+	headers := h.getDefaultHeaders()
+
+	var body io.Reader
+
+	if req != nil {
+		buf, err := json.Marshal(req)
+		if err != nil {
+			return nil, ErrCannotEncodeJSONRequest
+		}
+
+		// Use a bytes.Reader so that the body can be replayed if
+		// the request needs to be retried.
+		body = bytes.NewReader(buf)
+	}
+
+	return h.do(ctx, h.secretsURL+path, method, true, headers, body)
+}
@@ -0,0 +1,247 @@
+package smapi
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/url"
+	"strings"
+	"sync"
+	"testing"
+
+	sm "github.com/grafana/synthetic-monitoring-agent/pkg/pb/synthetic_monitoring"
+	"github.com/grafana/synthetic-monitoring-api-go-client/model"
+	"github.com/stretchr/testify/require"
+)
+
+func TestSecretsURL(t *testing.T) {
+	testcases := map[string]struct {
+		baseURL  string
+		opts     []Option
+		expected string
+	}{
+		"default": {
+			baseURL:  "https://example.org",
+			expected: "https://example.org/api/v1alpha1",
+		},
+		"with path": {
+			baseURL:  "https://example.org/sm/",
+			expected: "https://example.org/sm/api/v1alpha1",
+		},
+		"datasource proxy": {
+			baseURL:  "https://grafana.example.org/api/datasources/proxy/uid/abc/sm/v1",
+			opts:     []Option{WithDatasourceProxy()},
+			expected: "https://grafana.example.org/api/datasources/proxy/uid/abc/sm/v1alpha1",
+		},
+		"datasource proxy without version": {
+			baseURL:  "https://grafana.example.org/api/datasources/proxy/uid/abc/sm",
+			opts:     []Option{WithDatasourceProxy()},
+			expected: "https://grafana.example.org/api/datasources/proxy/uid/abc/sm",
+		},
+		"override": {
+			baseURL:  "https://example.org",
+			opts:     []Option{WithSecretsURL("https://secrets.example.org/v1/")},
+			expected: "https://secrets.example.org/v1",
+		},
+	}
+
+	for name, tc := range testcases {
+		t.Run(name, func(t *testing.T) {
+			c, err := New(tc.baseURL, tc.opts...)
+			require.NoError(t, err)
+			require.Equal(t, tc.expected, c.secretsURL)
+		})
+	}
+}
+
+// secretsStore is an in-memory implementation of the secrets API.
+type secretsStore struct {
+	mu      sync.Mutex
+	secrets map[string]model.Secret
+	values  map[string]string
+	nextID  int
+}
+
+func (s *secretsStore) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if r.Header.Get("Authorization") != "Bearer token" {
+		errorResponse(w, http.StatusUnauthorized, "not authorized")
+		return
+	}
+
+	id, _ := url.PathUnescape(strings.TrimPrefix(r.URL.EscapedPath(), "/api/v1alpha1/secrets"))
+	id = strings.TrimPrefix(id, "/")
+
+	switch {
+	case r.Method == http.MethodGet && id == "":
+		var resp model.SecretListResponse
+		for _, secret := range s.secrets {
+			resp.Secrets = append(resp.Secrets, secret)
+		}
+
+		writeResponse(w, http.StatusOK, resp)
+
+	case r.Method == http.MethodPost && id == "":
+		var secret model.Secret
+		if err := json.NewDecoder(r.Body).Decode(&secret); err != nil {
+			errorResponse(w, http.StatusBadRequest, err.Error())
+			return
+		}
+
+		s.nextID++
+		secret.UUID = "secret/" + strings.Repeat("x", s.nextID)
+		s.values[secret.UUID] = secret.Plaintext
+		secret.Plaintext = ""
+		s.secrets[secret.UUID] = secret
+
+		writeResponse(w, http.StatusCreated, secret)
+
+	case r.Method == http.MethodGet:
+		secret, found := s.secrets[id]
+		if !found {
+			errorResponse(w, http.StatusNotFound, "secret not found")
+			return
+		}
+
+		writeResponse(w, http.StatusOK, secret)
+
+	case r.Method == http.MethodPut:
+		if _, found := s.secrets[id]; !found {
+			errorResponse(w, http.StatusNotFound, "secret not found")
+			return
+		}
+
+		var secret model.Secret
+		if err := json.NewDecoder(r.Body).Decode(&secret); err != nil {
+			errorResponse(w, http.StatusBadRequest, err.Error())
+			return
+		}
+
+		if secret.Plaintext != "" {
+			s.values[id] = secret.Plaintext
+		}
+
+		secret.Plaintext = ""
+		s.secrets[id] = secret
+
+		writeResponse(w, http.StatusOK, secret)
+
+	case r.Method == http.MethodDelete:
+		if _, found := s.secrets[id]; !found {
+			errorResponse(w, http.StatusNotFound, "secret not found")
+			return
+		}
+
+		delete(s.secrets, id)
+		delete(s.values, id)
+
+		w.WriteHeader(http.StatusNoContent)
+
+	default:
+		errorResponse(w, http.StatusMethodNotAllowed, "method not allowed")
+	}
+}
+
+func TestSecrets(t *testing.T) {
+	url, mux, cleanup := newTestServer(t)
+	defer cleanup()
+
+	store := &secretsStore{
+		secrets: make(map[string]model.Secret),
+		values:  make(map[string]string),
+	}
+
+	mux.Handle("/api/v1alpha1/secrets", store)
+	mux.Handle("/api/v1alpha1/secrets/", store)
+
+	c, err := New(url, WithAccessToken("token"))
+	require.NoError(t, err)
+
+	ctx := context.Background()
+
+	secrets, err := c.ListSecrets(ctx)
+	require.NoError(t, err)
+	require.Empty(t, secrets)
+
+	added, err := c.AddSecret(ctx, model.Secret{
+		Name:        "api-password",
+		Description: "password for the API",
+		Labels:      []sm.Label{{Name: "env", Value: "prod"}},
+		Plaintext:   "hunter2",
+	})
+	require.NoError(t, err)
+	require.NotEmpty(t, added.UUID)
+	require.Equal(t, "api-password", added.Name)
+	require.Empty(t, added.Plaintext)
+	require.Equal(t, "hunter2", store.values[added.UUID])
+
+	// The ID contains a slash, which must be escaped.
+	got, err := c.GetSecret(ctx, added.UUID)
+	require.NoError(t, err)
+	require.Equal(t, added, got)
+
+	got.Description = "new description"
+
+	updated, err := c.UpdateSecret(ctx, *got)
+	require.NoError(t, err)
+	require.Equal(t, "new description", updated.Description)
+	require.Equal(t, "hunter2", store.values[added.UUID])
+
+	got.Plaintext = "correct horse"
+
+	_, err = c.UpdateSecret(ctx, *got)
+	require.NoError(t, err)
+	require.Equal(t, "correct horse", store.values[added.UUID])
+
+	secrets, err = c.ListSecrets(ctx)
+	require.NoError(t, err)
+	require.Len(t, secrets, 1)
+
+	require.NoError(t, c.DeleteSecret(ctx, added.UUID))
+
+	_, err = c.GetSecret(ctx, added.UUID)
+	require.ErrorIs(t, err, ErrNotFound)
+
+	err = c.DeleteSecret(ctx, added.UUID)
+	require.ErrorIs(t, err, ErrNotFound)
+}
+
+func TestDeleteSecretResponses(t *testing.T) {
+	testcases := map[string]struct {
+		status int
+		body   string
+		err    bool
+	}{
+		"no content":       {status: http.StatusNoContent},
+		"empty body":       {status: http.StatusOK},
+		"empty object":     {status: http.StatusOK, body: "{}"},
+		"truncated body":   {status: http.StatusOK, body: "{", err: true},
+		"not found":        {status: http.StatusNotFound, body: `{"msg":"secret not found"}`, err: true},
+		"empty error body": {status: http.StatusInternalServerError, err: true},
+	}
+
+	for name, tc := range testcases {
+		t.Run(name, func(t *testing.T) {
+			url, mux, cleanup := newTestServer(t)
+			defer cleanup()
+
+			mux.HandleFunc("/api/v1alpha1/secrets/", func(w http.ResponseWriter, r *http.Request) {
+				w.WriteHeader(tc.status)
+				_, _ = w.Write([]byte(tc.body))
+			})
+
+			c, err := New(url, WithAccessToken("token"))
+			require.NoError(t, err)
+
+			err = c.DeleteSecret(context.Background(), "secret-1")
+			if tc.err {
+				require.Error(t, err)
+				return
+			}
+
+			require.NoError(t, err)
+		})
+	}
+}
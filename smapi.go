@@ -8,10 +8,13 @@ import (
 	"errors"
 	"fmt"
 	"io"
+	"log/slog"
 	"net/http"
 	"net/url"
 	"path"
 	"strings"
+	"sync"
+	"time"
 
 	"github.com/grafana/synthetic-monitoring-api-go-client/model"
 	"github.com/grafana/synthetic-monitoring-api-go-client/version"
@@ -36,6 +39,44 @@ var (
 	// zero is zero. If possible a more specific error should be
 	// used.
 	ErrUnexpectedResponse = errors.New("unexpected response")
+
+	// ErrInvalidBaseURL is the error returned by New if the base URL
+	// does not include a scheme and a host.
+	ErrInvalidBaseURL = errors.New("invalid base URL")
+)
+
+// Errors that HTTPError matches using errors.Is, based on the status code
+// of the response, e.g.:
+//
+//	if errors.Is(err, smapi.ErrNotFound) {
+//		...
+//	}
+var (
+	// ErrValidation matches 400 Bad Request and 422 Unprocessable
+	// Entity responses, i.e. the API server rejected the request as
+	// invalid. The details might be available in
+	// HTTPError.FieldErrors.
+	ErrValidation = errors.New("validation error")
+
+	// ErrUnauthorized matches 401 Unauthorized responses, e.g. the
+	// access token is not valid.
+	ErrUnauthorized = errors.New("unauthorized")
+
+	// ErrForbidden matches 403 Forbidden responses.
+	ErrForbidden = errors.New("forbidden")
+
+	// ErrNotFound matches 404 Not Found responses.
+	ErrNotFound = errors.New("not found")
+
+	// ErrConflict matches 409 Conflict responses, e.g. a check
+	// with the same job and target already exists.
+	ErrConflict = errors.New("conflict")
+
+	// ErrRateLimited matches 429 Too Many Requests responses.
+	ErrRateLimited = errors.New("rate limited")
+
+	// ErrServerError matches 5xx responses.
+	ErrServerError = errors.New("server error")
 )
 
 const (
@@ -46,40 +87,116 @@ const (
 
 // Client is a Synthetic Monitoring API client.
 //
-// It should be initialized using the NewClient function in this package.
+// It should be initialized using the New function in this package.
 type Client struct {
-	client      *http.Client
-	accessToken string
-	baseURL     string
+	client  *http.Client
+	tokens  TokenSource
+	baseURL string
+
+	tokenRefresher TokenRefresher
+	refreshMu      sync.Mutex
+
+	timeout              time.Duration
+	userAgent            string
+	retryPolicy          RetryPolicy
+	limiter              *limiter
+	logger               *slog.Logger
+	transportMiddlewares []TransportMiddleware
+	interceptors         []Interceptor
+	secretsURL           string
+	datasourceProxy      bool
 
 	// custom headers that override defaults
 	customClientID      string
 	customClientVersion string
 }
 
+// New creates a new client for the Synthetic Monitoring API.
+//
+// The baseURL is the URL of the API server, without the "/api/v1"
+// suffix, unless the WithDatasourceProxy option is used.
+//
+// The behavior of the client can be adjusted using options. By default
+// the client uses http.DefaultClient, has no access token, does not
+// retry failed requests and does not impose any timeouts.
+func New(baseURL string, opts ...Option) (*Client, error) {
+	return newClient(baseURL, true, opts...)
+}
+
+// newClient creates a new client. If strict is false, base URLs without a
+// scheme or a host are accepted, as NewClient and NewDatasourceClient have
+// always done.
+func newClient(baseURL string, strict bool, opts ...Option) (*Client, error) {
+	c := &Client{
+		tokens:  &StaticTokenSource{},
+		limiter: &limiter{},
+	}
+
+	for _, opt := range opts {
+		opt(c)
+	}
+
+	u, err := url.Parse(baseURL)
+	if err != nil {
+		return nil, fmt.Errorf("parsing base URL: %w", err)
+	}
+
+	if strict && (u.Scheme == "" || u.Host == "") {
+		return nil, fmt.Errorf("%w: %q", ErrInvalidBaseURL, baseURL)
+	}
+
+	if c.datasourceProxy {
+		u.Path = strings.TrimSuffix(u.Path, "/")
+	} else {
+		u.Path = path.Clean(u.Path + "/api/v1")
+	}
+
+	c.baseURL = u.String()
+
+	if c.secretsURL == "" {
+		c.secretsURL = secretsURL(*u, c.datasourceProxy)
+	}
+
+	if c.client == nil {
+		c.client = http.DefaultClient
+	}
+
+	if len(c.transportMiddlewares) > 0 {
+		client := *c.client
+
+		transport := client.Transport
+		if transport == nil {
+			transport = http.DefaultTransport
+		}
+
+		for i := len(c.transportMiddlewares) - 1; i >= 0; i-- {
+			transport = c.transportMiddlewares[i](transport)
+		}
+
+		client.Transport = transport
+		c.client = &client
+	}
+
+	return c, nil
+}
+
 // NewClient creates a new client for the Synthetic Monitoring API.
 //
 // The accessToken is optional. If it's not specified, it's necessary to
 // use one of the registration calls to obtain one, Install or Init.
 //
 // If no client is provided, http.DefaultClient will be used.
+//
+// NewClient returns nil if baseURL cannot be parsed. Unlike New, it
+// accepts base URLs without a scheme or a host. Use New in order to
+// validate the base URL and to access additional options.
 func NewClient(baseURL, accessToken string, client *http.Client) *Client {
-	if client == nil {
-		client = http.DefaultClient
-	}
-
-	u, err := url.Parse(baseURL + "/api/v1")
+	c, err := newClient(baseURL, false, WithAccessToken(accessToken), WithHTTPClient(client))
 	if err != nil {
 		return nil
 	}
 
-	u.Path = path.Clean(u.Path)
-
-	return &Client{
-		client:      client,
-		accessToken: accessToken,
-		baseURL:     u.String(),
-	}
+	return c
 }
 
 // NewDatasourceClient creates a new client for the Synthetic Monitoring API using a Grafana datasource proxy.
@@ -87,22 +204,18 @@ func NewClient(baseURL, accessToken string, client *http.Client) *Client {
 // The accessToken should be the grafana access token.
 //
 // If no client is provided, http.DefaultClient will be used.
+//
+// NewDatasourceClient returns nil if baseURL cannot be parsed. Unlike New,
+// it accepts base URLs without a scheme or a host. Use New with the
+// WithDatasourceProxy option in order to validate the base URL and to
+// access additional options.
 func NewDatasourceClient(baseURL, accessToken string, client *http.Client) *Client {
-	if client == nil {
-		client = http.DefaultClient
-	}
-
-	u, err := url.Parse(baseURL)
+	c, err := newClient(baseURL, false, WithDatasourceProxy(), WithAccessToken(accessToken), WithHTTPClient(client))
 	if err != nil {
 		return nil
 	}
-	u.Path = strings.TrimSuffix(u.Path, "/")
 
-	return &Client{
-		client:      client,
-		accessToken: accessToken,
-		baseURL:     u.String(),
-	}
+	return c
 }
 
 // SetCustomClientID sets a custom X-Client-ID header value that will
@@ -127,7 +240,8 @@ func (h *Client) SetCustomClientVersion(version string) {
 //
 // The returned RegistrationInstallResponse will contain the access token used
 // to make further calls to the API server. This call will _modify_ the client
-// in order to use that access token.
+// in order to use that access token, as long as the client's TokenSource
+// implements TokenSetter (the default one does).
 func (h *Client) Install(ctx context.Context, stackID, metricsInstanceID, logsInstanceID int64, publisherToken string) (*model.RegistrationInstallResponse, error) {
 	request := model.RegistrationInstallRequest{
 		LogsInstanceID:    logsInstanceID,
@@ -140,23 +254,32 @@ func (h *Client) Install(ctx context.Context, stackID, metricsInstanceID, logsIn
 		return nil, fmt.Errorf("unmarshalling install request: %w", err)
 	}
 
-	body := bytes.NewReader(buf)
-
 	headers := h.getDefaultHeaders()
 	headers.Set("Authorization", "Bearer "+publisherToken)
 
-	resp, err := h.Post(ctx, "/register/install", false, headers, body)
-	if err != nil {
-		return nil, fmt.Errorf("sending install request: %w", err)
+	var result model.RegistrationInstallResponse
+
+	op := Operation{
+		Name:    "registration install request",
+		Method:  http.MethodPost,
+		Path:    "/register/install",
+		Request: &request,
+		Result:  &result,
 	}
 
-	var result model.RegistrationInstallResponse
+	err = h.invoke(ctx, &op, func(ctx context.Context) error {
+		resp, err := h.Post(ctx, op.Path, false, headers, bytes.NewReader(buf))
+		if err != nil {
+			return fmt.Errorf("sending install request: %w", err)
+		}
 
-	if err := ValidateResponse("registration install request", resp, &result); err != nil {
+		return ValidateResponse(op.Name, resp, &result)
+	})
+	if err != nil {
 		return nil, err
 	}
 
-	h.accessToken = result.AccessToken
+	h.setToken(result.AccessToken)
 
 	return &result, nil
 }
@@ -171,14 +294,24 @@ func (h *Client) CreateToken(ctx context.Context) (string, error) {
 		return "", err
 	}
 
-	resp, err := h.PostJSON(ctx, "/token/create", true, nil)
-	if err != nil {
-		return "", fmt.Errorf("creating token: %w", err)
+	var result model.TokenCreateResponse
+
+	op := Operation{
+		Name:   "token create request",
+		Method: http.MethodPost,
+		Path:   "/token/create",
+		Result: &result,
 	}
 
-	var result model.TokenCreateResponse
+	err := h.invoke(ctx, &op, func(ctx context.Context) error {
+		resp, err := h.PostJSON(ctx, op.Path, true, nil)
+		if err != nil {
+			return fmt.Errorf("creating token: %w", err)
+		}
 
-	if err := ValidateResponse("token create request", resp, &result); err != nil {
+		return ValidateResponse(op.Name, resp, &result)
+	})
+	if err != nil {
 		return "", err
 	}
 
@@ -195,39 +328,62 @@ func (h *Client) DeleteToken(ctx context.Context) error {
 		return err
 	}
 
-	resp, err := h.Delete(ctx, fmt.Sprintf("%s%s", h.baseURL, "/token/delete"), true)
-	if err != nil {
-		return fmt.Errorf("deleting token: %w", err)
+	var result model.TokenDeleteResponse
+
+	op := Operation{
+		Name:   "token delete request",
+		Method: http.MethodDelete,
+		Path:   "/token/delete",
+		Result: &result,
 	}
 
-	var result model.TokenDeleteResponse
+	err := h.invoke(ctx, &op, func(ctx context.Context) error {
+		resp, err := h.Delete(ctx, h.baseURL+op.Path, true)
+		if err != nil {
+			return fmt.Errorf("deleting token: %w", err)
+		}
 
-	if err := ValidateResponse("token delete request", resp, &result); err != nil {
+		return validateDeleteResponse(op.Name, resp, &result)
+	})
+	if err != nil {
 		return err
 	}
 
 	// the token is no longer valid, remove it from the client so
 	// that future calls fail.
-	h.accessToken = ""
+	h.setToken("")
 
 	return nil
 }
 
 // RefreshToken creates a new access token in the API which replaces the
 // one that the client is currently using.
+//
+// Like Install, this only changes the token used by the client if its
+// TokenSource implements TokenSetter.
 func (h *Client) RefreshToken(ctx context.Context) error {
 	if err := h.requireAuthToken(); err != nil {
 		return err
 	}
 
-	resp, err := h.PostJSON(ctx, "/token/refresh", true, nil)
-	if err != nil {
-		return fmt.Errorf("refreshing token: %w", err)
+	var result model.TokenRefreshResponse
+
+	op := Operation{
+		Name:   "token refresh request",
+		Method: http.MethodPost,
+		Path:   "/token/refresh",
+		Result: &result,
 	}
 
-	var result model.TokenRefreshResponse
+	err := h.invoke(ctx, &op, func(ctx context.Context) error {
+		resp, err := h.PostJSON(ctx, op.Path, true, nil)
+		if err != nil {
+			return fmt.Errorf("refreshing token: %w", err)
+		}
 
-	if err := ValidateResponse("token refresh request", resp, &result); err != nil {
+		return ValidateResponse(op.Name, resp, &result)
+	})
+	if err != nil {
 		return err
 	}
 
@@ -236,7 +392,7 @@ func (h *Client) RefreshToken(ctx context.Context) error {
 	}
 
 	// replace the existing (now invalid) token with the new one
-	h.accessToken = result.AccessToken
+	h.setToken(result.AccessToken)
 
 	return nil
 }
@@ -249,14 +405,24 @@ func (h *Client) ValidateToken(ctx context.Context) error {
 		return err
 	}
 
-	resp, err := h.PostJSON(ctx, "/token/validate", true, nil)
-	if err != nil {
-		return fmt.Errorf("validating token: %w", err)
+	var result model.TokenValidateResponse
+
+	op := Operation{
+		Name:   "token validate request",
+		Method: http.MethodPost,
+		Path:   "/token/validate",
+		Result: &result,
 	}
 
-	var result model.TokenValidateResponse
+	err := h.invoke(ctx, &op, func(ctx context.Context) error {
+		resp, err := h.PostJSON(ctx, op.Path, true, nil)
+		if err != nil {
+			return fmt.Errorf("validating token: %w", err)
+		}
 
-	if err := ValidateResponse("token validate request", resp, &result); err != nil {
+		return ValidateResponse(op.Name, resp, &result)
+	})
+	if err != nil {
 		return err
 	}
 
@@ -277,14 +443,25 @@ func (h *Client) AddProbe(ctx context.Context, probe synthetic_monitoring.Probe)
 		return nil, nil, err
 	}
 
-	resp, err := h.PostJSON(ctx, "/probe/add", true, &probe)
-	if err != nil {
-		return nil, nil, fmt.Errorf("adding probe: %w", err)
+	var result model.ProbeAddResponse
+
+	op := Operation{
+		Name:    "probe add request",
+		Method:  http.MethodPost,
+		Path:    "/probe/add",
+		Request: &probe,
+		Result:  &result,
 	}
 
-	var result model.ProbeAddResponse
+	err := h.invoke(ctx, &op, func(ctx context.Context) error {
+		resp, err := h.PostJSON(ctx, op.Path, true, &probe)
+		if err != nil {
+			return fmt.Errorf("adding probe: %w", err)
+		}
 
-	if err := ValidateResponse("probe add request", resp, &result); err != nil {
+		return ValidateResponse(op.Name, resp, &result)
+	})
+	if err != nil {
 		return nil, nil, err
 	}
 
@@ -297,14 +474,25 @@ func (h *Client) DeleteProbe(ctx context.Context, id int64) error {
 		return err
 	}
 
-	resp, err := h.Delete(ctx, fmt.Sprintf("%s%s/%d", h.baseURL, "/probe/delete", id), true)
-	if err != nil {
-		return fmt.Errorf("sending probe delete request: %w", err)
+	var result model.ProbeDeleteResponse
+
+	op := Operation{
+		Name:    "probe delete request",
+		Method:  http.MethodDelete,
+		Path:    fmt.Sprintf("/probe/delete/%d", id),
+		ProbeID: id,
+		Result:  &result,
 	}
 
-	var result model.ProbeDeleteResponse
+	err := h.invoke(ctx, &op, func(ctx context.Context) error {
+		resp, err := h.Delete(ctx, h.baseURL+op.Path, true)
+		if err != nil {
+			return fmt.Errorf("sending probe delete request: %w", err)
+		}
 
-	if err := ValidateResponse("probe delete request", resp, &result); err != nil {
+		return validateDeleteResponse(op.Name, resp, &result)
+	})
+	if err != nil {
 		return err
 	}
 
@@ -321,14 +509,26 @@ func (h *Client) UpdateProbe(ctx context.Context, probe synthetic_monitoring.Pro
 		return nil, err
 	}
 
-	resp, err := h.PostJSON(ctx, "/probe/update", true, &probe)
-	if err != nil {
-		return nil, fmt.Errorf("sending probe update request: %w", err)
+	var result model.ProbeUpdateResponse
+
+	op := Operation{
+		Name:    "probe update request",
+		Method:  http.MethodPost,
+		Path:    "/probe/update",
+		ProbeID: probe.Id,
+		Request: &probe,
+		Result:  &result,
 	}
 
-	var result model.ProbeUpdateResponse
+	err := h.invoke(ctx, &op, func(ctx context.Context) error {
+		resp, err := h.PostJSON(ctx, op.Path, true, &probe)
+		if err != nil {
+			return fmt.Errorf("sending probe update request: %w", err)
+		}
 
-	if err := ValidateResponse("probe update request", resp, &result); err != nil {
+		return ValidateResponse(op.Name, resp, &result)
+	})
+	if err != nil {
 		return nil, err
 	}
 
@@ -341,14 +541,26 @@ func (h *Client) ResetProbeToken(ctx context.Context, probe synthetic_monitoring
 		return nil, nil, err
 	}
 
-	resp, err := h.PostJSON(ctx, "/probe/update?reset-token", true, &probe)
-	if err != nil {
-		return nil, nil, fmt.Errorf("sending probe update request: %w", err)
+	var result model.ProbeUpdateResponse
+
+	op := Operation{
+		Name:    "probe update request",
+		Method:  http.MethodPost,
+		Path:    "/probe/update?reset-token",
+		ProbeID: probe.Id,
+		Request: &probe,
+		Result:  &result,
 	}
 
-	var result model.ProbeUpdateResponse
+	err := h.invoke(ctx, &op, func(ctx context.Context) error {
+		resp, err := h.PostJSON(ctx, op.Path, true, &probe)
+		if err != nil {
+			return fmt.Errorf("sending probe update request: %w", err)
+		}
 
-	if err := ValidateResponse("probe update request", resp, &result); err != nil {
+		return ValidateResponse(op.Name, resp, &result)
+	})
+	if err != nil {
 		return nil, nil, err
 	}
 
@@ -362,14 +574,25 @@ func (h *Client) GetProbe(ctx context.Context, id int64) (*synthetic_monitoring.
 		return nil, err
 	}
 
-	resp, err := h.Get(ctx, fmt.Sprintf("/probe/%d", id), true, nil)
-	if err != nil {
-		return nil, fmt.Errorf("sending probe get request: %w", err)
+	var result synthetic_monitoring.Probe
+
+	op := Operation{
+		Name:    "probe get request",
+		Method:  http.MethodGet,
+		Path:    fmt.Sprintf("/probe/%d", id),
+		ProbeID: id,
+		Result:  &result,
 	}
 
-	var result synthetic_monitoring.Probe
+	err := h.invoke(ctx, &op, func(ctx context.Context) error {
+		resp, err := h.Get(ctx, op.Path, true, nil)
+		if err != nil {
+			return fmt.Errorf("sending probe get request: %w", err)
+		}
 
-	if err := ValidateResponse("probe get request", resp, &result); err != nil {
+		return ValidateResponse(op.Name, resp, &result)
+	})
+	if err != nil {
 		return nil, err
 	}
 
@@ -383,14 +606,24 @@ func (h *Client) ListProbes(ctx context.Context) ([]synthetic_monitoring.Probe,
 		return nil, err
 	}
 
-	resp, err := h.Get(ctx, "/probe/list", true, nil)
-	if err != nil {
-		return nil, fmt.Errorf("sending probe list request: %w", err)
+	var result []synthetic_monitoring.Probe
+
+	op := Operation{
+		Name:   "probe list request",
+		Method: http.MethodGet,
+		Path:   "/probe/list",
+		Result: &result,
 	}
 
-	var result []synthetic_monitoring.Probe
+	err := h.invoke(ctx, &op, func(ctx context.Context) error {
+		resp, err := h.Get(ctx, op.Path, true, nil)
+		if err != nil {
+			return fmt.Errorf("sending probe list request: %w", err)
+		}
 
-	if err := ValidateResponse("probe list request", resp, &result); err != nil {
+		return ValidateResponse(op.Name, resp, &result)
+	})
+	if err != nil {
 		return nil, err
 	}
 
@@ -405,14 +638,25 @@ func (h *Client) AddCheck(ctx context.Context, check model.Check) (*model.Check,
 		return nil, err
 	}
 
-	resp, err := h.PostJSON(ctx, "/check/add", true, &check)
-	if err != nil {
-		return nil, fmt.Errorf("sending check add request: %w", err)
+	var result model.Check
+
+	op := Operation{
+		Name:    "check add request",
+		Method:  http.MethodPost,
+		Path:    "/check/add",
+		Request: &check,
+		Result:  &result,
 	}
 
-	var result model.Check
+	err := h.invoke(ctx, &op, func(ctx context.Context) error {
+		resp, err := h.PostJSON(ctx, op.Path, true, &check)
+		if err != nil {
+			return fmt.Errorf("sending check add request: %w", err)
+		}
 
-	if err := ValidateResponse("check add request", resp, &result); err != nil {
+		return ValidateResponse(op.Name, resp, &result)
+	})
+	if err != nil {
 		return nil, err
 	}
 
@@ -426,14 +670,25 @@ func (h *Client) GetCheck(ctx context.Context, id int64) (*model.Check, error) {
 		return nil, err
 	}
 
-	resp, err := h.Get(ctx, fmt.Sprintf("/check/%d", id), true, nil)
-	if err != nil {
-		return nil, fmt.Errorf("sending check get request: %w", err)
+	var result model.Check
+
+	op := Operation{
+		Name:    "check get request",
+		Method:  http.MethodGet,
+		Path:    fmt.Sprintf("/check/%d", id),
+		CheckID: id,
+		Result:  &result,
 	}
 
-	var result model.Check
+	err := h.invoke(ctx, &op, func(ctx context.Context) error {
+		resp, err := h.Get(ctx, op.Path, true, nil)
+		if err != nil {
+			return fmt.Errorf("sending check get request: %w", err)
+		}
 
-	if err := ValidateResponse("check get request", resp, &result); err != nil {
+		return ValidateResponse(op.Name, resp, &result)
+	})
+	if err != nil {
 		return nil, err
 	}
 
@@ -449,14 +704,26 @@ func (h *Client) UpdateCheck(ctx context.Context, check model.Check) (*model.Che
 		return nil, err
 	}
 
-	resp, err := h.PostJSON(ctx, "/check/update", true, &check)
-	if err != nil {
-		return nil, fmt.Errorf("sending check update request: %w", err)
+	var result model.Check
+
+	op := Operation{
+		Name:    "check update request",
+		Method:  http.MethodPost,
+		Path:    "/check/update",
+		CheckID: check.Id,
+		Request: &check,
+		Result:  &result,
 	}
 
-	var result model.Check
+	err := h.invoke(ctx, &op, func(ctx context.Context) error {
+		resp, err := h.PostJSON(ctx, op.Path, true, &check)
+		if err != nil {
+			return fmt.Errorf("sending check update request: %w", err)
+		}
 
-	if err := ValidateResponse("check update request", resp, &result); err != nil {
+		return ValidateResponse(op.Name, resp, &result)
+	})
+	if err != nil {
 		return nil, err
 	}
 
@@ -470,14 +737,25 @@ func (h *Client) DeleteCheck(ctx context.Context, id int64) error {
 		return err
 	}
 
-	resp, err := h.Delete(ctx, fmt.Sprintf("%s%s/%d", h.baseURL, "/check/delete", id), true)
-	if err != nil {
-		return fmt.Errorf("sending check delete request: %w", err)
+	var result model.CheckDeleteResponse
+
+	op := Operation{
+		Name:    "check delete request",
+		Method:  http.MethodDelete,
+		Path:    fmt.Sprintf("/check/delete/%d", id),
+		CheckID: id,
+		Result:  &result,
 	}
 
-	var result model.CheckDeleteResponse
+	err := h.invoke(ctx, &op, func(ctx context.Context) error {
+		resp, err := h.Delete(ctx, h.baseURL+op.Path, true)
+		if err != nil {
+			return fmt.Errorf("sending check delete request: %w", err)
+		}
 
-	if err := ValidateResponse("check delete request", resp, &result); err != nil {
+		return validateDeleteResponse(op.Name, resp, &result)
+	})
+	if err != nil {
 		return err
 	}
 
@@ -491,14 +769,24 @@ func (h *Client) ListChecks(ctx context.Context) ([]model.Check, error) {
 		return nil, err
 	}
 
-	resp, err := h.Get(ctx, "/check/list", true, nil)
-	if err != nil {
-		return nil, fmt.Errorf("sending check list request: %w", err)
+	var result []model.Check
+
+	op := Operation{
+		Name:   "check list request",
+		Method: http.MethodGet,
+		Path:   "/check/list",
+		Result: &result,
 	}
 
-	var result []model.Check
+	err := h.invoke(ctx, &op, func(ctx context.Context) error {
+		resp, err := h.Get(ctx, op.Path, true, nil)
+		if err != nil {
+			return fmt.Errorf("sending check list request: %w", err)
+		}
 
-	if err := ValidateResponse("check list request", resp, &result); err != nil {
+		return ValidateResponse(op.Name, resp, &result)
+	})
+	if err != nil {
 		return nil, err
 	}
 
@@ -512,14 +800,24 @@ func (h *Client) ListChecksWithAlerts(ctx context.Context) ([]model.CheckWithAle
 		return nil, err
 	}
 
-	resp, err := h.Get(ctx, "/check/list?includeAlerts=true", true, nil)
-	if err != nil {
-		return nil, fmt.Errorf("sending check list request: %w", err)
+	var result []model.CheckWithAlerts
+
+	op := Operation{
+		Name:   "check list request",
+		Method: http.MethodGet,
+		Path:   "/check/list?includeAlerts=true",
+		Result: &result,
 	}
 
-	var result []model.CheckWithAlerts
+	err := h.invoke(ctx, &op, func(ctx context.Context) error {
+		resp, err := h.Get(ctx, op.Path, true, nil)
+		if err != nil {
+			return fmt.Errorf("sending check list request: %w", err)
+		}
 
-	if err := ValidateResponse("check list request", resp, &result); err != nil {
+		return ValidateResponse(op.Name, resp, &result)
+	})
+	if err != nil {
 		return nil, err
 	}
 
@@ -537,16 +835,33 @@ func (h *Client) QueryCheck(ctx context.Context, job string, target string) (*mo
 		return nil, err
 	}
 
-	resp, err := h.Get(ctx, fmt.Sprintf("/check/query?job=%s&target=%s", job, target), true, nil)
-	if err != nil {
-		return nil, fmt.Errorf("check query err: %w", err)
+	var result *model.Check
+
+	query := url.Values{"job": {job}, "target": {target}}
+
+	op := Operation{
+		Name:   "check query request",
+		Method: http.MethodGet,
+		Path:   "/check/query?" + query.Encode(),
+		Result: &result,
 	}
 
-	var result *model.Check
+	err := h.invoke(ctx, &op, func(ctx context.Context) error {
+		resp, err := h.Get(ctx, op.Path, true, nil)
+		if err != nil {
+			return fmt.Errorf("check query err: %w", err)
+		}
 
-	if err := ValidateResponse("check query request", resp, &result); err != nil {
+		return ValidateResponse(op.Name, resp, &result)
+	})
+	if err != nil {
 		return nil, err
 	}
+
+	if result == nil {
+		return nil, fmt.Errorf("%s: empty response: %w", op.Name, ErrUnexpectedResponse)
+	}
+
 	return result, nil
 }
 
@@ -557,14 +872,24 @@ func (h *Client) GetTenant(ctx context.Context) (*synthetic_monitoring.Tenant, e
 		return nil, err
 	}
 
-	resp, err := h.Get(ctx, "/tenant", true, nil)
-	if err != nil {
-		return nil, fmt.Errorf("sending get tenant request: %w", err)
+	var result synthetic_monitoring.Tenant
+
+	op := Operation{
+		Name:   "get tenant request",
+		Method: http.MethodGet,
+		Path:   "/tenant",
+		Result: &result,
 	}
 
-	var result synthetic_monitoring.Tenant
+	err := h.invoke(ctx, &op, func(ctx context.Context) error {
+		resp, err := h.Get(ctx, op.Path, true, nil)
+		if err != nil {
+			return fmt.Errorf("sending get tenant request: %w", err)
+		}
 
-	if err := ValidateResponse("get tenant request", resp, &result); err != nil {
+		return ValidateResponse(op.Name, resp, &result)
+	})
+	if err != nil {
 		return nil, err
 	}
 
@@ -579,14 +904,25 @@ func (h *Client) UpdateTenant(ctx context.Context, tenant synthetic_monitoring.T
 		return nil, err
 	}
 
-	resp, err := h.PostJSON(ctx, "/tenant/update", true, &tenant)
-	if err != nil {
-		return nil, fmt.Errorf("sending tenant update request: %w", err)
+	var result synthetic_monitoring.Tenant
+
+	op := Operation{
+		Name:    "tenant update request",
+		Method:  http.MethodPost,
+		Path:    "/tenant/update",
+		Request: &tenant,
+		Result:  &result,
 	}
 
-	var result synthetic_monitoring.Tenant
+	err := h.invoke(ctx, &op, func(ctx context.Context) error {
+		resp, err := h.PostJSON(ctx, op.Path, true, &tenant)
+		if err != nil {
+			return fmt.Errorf("sending tenant update request: %w", err)
+		}
 
-	if err := ValidateResponse("tenant update request", resp, &result); err != nil {
+		return ValidateResponse(op.Name, resp, &result)
+	})
+	if err != nil {
 		return nil, err
 	}
 
@@ -594,13 +930,46 @@ func (h *Client) UpdateTenant(ctx context.Context, tenant synthetic_monitoring.T
 }
 
 func (h *Client) requireAuthToken() error {
-	if h.accessToken == "" {
+	switch tokens := h.tokens.(type) {
+	case nil:
 		return ErrAuthorizationTokenRequired
+
+	case *StaticTokenSource:
+		if tokens.get() == "" {
+			return ErrAuthorizationTokenRequired
+		}
 	}
 
+	// Other token sources are consulted when the request is sent.
 	return nil
 }
 
+// token returns the access token that should be used for a request.
+func (h *Client) token(ctx context.Context) (string, error) {
+	if h.tokens == nil {
+		return "", ErrAuthorizationTokenRequired
+	}
+
+	token, err := h.tokens.Token(ctx)
+	if err != nil {
+		return "", fmt.Errorf("obtaining access token: %w", err)
+	}
+
+	if token == "" {
+		return "", ErrAuthorizationTokenRequired
+	}
+
+	return token, nil
+}
+
+// setToken replaces the access token used by the client, if the token
+// source allows it.
+func (h *Client) setToken(token string) {
+	if tokens, ok := h.tokens.(TokenSetter); ok {
+		tokens.SetToken(token)
+	}
+}
+
 func (h *Client) do(ctx context.Context, url, method string, auth bool, headers http.Header, body io.Reader) (*http.Response, error) {
 	req, err := http.NewRequestWithContext(ctx, method, url, body)
 	if err != nil {
@@ -611,21 +980,172 @@ func (h *Client) do(ctx context.Context, url, method string, auth bool, headers
 		req.Header = headers
 	}
 
+	if h.userAgent != "" {
+		req.Header.Set("User-Agent", h.userAgent)
+	}
+
+	op := operationFromContext(req.Context())
+	if op != nil {
+		for name, values := range op.Header {
+			req.Header[http.CanonicalHeaderKey(name)] = values
+		}
+	}
+
+	var token string
+
 	if auth {
-		if req.Header == nil {
-			req.Header = make(http.Header)
+		token, err = h.token(req.Context())
+		if err != nil {
+			return nil, err
 		}
-		req.Header.Set("Authorization", "Bearer "+h.accessToken)
+
+		req.Header.Set("Authorization", "Bearer "+token)
+	}
+
+	ctx, cancel := h.withTimeout(req.Context())
+	req = req.WithContext(ctx)
+
+	start := time.Now()
+
+	resp, err := h.send(req)
+	if err == nil && auth && resp.StatusCode == http.StatusUnauthorized && h.canRefreshToken(req) {
+		resp, err = h.refreshTokenAndResend(req, resp, token)
 	}
 
-	resp, err := h.client.Do(req)
+	h.logExchange(req, resp, err, time.Since(start))
+
 	if err != nil {
-		return nil, fmt.Errorf("sending HTTP request: %w", err)
+		cancel()
+
+		return nil, err
 	}
 
+	if op != nil {
+		op.StatusCode = resp.StatusCode
+	}
+
+	// The timeout must cover reading the body, too, so the context
+	// is canceled once the caller is done with the response.
+	resp.Body = &closeNotifier{ReadCloser: resp.Body, onClose: cancel}
+
 	return resp, nil
 }
 
+// send sends the request, retrying it according to the client's retry
+// policy.
+func (h *Client) send(req *http.Request) (*http.Response, error) {
+	maxAttempts := h.retryPolicy.maxAttempts(req)
+
+	ctx, attempts := withAttemptsCounter(req.Context())
+	req = req.WithContext(ctx)
+
+	op := operationFromContext(ctx)
+
+	for {
+		*attempts++
+
+		if op != nil {
+			op.Attempts++
+		}
+
+		release, err := h.limiter.acquire(ctx)
+		if err != nil {
+			return nil, err
+		}
+
+		resp, err := h.client.Do(req)
+
+		if *attempts >= maxAttempts || !shouldRetry(resp, err) || ctx.Err() != nil {
+			return sendResult(resp, err, *attempts, release)
+		}
+
+		delay := h.retryPolicy.delay(*attempts, resp)
+		if !canWait(ctx, delay) {
+			// There's no point in waiting if the deadline is going
+			// to expire before the next attempt.
+			return sendResult(resp, err, *attempts, release)
+		}
+
+		discardResponse(resp)
+		release()
+
+		h.getLogger().DebugContext(ctx, "retrying request",
+			"method", req.Method,
+			"path", req.URL.Path,
+			"attempt", *attempts,
+			"delay", delay,
+			"error", err,
+		)
+
+		if err := sleep(ctx, delay); err != nil {
+			return nil, fmt.Errorf("sending HTTP request (%d attempts): %w", *attempts, err)
+		}
+
+		next := req.Clone(ctx)
+		if req.GetBody != nil {
+			body, err := req.GetBody()
+			if err != nil {
+				return nil, fmt.Errorf("replaying HTTP request body: %w", err)
+			}
+
+			next.Body = body
+		}
+
+		req = next
+	}
+}
+
+// sendResult returns the result of the last attempt to send a request.
+// release is called once the response is no longer in use.
+func sendResult(resp *http.Response, err error, attempts int, release func()) (*http.Response, error) {
+	if err == nil {
+		resp.Body = &closeNotifier{ReadCloser: resp.Body, onClose: release}
+
+		return resp, nil
+	}
+
+	release()
+
+	if attempts > 1 {
+		return nil, fmt.Errorf("sending HTTP request (%d attempts): %w", attempts, err)
+	}
+
+	return nil, fmt.Errorf("sending HTTP request: %w", err)
+}
+
+// withTimeout applies the client's default timeout to ctx, unless it
+// already has a deadline.
+func (h *Client) withTimeout(ctx context.Context) (context.Context, context.CancelFunc) {
+	if _, found := ctx.Deadline(); found || h.timeout <= 0 {
+		return ctx, func() {}
+	}
+
+	return context.WithTimeout(ctx, h.timeout)
+}
+
+func (h *Client) getLogger() *slog.Logger {
+	if h.logger == nil {
+		return slog.New(slog.DiscardHandler)
+	}
+
+	return h.logger
+}
+
+// closeNotifier calls onClose once the wrapped ReadCloser is closed.
+type closeNotifier struct {
+	io.ReadCloser
+	once    sync.Once
+	onClose func()
+}
+
+func (r *closeNotifier) Close() error {
+	err := r.ReadCloser.Close()
+
+	r.once.Do(r.onClose)
+
+	return err
+}
+
 // Get is a utility method to send a GET request to the SM API.
 //
 // The `url` argument specifies the additional URL path of the request (minus
@@ -673,7 +1193,9 @@ func (h *Client) PostJSON(ctx context.Context, url string, auth bool, req interf
 		}
 	}
 
-	return h.Post(ctx, url, auth, headers, &body)
+	// Use a bytes.Reader so that the body can be replayed if the
+	// request needs to be retried.
+	return h.Post(ctx, url, auth, headers, bytes.NewReader(body.Bytes()))
 }
 
 // Delete is a utility method to send a DELETE request to the SM API.
@@ -726,7 +1248,9 @@ func (h *Client) PutJSON(ctx context.Context, url string, auth bool, req interfa
 		}
 	}
 
-	return h.Put(ctx, url, auth, headers, &body)
+	// Use a bytes.Reader so that the body can be replayed if the
+	// request needs to be retried.
+	return h.Put(ctx, url, auth, headers, bytes.NewReader(body.Bytes()))
 }
 
 func (h *Client) UpdateCheckAlerts(ctx context.Context, checkID int64, alerts []model.CheckAlert) ([]model.CheckAlert, error) {
@@ -740,15 +1264,28 @@ func (h *Client) UpdateCheckAlerts(ctx context.Context, checkID int64, alerts []
 		Alerts: alerts,
 	}
 
-	resp, err := h.PutJSON(ctx, fmt.Sprintf("/check/%d/alerts", checkID), true, &request)
-	if err != nil {
-		return nil, fmt.Errorf("sending check alerts update request: %w", err)
-	}
-
 	var result struct {
 		Alerts []model.CheckAlert `json:"alerts"`
 	}
-	if err := ValidateResponse("check alerts update request", resp, &result); err != nil {
+
+	op := Operation{
+		Name:    "check alerts update request",
+		Method:  http.MethodPut,
+		Path:    fmt.Sprintf("/check/%d/alerts", checkID),
+		CheckID: checkID,
+		Request: &request,
+		Result:  &result,
+	}
+
+	err := h.invoke(ctx, &op, func(ctx context.Context) error {
+		resp, err := h.PutJSON(ctx, op.Path, true, &request)
+		if err != nil {
+			return fmt.Errorf("sending check alerts update request: %w", err)
+		}
+
+		return ValidateResponse(op.Name, resp, &result)
+	})
+	if err != nil {
 		return nil, err
 	}
 
@@ -760,15 +1297,27 @@ func (h *Client) GetCheckAlerts(ctx context.Context, checkID int64) ([]model.Che
 		return nil, err
 	}
 
-	resp, err := h.Get(ctx, fmt.Sprintf("/check/%d/alerts", checkID), true, nil)
-	if err != nil {
-		return nil, fmt.Errorf("sending check alerts get request: %w", err)
-	}
-
 	var result struct {
 		Alerts []model.CheckAlertWithStatus `json:"alerts"`
 	}
-	if err := ValidateResponse("check alerts get request", resp, &result); err != nil {
+
+	op := Operation{
+		Name:    "check alerts get request",
+		Method:  http.MethodGet,
+		Path:    fmt.Sprintf("/check/%d/alerts", checkID),
+		CheckID: checkID,
+		Result:  &result,
+	}
+
+	err := h.invoke(ctx, &op, func(ctx context.Context) error {
+		resp, err := h.Get(ctx, op.Path, true, nil)
+		if err != nil {
+			return fmt.Errorf("sending check alerts get request: %w", err)
+		}
+
+		return ValidateResponse(op.Name, resp, &result)
+	})
+	if err != nil {
 		return nil, err
 	}
 
@@ -788,19 +1337,93 @@ type HTTPError struct {
 		Msg   string
 		Error string
 	}
+
+	// Attempts is the number of attempts that were made before
+	// giving up, if the request was retried.
+	Attempts int
+
+	// RequestID is the ID assigned to the request by the API server
+	// or a proxy in front of it, if available. It's useful when
+	// reporting problems.
+	RequestID string
+
+	// FieldErrors contains the per-field validation errors reported
+	// by the API server, if any.
+	FieldErrors []FieldError
+}
+
+// FieldError describes a problem with a single field of a request.
+type FieldError struct {
+	Field string `json:"field"`
+	Msg   string `json:"msg"`
 }
 
+// requestIDHeaders lists the headers that might carry the ID of a
+// request, in order of preference.
+var requestIDHeaders = []string{"X-Request-Id", "X-Correlation-Id"}
+
 // Error allows HTTPError to implement the error interface.
 //
 // The formatting of the error is a little opinionated, as it has to
 // communicate an error from the API if it's there, or an error from the
 // HTTP client.
 func (e *HTTPError) Error() string {
+	var sb strings.Builder
+
+	fmt.Fprintf(&sb, "%s: status=\"%s\"", e.Action, e.Status)
+
 	if e.Api.Msg != "" || e.Api.Error != "" {
-		return fmt.Sprintf("%s: status=\"%s\", msg=\"%s\", err=\"%s\"", e.Action, e.Status, e.Api.Msg, e.Api.Error)
+		fmt.Fprintf(&sb, ", msg=\"%s\", err=\"%s\"", e.Api.Msg, e.Api.Error)
+	}
+
+	if len(e.FieldErrors) > 0 {
+		fields := make([]string, 0, len(e.FieldErrors))
+		for _, fe := range e.FieldErrors {
+			fields = append(fields, fe.Field+": "+fe.Msg)
+		}
+
+		fmt.Fprintf(&sb, ", fields=\"%s\"", strings.Join(fields, "; "))
 	}
 
-	return fmt.Sprintf("%s: status=\"%s\"", e.Action, e.Status)
+	if e.Attempts > 1 {
+		fmt.Fprintf(&sb, ", attempts=%d", e.Attempts)
+	}
+
+	if e.RequestID != "" {
+		fmt.Fprintf(&sb, ", request_id=\"%s\"", e.RequestID)
+	}
+
+	return sb.String()
+}
+
+// Is allows matching HTTPError against the sentinel errors defined in
+// this package (ErrNotFound, ErrConflict, etc) using errors.Is.
+func (e *HTTPError) Is(target error) bool {
+	switch target { //nolint:errorlint // comparing against sentinels on purpose
+	case ErrValidation:
+		return e.Code == http.StatusBadRequest || e.Code == http.StatusUnprocessableEntity
+
+	case ErrUnauthorized:
+		return e.Code == http.StatusUnauthorized
+
+	case ErrForbidden:
+		return e.Code == http.StatusForbidden
+
+	case ErrNotFound:
+		return e.Code == http.StatusNotFound
+
+	case ErrConflict:
+		return e.Code == http.StatusConflict
+
+	case ErrRateLimited:
+		return e.Code == http.StatusTooManyRequests
+
+	case ErrServerError:
+		return e.Code >= http.StatusInternalServerError && e.Code < 600
+
+	default:
+		return false
+	}
 }
 
 // getDefaultHeaders returns the default headers with custom values if set.
@@ -832,39 +1455,27 @@ func (h *Client) getClientHeaders() (string, string) {
 
 // ValidateResponse handles responses from the SM API.
 //
-// If the status code of the request is not 200 or 202, it is expected that there's an
-// error included with the response. This function will decode that response
-// and return in the form of an HTTPError.
+// If the status code of the request is not 200, 201, 202 or 204, it is
+// expected that there's an error included with the response. This
+// function will decode that response and return in the form of an
+// HTTPError.
 //
 // In the case of success, this function attempts to decode the response as a
-// JSON object and storing it the `result` argument.
+// JSON object and storing it the `result` argument. 204 responses have no
+// body, so `result` is not modified.
 func ValidateResponse(action string, resp *http.Response, result interface{}) error {
-	if resp.StatusCode != http.StatusOK && resp.StatusCode != http.StatusAccepted {
-		respError := HTTPError{Code: resp.StatusCode, Status: resp.Status, Action: action}
+	switch resp.StatusCode {
+	case http.StatusOK, http.StatusCreated, http.StatusAccepted:
 
+	case http.StatusNoContent:
 		if resp.Body != nil {
-			defer resp.Body.Close()
-
-			dec := json.NewDecoder(resp.Body)
-
-			var apiError struct {
-				Error string `json:"err"`
-				Msg   string `json:"msg"`
-			}
-
-			if err := dec.Decode(&apiError); err != nil {
-				// If there's an error decoding this,
-				// it's not something we can deal with,
-				// so don't add additional annotations.
-				respError.Api.Msg = "cannot decode response"
-				respError.Api.Error = err.Error()
-			} else {
-				respError.Api.Msg = apiError.Msg
-				respError.Api.Error = apiError.Error
-			}
+			_ = resp.Body.Close()
 		}
 
-		return &respError
+		return nil
+
+	default:
+		return newHTTPError(action, resp)
 	}
 
 	if resp.Body != nil {
@@ -879,3 +1490,58 @@ func ValidateResponse(action string, resp *http.Response, result interface{}) er
 
 	return nil
 }
+
+// validateDeleteResponse is like ValidateResponse, but accepts successful
+// responses without a body, as delete requests have nothing to return.
+func validateDeleteResponse(action string, resp *http.Response, result interface{}) error {
+	err := ValidateResponse(action, resp, result)
+	if errors.Is(err, io.EOF) {
+		return nil
+	}
+
+	return err
+}
+
+// newHTTPError returns the error corresponding to the failed request
+// described by action, decoding the error details from resp.
+func newHTTPError(action string, resp *http.Response) *HTTPError {
+	respError := HTTPError{
+		Code:     resp.StatusCode,
+		Status:   resp.Status,
+		Action:   action,
+		Attempts: attemptsFromResponse(resp),
+	}
+
+	for _, header := range requestIDHeaders {
+		if id := resp.Header.Get(header); id != "" {
+			respError.RequestID = id
+			break
+		}
+	}
+
+	if resp.Body != nil {
+		defer resp.Body.Close()
+
+		dec := json.NewDecoder(resp.Body)
+
+		var apiError struct {
+			Error  string       `json:"err"`
+			Msg    string       `json:"msg"`
+			Fields []FieldError `json:"errors"`
+		}
+
+		if err := dec.Decode(&apiError); err != nil {
+			// If there's an error decoding this,
+			// it's not something we can deal with,
+			// so don't add additional annotations.
+			respError.Api.Msg = "cannot decode response"
+			respError.Api.Error = err.Error()
+		} else {
+			respError.Api.Msg = apiError.Msg
+			respError.Api.Error = apiError.Error
+			respError.FieldErrors = apiError.Fields
+		}
+	}
+
+	return &respError
+}
@@ -5,6 +5,7 @@ import (
 	"encoding/json"
 	"errors"
 	"fmt"
+	"io"
 	"net/http"
 	"net/http/httptest"
 	"strconv"
@@ -226,7 +227,7 @@ func TestNewClient(t *testing.T) {
 			if testcase.client != nil {
 				require.Equal(t, testcase.client, c.client)
 			}
-			require.Equal(t, c.accessToken, testcase.accessToken)
+			require.Equal(t, testcase.accessToken, currentToken(t, c))
 			require.Equal(t, c.baseURL, url+"/api/v1")
 		})
 	}
@@ -267,7 +268,7 @@ func TestNewDatasourceClient(t *testing.T) {
 			if testcase.client != nil {
 				require.Equal(t, testcase.client, c.client)
 			}
-			require.Equal(t, c.accessToken, testcase.accessToken)
+			require.Equal(t, testcase.accessToken, currentToken(t, c))
 			require.Equal(t, c.baseURL, url)
 		})
 	}
@@ -506,7 +507,7 @@ func TestDeleteToken(t *testing.T) {
 		err := c.DeleteToken(ctx)
 		require.True(t, called)
 		require.NoError(t, err)
-		require.Empty(t, c.accessToken)
+		require.Empty(t, currentToken(t, c))
 	})
 
 	t.Run("invalid token", func(t *testing.T) {
@@ -517,7 +518,7 @@ func TestDeleteToken(t *testing.T) {
 		err := c.DeleteToken(ctx)
 		require.True(t, called)
 		require.Error(t, err)
-		require.Equal(t, token, c.accessToken)
+		require.Equal(t, token, currentToken(t, c))
 	})
 }
 
@@ -559,7 +560,7 @@ func TestRefreshToken(t *testing.T) {
 		err := c.RefreshToken(ctx)
 		require.True(t, called)
 		require.NoError(t, err)
-		require.Equal(t, newToken, c.accessToken)
+		require.Equal(t, newToken, currentToken(t, c))
 	})
 
 	t.Run("invalid token", func(t *testing.T) {
@@ -570,7 +571,7 @@ func TestRefreshToken(t *testing.T) {
 		err := c.RefreshToken(ctx)
 		require.True(t, called)
 		require.Error(t, err)
-		require.Equal(t, token, c.accessToken)
+		require.Equal(t, token, currentToken(t, c))
 	})
 }
 
@@ -1265,6 +1266,25 @@ func TestDeleteCheck(t *testing.T) {
 	require.NoError(t, err)
 }
 
+func TestDeleteEmptyResponse(t *testing.T) {
+	url, mux, cleanup := newTestServer(t)
+	defer cleanup()
+
+	// Successful deletes might not return anything.
+	mux.Handle("/api/v1/", http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusOK)
+	}))
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	c := NewClient(url, "token", http.DefaultClient)
+
+	require.NoError(t, c.DeleteCheck(ctx, 1))
+	require.NoError(t, c.DeleteProbe(ctx, 1))
+	require.NoError(t, c.DeleteToken(ctx))
+}
+
 func TestListChecks(t *testing.T) {
 	orgs := orgs()
 	testTenant := orgs.findTenantByOrg(1000)
@@ -1393,6 +1413,14 @@ func TestQueryChecks(t *testing.T) {
 				Target:   "nocanhazip.com",
 			},
 		},
+		{
+			Check: synthetic_monitoring.Check{
+				Id:       126,
+				TenantId: testTenantId,
+				Job:      "search & find",
+				Target:   "https://example.org/search?q=a+b&lang=en#results",
+			},
+		},
 	}
 	url, mux, cleanup := newTestServer(t)
 	defer cleanup()
@@ -1401,6 +1429,10 @@ func TestQueryChecks(t *testing.T) {
 		fmt.Println(r.URL.RawQuery)
 		job := r.URL.Query().Get("job")
 		target := r.URL.Query().Get("target")
+		if job == "null" {
+			writeResponse(w, http.StatusOK, nil)
+			return
+		}
 		for _, check := range checks {
 			if check.Job == job && check.Target == target {
 				resp = &check
@@ -1431,6 +1463,14 @@ func TestQueryChecks(t *testing.T) {
 		require.NotNil(t, filteredChecks)
 		require.Equal(t, filteredChecks.Target, "nocanhazip.com")
 	})
+	t.Run("Validate a check with a URL target can be found", func(t *testing.T) {
+		ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+		defer cancel()
+		filteredChecks, err := c.QueryCheck(ctx, "search & find", "https://example.org/search?q=a+b&lang=en#results")
+		require.NoError(t, err)
+		require.NotNil(t, filteredChecks)
+		require.Equal(t, int64(126), filteredChecks.Id)
+	})
 	t.Run("Validate a not found check returns a 404", func(t *testing.T) {
 		ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
 		defer cancel()
@@ -1438,6 +1478,13 @@ func TestQueryChecks(t *testing.T) {
 		require.Error(t, err)
 		require.Nil(t, filteredChecks)
 	})
+	t.Run("Validate an empty response is an error", func(t *testing.T) {
+		ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+		defer cancel()
+		filteredChecks, err := c.QueryCheck(ctx, "null", "nocanhazip.com")
+		require.ErrorIs(t, err, ErrUnexpectedResponse)
+		require.Nil(t, filteredChecks)
+	})
 
 	t.Run("Ensure the client checks for missing jobs and checks", func(t *testing.T) {
 		ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
@@ -1808,6 +1855,17 @@ func TestCustomHeadersInRequests(t *testing.T) {
 	resp.Body.Close()
 }
 
+// currentToken returns the token currently stored in the client, without
+// going through the validation done when sending requests.
+func currentToken(t *testing.T, c *Client) string {
+	t.Helper()
+
+	token, err := c.tokens.Token(context.Background())
+	require.NoError(t, err)
+
+	return token
+}
+
 func newTestServer(t *testing.T) (string, *http.ServeMux, func()) {
 	t.Helper()
 
@@ -1924,3 +1982,136 @@ func writeResponse(w http.ResponseWriter, code int, resp interface{}) {
 func errorResponse(w http.ResponseWriter, code int, msg string) {
 	writeResponse(w, code, &model.ResponseError{Msg: msg})
 }
+
+func TestHTTPErrorIs(t *testing.T) {
+	sentinels := []error{
+		ErrValidation,
+		ErrUnauthorized,
+		ErrForbidden,
+		ErrNotFound,
+		ErrConflict,
+		ErrRateLimited,
+		ErrServerError,
+	}
+
+	testcases := map[int]error{
+		http.StatusBadRequest:          ErrValidation,
+		http.StatusUnprocessableEntity: ErrValidation,
+		http.StatusUnauthorized:        ErrUnauthorized,
+		http.StatusForbidden:           ErrForbidden,
+		http.StatusNotFound:            ErrNotFound,
+		http.StatusConflict:            ErrConflict,
+		http.StatusTooManyRequests:     ErrRateLimited,
+		http.StatusInternalServerError: ErrServerError,
+		http.StatusBadGateway:          ErrServerError,
+		http.StatusServiceUnavailable:  ErrServerError,
+		http.StatusTeapot:              nil,
+	}
+
+	for code, expected := range testcases {
+		t.Run(http.StatusText(code), func(t *testing.T) {
+			err := fmt.Errorf("wrapped: %w", &HTTPError{Code: code})
+
+			for _, sentinel := range sentinels {
+				require.Equal(t, sentinel == expected, errors.Is(err, sentinel), "code %d, sentinel %q", code, sentinel)
+			}
+		})
+	}
+}
+
+func TestValidateResponseStatus(t *testing.T) {
+	// 201 and 204 are accepted as success since secrets were added,
+	// as the secrets API uses them. Before that, only 200 and 202
+	// were.
+	testcases := map[string]struct {
+		status         int
+		body           string
+		expectedResult map[string]string
+		expectedErr    error
+	}{
+		"200":       {status: http.StatusOK, body: `{"a":"b"}`, expectedResult: map[string]string{"a": "b"}},
+		"201":       {status: http.StatusCreated, body: `{"a":"b"}`, expectedResult: map[string]string{"a": "b"}},
+		"202":       {status: http.StatusAccepted, body: `{"a":"b"}`, expectedResult: map[string]string{"a": "b"}},
+		"204":       {status: http.StatusNoContent, body: ``, expectedResult: map[string]string{"unchanged": "yes"}},
+		"400":       {status: http.StatusBadRequest, body: `{"msg":"bad"}`, expectedErr: ErrValidation},
+		"401":       {status: http.StatusUnauthorized, body: `{"msg":"bad"}`, expectedErr: ErrUnauthorized},
+		"404":       {status: http.StatusNotFound, body: `{"msg":"bad"}`, expectedErr: ErrNotFound},
+		"500":       {status: http.StatusInternalServerError, body: `{"msg":"bad"}`, expectedErr: ErrServerError},
+		"other 2xx": {status: http.StatusPartialContent, body: `{"a":"b"}`},
+	}
+
+	for name, tc := range testcases {
+		t.Run(name, func(t *testing.T) {
+			resp := &http.Response{
+				StatusCode: tc.status,
+				Status:     strconv.Itoa(tc.status) + " " + http.StatusText(tc.status),
+				Header:     make(http.Header),
+				Body:       io.NopCloser(strings.NewReader(tc.body)),
+			}
+
+			result := map[string]string{"unchanged": "yes"}
+			if tc.status != http.StatusNoContent {
+				result = map[string]string{}
+			}
+
+			err := ValidateResponse("test request", resp, &result)
+
+			switch {
+			case tc.expectedErr != nil:
+				require.ErrorIs(t, err, tc.expectedErr)
+
+			case tc.expectedResult == nil:
+				var httpErr *HTTPError
+				require.ErrorAs(t, err, &httpErr)
+				require.Equal(t, tc.status, httpErr.Code)
+
+			default:
+				require.NoError(t, err)
+				require.Equal(t, tc.expectedResult, result)
+			}
+		})
+	}
+}
+
+func TestValidateResponseErrorDetails(t *testing.T) {
+	url, mux, cleanup := newTestServer(t)
+	defer cleanup()
+
+	mux.Handle("/api/v1/check/add", http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("X-Request-Id", "req-123")
+		writeResponse(w, http.StatusBadRequest, map[string]interface{}{
+			"msg": "invalid check",
+			"err": "validation failed",
+			"errors": []map[string]string{
+				{"field": "target", "msg": "invalid target"},
+				{"field": "frequency", "msg": "out of range"},
+			},
+		})
+	}))
+
+	mux.Handle("/api/v1/check/42", http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		errorResponse(w, http.StatusNotFound, "check not found")
+	}))
+
+	c := NewClient(url, "token", nil)
+
+	_, err := c.AddCheck(context.Background(), model.Check{})
+	require.ErrorIs(t, err, ErrValidation)
+
+	var httpErr *HTTPError
+	require.ErrorAs(t, err, &httpErr)
+	require.Equal(t, "req-123", httpErr.RequestID)
+	require.Equal(t, []FieldError{
+		{Field: "target", Msg: "invalid target"},
+		{Field: "frequency", Msg: "out of range"},
+	}, httpErr.FieldErrors)
+	require.Equal(
+		t,
+		`check add request: status="400 Bad Request", msg="invalid check", err="validation failed", fields="target: invalid target; frequency: out of range", request_id="req-123"`,
+		err.Error(),
+	)
+
+	_, err = c.GetCheck(context.Background(), 42)
+	require.ErrorIs(t, err, ErrNotFound)
+	require.NotErrorIs(t, err, ErrValidation)
+}
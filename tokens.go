@@ -0,0 +1,287 @@
+package smapi
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"strings"
+	"sync"
+	"time"
+)
+
+// TokenSource provides the access token used to authenticate requests
+// to the API server.
+//
+// The client consults the token source for every request, so
+// implementations must be safe for concurrent use and they should
+// avoid doing expensive work on every call.
+type TokenSource interface {
+	Token(ctx context.Context) (string, error)
+}
+
+// TokenSetter is implemented by token sources that can store a new
+// token, like the ones obtained by Install and RefreshToken.
+//
+// If the client's token source does not implement this interface,
+// those calls do not change the token used by the client.
+type TokenSetter interface {
+	SetToken(token string)
+}
+
+// TokenSourceFunc is an adapter to allow the use of ordinary functions
+// as token sources, e.g. in order to obtain the token from a secret
+// store.
+type TokenSourceFunc func(ctx context.Context) (string, error)
+
+// Token returns the result of calling f.
+func (f TokenSourceFunc) Token(ctx context.Context) (string, error) {
+	return f(ctx)
+}
+
+// StaticTokenSource is a TokenSource that holds a single token, which
+// can be replaced at any time. This is the token source used by
+// default.
+type StaticTokenSource struct {
+	mu    sync.RWMutex
+	token string
+}
+
+var _ TokenSetter = (*StaticTokenSource)(nil)
+
+// NewStaticTokenSource returns a StaticTokenSource holding token.
+func NewStaticTokenSource(token string) *StaticTokenSource {
+	return &StaticTokenSource{token: token}
+}
+
+// Token returns the current token.
+func (s *StaticTokenSource) Token(context.Context) (string, error) {
+	return s.get(), nil
+}
+
+// SetToken replaces the current token.
+func (s *StaticTokenSource) SetToken(token string) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.token = token
+}
+
+func (s *StaticTokenSource) get() string {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	return s.token
+}
+
+// EnvTokenSource returns a TokenSource that reads the token from the
+// named environment variable every time it's needed.
+func EnvTokenSource(name string) TokenSource {
+	return TokenSourceFunc(func(context.Context) (string, error) {
+		return strings.TrimSpace(os.Getenv(name)), nil
+	})
+}
+
+// FileTokenSource is a TokenSource that reads the token from a file. The
+// file is read again if it changes, which allows rotating the token
+// without restarting the program.
+//
+// Leading and trailing whitespace is removed from the token.
+type FileTokenSource struct {
+	path string
+
+	mu      sync.Mutex
+	token   string
+	modTime time.Time
+	size    int64
+}
+
+// NewFileTokenSource returns a FileTokenSource that reads the token from
+// the file at path.
+func NewFileTokenSource(path string) *FileTokenSource {
+	return &FileTokenSource{path: path}
+}
+
+// Token returns the token stored in the file, reading it again if the
+// file has changed since the last time it was read.
+func (s *FileTokenSource) Token(context.Context) (string, error) {
+	fi, err := os.Stat(s.path)
+	if err != nil {
+		return "", fmt.Errorf("reading token file: %w", err)
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if s.token != "" && fi.ModTime().Equal(s.modTime) && fi.Size() == s.size {
+		return s.token, nil
+	}
+
+	if err := s.read(); err != nil {
+		return "", err
+	}
+
+	return s.token, nil
+}
+
+// Reload reads the token from the file, even if the file does not seem
+// to have changed.
+func (s *FileTokenSource) Reload(context.Context) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	return s.read()
+}
+
+// read reads the token from the file. The caller must hold s.mu.
+func (s *FileTokenSource) read() error {
+	fh, err := os.Open(s.path)
+	if err != nil {
+		return fmt.Errorf("reading token file: %w", err)
+	}
+	defer func() { _ = fh.Close() }()
+
+	fi, err := fh.Stat()
+	if err != nil {
+		return fmt.Errorf("reading token file: %w", err)
+	}
+
+	buf, err := io.ReadAll(fh)
+	if err != nil {
+		return fmt.Errorf("reading token file: %w", err)
+	}
+
+	s.token = strings.TrimSpace(string(buf))
+	s.modTime = fi.ModTime()
+	s.size = fi.Size()
+
+	return nil
+}
+
+// TokenReloader is implemented by token sources that can be forced to
+// obtain the token again, e.g. FileTokenSource.
+type TokenReloader interface {
+	Reload(ctx context.Context) error
+}
+
+// TokenRefresher obtains a new access token for the client after the API
+// server rejected the current one. It must store the new token in the
+// client, either by calling one of the client's methods that do that
+// (e.g. Install or RefreshToken) or by updating the client's token
+// source.
+//
+// See WithTokenRefresh.
+type TokenRefresher func(ctx context.Context, c *Client) error
+
+// RefreshWithRefreshToken returns a TokenRefresher that calls RefreshToken
+// in order to replace the current token.
+func RefreshWithRefreshToken() TokenRefresher {
+	return func(ctx context.Context, c *Client) error {
+		return c.RefreshToken(ctx)
+	}
+}
+
+// RefreshWithInstall returns a TokenRefresher that calls Install using
+// the provided arguments in order to obtain a new token.
+func RefreshWithInstall(stackID, metricsInstanceID, logsInstanceID int64, publisherToken string) TokenRefresher {
+	return func(ctx context.Context, c *Client) error {
+		if _, err := c.Install(ctx, stackID, metricsInstanceID, logsInstanceID, publisherToken); err != nil {
+			return err
+		}
+
+		return nil
+	}
+}
+
+// RefreshWithTokenSourceReload returns a TokenRefresher that reloads the
+// client's token source, if it implements TokenReloader. Other token
+// sources are simply consulted again.
+func RefreshWithTokenSourceReload() TokenRefresher {
+	return func(ctx context.Context, c *Client) error {
+		if tokens, ok := c.tokens.(TokenReloader); ok {
+			return tokens.Reload(ctx)
+		}
+
+		return nil
+	}
+}
+
+type noTokenRefreshKey struct{}
+
+// refreshToken calls the client's token refresher, unless the token
+// has changed since it was rejected by the API server.
+func (h *Client) refreshToken(ctx context.Context, rejected string) error {
+	h.refreshMu.Lock()
+	defer h.refreshMu.Unlock()
+
+	// Another request might have obtained a new token while this one
+	// was waiting for the lock.
+	if current, err := h.token(ctx); err == nil && current != rejected {
+		return nil
+	}
+
+	// The requests made by the refresher must not trigger another
+	// refresh.
+	return h.tokenRefresher(context.WithValue(ctx, noTokenRefreshKey{}, true), h)
+}
+
+// canRefreshToken reports whether it's possible to refresh the token and
+// send req again after the API server rejected it.
+func (h *Client) canRefreshToken(req *http.Request) bool {
+	if h.tokenRefresher == nil {
+		return false
+	}
+
+	if disabled, _ := req.Context().Value(noTokenRefreshKey{}).(bool); disabled {
+		return false
+	}
+
+	return req.Body == nil || req.Body == http.NoBody || req.GetBody != nil
+}
+
+// refreshTokenAndResend refreshes the access token after the API server
+// rejected it with the response resp, and sends req again using the new
+// token. If the token cannot be refreshed, resp is returned.
+func (h *Client) refreshTokenAndResend(req *http.Request, resp *http.Response, rejected string) (*http.Response, error) {
+	ctx := req.Context()
+
+	// Close the rejected response before refreshing the token, as it
+	// holds one of the slots limited by WithMaxInFlight, which the
+	// refresher might need in order to send its own requests. Its body
+	// is kept in case the token cannot be refreshed.
+	body, err := io.ReadAll(resp.Body)
+	_ = resp.Body.Close()
+
+	if err != nil {
+		return nil, fmt.Errorf("reading HTTP response body: %w", err)
+	}
+
+	resp.Body = io.NopCloser(bytes.NewReader(body))
+
+	if err := h.refreshToken(ctx, rejected); err != nil {
+		h.getLogger().WarnContext(ctx, "cannot refresh access token", "error", err)
+
+		return resp, nil
+	}
+
+	token, err := h.token(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	next := req.Clone(ctx)
+	if req.GetBody != nil {
+		body, err := req.GetBody()
+		if err != nil {
+			return nil, fmt.Errorf("replaying HTTP request body: %w", err)
+		}
+
+		next.Body = body
+	}
+
+	next.Header.Set("Authorization", "Bearer "+token)
+
+	return h.send(next)
+}
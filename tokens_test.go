@@ -0,0 +1,420 @@
+package smapi
+
+import (
+	"context"
+	"errors"
+	"io"
+	"net/http"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/grafana/synthetic-monitoring-api-go-client/model"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestStaticTokenSource(t *testing.T) {
+	s := NewStaticTokenSource("token-1")
+
+	token, err := s.Token(context.Background())
+	require.NoError(t, err)
+	require.Equal(t, "token-1", token)
+
+	var wg sync.WaitGroup
+
+	for range 10 {
+		wg.Add(2)
+
+		go func() {
+			defer wg.Done()
+			s.SetToken("token-2")
+		}()
+
+		go func() {
+			defer wg.Done()
+			_, _ = s.Token(context.Background())
+		}()
+	}
+
+	wg.Wait()
+
+	token, err = s.Token(context.Background())
+	require.NoError(t, err)
+	require.Equal(t, "token-2", token)
+}
+
+func TestEnvTokenSource(t *testing.T) {
+	t.Setenv("SM_TEST_TOKEN", " token-1\n")
+
+	s := EnvTokenSource("SM_TEST_TOKEN")
+
+	token, err := s.Token(context.Background())
+	require.NoError(t, err)
+	require.Equal(t, "token-1", token)
+
+	t.Setenv("SM_TEST_TOKEN", "token-2")
+
+	token, err = s.Token(context.Background())
+	require.NoError(t, err)
+	require.Equal(t, "token-2", token)
+}
+
+func TestFileTokenSource(t *testing.T) {
+	filename := filepath.Join(t.TempDir(), "token")
+
+	s := NewFileTokenSource(filename)
+
+	_, err := s.Token(context.Background())
+	require.ErrorIs(t, err, os.ErrNotExist)
+
+	require.NoError(t, os.WriteFile(filename, []byte("token-1\n"), 0o600))
+
+	token, err := s.Token(context.Background())
+	require.NoError(t, err)
+	require.Equal(t, "token-1", token)
+
+	// Make sure the modification time changes, some filesystems have
+	// a coarse resolution.
+	require.NoError(t, os.WriteFile(filename, []byte("token-22\n"), 0o600))
+	require.NoError(t, os.Chtimes(filename, time.Now(), time.Now().Add(time.Second)))
+
+	token, err = s.Token(context.Background())
+	require.NoError(t, err)
+	require.Equal(t, "token-22", token)
+}
+
+func TestClientTokenSource(t *testing.T) {
+	url, mux, cleanup := newTestServer(t)
+	defer cleanup()
+
+	var received []string
+	mux.Handle("/api/v1/tenant", http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		received = append(received, strings.TrimPrefix(r.Header.Get("Authorization"), "Bearer "))
+		writeResponse(w, http.StatusOK, struct{}{})
+	}))
+
+	var (
+		calls     int
+		errSecret = errors.New("secret store unavailable")
+	)
+
+	tokens := TokenSourceFunc(func(ctx context.Context) (string, error) {
+		calls++
+
+		switch calls {
+		case 1:
+			return "token-1", nil
+		case 2:
+			return "token-2", nil
+		case 3:
+			return "", nil
+		default:
+			return "", errSecret
+		}
+	})
+
+	c, err := New(url, WithTokenSource(tokens))
+	require.NoError(t, err)
+
+	_, err = c.GetTenant(context.Background())
+	require.NoError(t, err)
+
+	_, err = c.GetTenant(context.Background())
+	require.NoError(t, err)
+
+	require.Equal(t, []string{"token-1", "token-2"}, received)
+
+	_, err = c.GetTenant(context.Background())
+	require.ErrorIs(t, err, ErrAuthorizationTokenRequired)
+
+	_, err = c.GetTenant(context.Background())
+	require.ErrorIs(t, err, errSecret)
+}
+
+func TestClientConcurrentTokenUse(t *testing.T) {
+	url, mux, cleanup := newTestServer(t)
+	defer cleanup()
+
+	mux.Handle("/api/v1/tenant", http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		writeResponse(w, http.StatusOK, struct{}{})
+	}))
+
+	mux.Handle("/api/v1/token/refresh", http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		writeResponse(w, http.StatusOK, struct {
+			AccessToken string `json:"accessToken"`
+		}{AccessToken: "new-token"})
+	}))
+
+	c, err := New(url, WithAccessToken("token"))
+	require.NoError(t, err)
+
+	var wg sync.WaitGroup
+
+	for range 10 {
+		wg.Add(2)
+
+		go func() {
+			defer wg.Done()
+			_, _ = c.GetTenant(context.Background())
+		}()
+
+		go func() {
+			defer wg.Done()
+			_ = c.RefreshToken(context.Background())
+		}()
+	}
+
+	wg.Wait()
+
+	require.Equal(t, "new-token", currentToken(t, c))
+}
+
+// newRotatingTokenServer returns a test server that only accepts validToken
+// for tenant requests, and which returns validToken from the token
+// refresh endpoint.
+func newRotatingTokenServer(t *testing.T, validToken string) (string, *atomic.Int64, *atomic.Int64, func()) {
+	t.Helper()
+
+	url, mux, cleanup := newTestServer(t)
+
+	var tenantRequests, refreshRequests atomic.Int64
+
+	mux.Handle("/api/v1/tenant", http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		tenantRequests.Add(1)
+
+		if r.Header.Get("Authorization") != "Bearer "+validToken {
+			errorResponse(w, http.StatusUnauthorized, "not authorized")
+			return
+		}
+
+		writeResponse(w, http.StatusOK, struct{}{})
+	}))
+
+	mux.Handle("/api/v1/token/refresh", http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		refreshRequests.Add(1)
+
+		writeResponse(w, http.StatusOK, struct {
+			AccessToken string `json:"accessToken"`
+		}{AccessToken: validToken})
+	}))
+
+	return url, &tenantRequests, &refreshRequests, cleanup
+}
+
+func TestTokenRefresh(t *testing.T) {
+	t.Run("disabled", func(t *testing.T) {
+		url, tenantRequests, refreshRequests, cleanup := newRotatingTokenServer(t, "new-token")
+		defer cleanup()
+
+		c, err := New(url, WithAccessToken("old-token"))
+		require.NoError(t, err)
+
+		_, err = c.GetTenant(context.Background())
+		require.ErrorIs(t, err, ErrUnauthorized)
+		require.Equal(t, int64(1), tenantRequests.Load())
+		require.Zero(t, refreshRequests.Load())
+	})
+
+	t.Run("refresh token", func(t *testing.T) {
+		url, tenantRequests, refreshRequests, cleanup := newRotatingTokenServer(t, "new-token")
+		defer cleanup()
+
+		c, err := New(url, WithAccessToken("old-token"), WithTokenRefresh(RefreshWithRefreshToken()))
+		require.NoError(t, err)
+
+		_, err = c.GetTenant(context.Background())
+		require.NoError(t, err)
+		require.Equal(t, int64(2), tenantRequests.Load())
+		require.Equal(t, int64(1), refreshRequests.Load())
+		require.Equal(t, "new-token", currentToken(t, c))
+
+		// The new token is used for subsequent requests.
+		_, err = c.GetTenant(context.Background())
+		require.NoError(t, err)
+		require.Equal(t, int64(3), tenantRequests.Load())
+		require.Equal(t, int64(1), refreshRequests.Load())
+	})
+
+	t.Run("retried once", func(t *testing.T) {
+		url, tenantRequests, refreshRequests, cleanup := newRotatingTokenServer(t, "new-token")
+		defer cleanup()
+
+		var calls int
+
+		refresher := func(ctx context.Context, c *Client) error {
+			calls++
+			c.setToken("another-invalid-token")
+
+			return nil
+		}
+
+		c, err := New(url, WithAccessToken("old-token"), WithTokenRefresh(refresher))
+		require.NoError(t, err)
+
+		_, err = c.GetTenant(context.Background())
+		require.ErrorIs(t, err, ErrUnauthorized)
+		require.Equal(t, int64(2), tenantRequests.Load())
+		require.Zero(t, refreshRequests.Load())
+		require.Equal(t, 1, calls)
+	})
+
+	t.Run("refresh fails", func(t *testing.T) {
+		url, tenantRequests, _, cleanup := newRotatingTokenServer(t, "new-token")
+		defer cleanup()
+
+		errRefresh := errors.New("cannot refresh")
+
+		c, err := New(url, WithAccessToken("old-token"), WithTokenRefresh(func(ctx context.Context, c *Client) error {
+			return errRefresh
+		}))
+		require.NoError(t, err)
+
+		_, err = c.GetTenant(context.Background())
+		require.ErrorIs(t, err, ErrUnauthorized)
+		require.Equal(t, int64(1), tenantRequests.Load())
+	})
+
+	t.Run("max in flight", func(t *testing.T) {
+		url, tenantRequests, refreshRequests, cleanup := newRotatingTokenServer(t, "new-token")
+		defer cleanup()
+
+		// The refresher needs the slot held by the rejected
+		// request.
+		c, err := New(url, WithAccessToken("old-token"), WithMaxInFlight(1), WithTokenRefresh(RefreshWithRefreshToken()))
+		require.NoError(t, err)
+
+		ctx, cancel := context.WithTimeout(context.Background(), 3*time.Second)
+		defer cancel()
+
+		_, err = c.GetTenant(ctx)
+		require.NoError(t, err)
+		require.Equal(t, int64(2), tenantRequests.Load())
+		require.Equal(t, int64(1), refreshRequests.Load())
+	})
+
+	t.Run("max in flight refresh fails", func(t *testing.T) {
+		url, tenantRequests, _, cleanup := newRotatingTokenServer(t, "new-token")
+		defer cleanup()
+
+		c, err := New(url, WithAccessToken("old-token"), WithMaxInFlight(1), WithTokenRefresh(func(ctx context.Context, c *Client) error {
+			return errors.New("cannot refresh")
+		}))
+		require.NoError(t, err)
+
+		ctx, cancel := context.WithTimeout(context.Background(), 3*time.Second)
+		defer cancel()
+
+		// The rejected response is still reported, and the slot it
+		// held is available for the next request.
+		for range 2 {
+			_, err = c.GetTenant(ctx)
+			require.ErrorIs(t, err, ErrUnauthorized)
+		}
+
+		require.Equal(t, int64(2), tenantRequests.Load())
+	})
+
+	t.Run("token source reload", func(t *testing.T) {
+		url, tenantRequests, _, cleanup := newRotatingTokenServer(t, "new-token")
+		defer cleanup()
+
+		filename := filepath.Join(t.TempDir(), "token")
+		require.NoError(t, os.WriteFile(filename, []byte("old-token"), 0o600))
+
+		tokens := NewFileTokenSource(filename)
+
+		c, err := New(url, WithTokenSource(tokens), WithTokenRefresh(RefreshWithTokenSourceReload()))
+		require.NoError(t, err)
+
+		_, err = c.GetTenant(context.Background())
+		require.ErrorIs(t, err, ErrUnauthorized)
+
+		// Replace the token keeping the same size and modification
+		// time, so that it's necessary to force a reload.
+		fi, err := os.Stat(filename)
+		require.NoError(t, err)
+		require.NoError(t, os.WriteFile(filename, []byte("new-token"), 0o600))
+		require.NoError(t, os.Chtimes(filename, fi.ModTime(), fi.ModTime()))
+
+		tenantRequests.Store(0)
+
+		_, err = c.GetTenant(context.Background())
+		require.NoError(t, err)
+		require.Equal(t, int64(2), tenantRequests.Load())
+	})
+
+	t.Run("concurrent requests", func(t *testing.T) {
+		url, _, _, cleanup := newRotatingTokenServer(t, "new-token")
+		defer cleanup()
+
+		var calls atomic.Int64
+
+		tokens := NewStaticTokenSource("old-token")
+
+		refresher := func(ctx context.Context, c *Client) error {
+			calls.Add(1)
+			tokens.SetToken("new-token")
+
+			return nil
+		}
+
+		c, err := New(url, WithTokenSource(tokens), WithTokenRefresh(refresher))
+		require.NoError(t, err)
+
+		var wg sync.WaitGroup
+
+		for range 10 {
+			wg.Add(1)
+
+			go func() {
+				defer wg.Done()
+
+				_, err := c.GetTenant(context.Background())
+				assert.NoError(t, err)
+			}()
+		}
+
+		wg.Wait()
+
+		require.Equal(t, int64(1), calls.Load())
+	})
+}
+
+func TestTokenRefreshReplaysBody(t *testing.T) {
+	url, mux, cleanup := newTestServer(t)
+	defer cleanup()
+
+	var bodies []string
+
+	mux.Handle("/api/v1/check/1/alerts", http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		buf, _ := io.ReadAll(r.Body)
+		bodies = append(bodies, string(buf))
+
+		if r.Header.Get("Authorization") != "Bearer new-token" {
+			errorResponse(w, http.StatusUnauthorized, "not authorized")
+			return
+		}
+
+		writeResponse(w, http.StatusOK, struct{}{})
+	}))
+
+	tokens := NewStaticTokenSource("old-token")
+
+	c, err := New(url, WithTokenSource(tokens), WithTokenRefresh(func(ctx context.Context, c *Client) error {
+		tokens.SetToken("new-token")
+
+		return nil
+	}))
+	require.NoError(t, err)
+
+	_, err = c.UpdateCheckAlerts(context.Background(), 1, []model.CheckAlert{{Name: "ProbeFailedExecutionsTooHigh", Threshold: 1}})
+	require.NoError(t, err)
+	require.Len(t, bodies, 2)
+	require.NotEmpty(t, bodies[0])
+	require.Equal(t, bodies[0], bodies[1])
+}
@@ -0,0 +1,185 @@
+// Package tracing provides OpenTelemetry instrumentation for the
+// Synthetic Monitoring API client.
+//
+// The instrumentation is installed as an interceptor:
+//
+//	client, err := smapi.New(url,
+//		smapi.WithAccessToken(token),
+//		smapi.WithInterceptors(tracing.Interceptor()),
+//	)
+//
+// Each call to one of the client's methods creates a span, and the trace
+// context is propagated to the API server using the W3C Trace Context
+// headers.
+package tracing
+
+import (
+	"cmp"
+	"context"
+	"errors"
+	"strconv"
+
+	sm "github.com/grafana/synthetic-monitoring-agent/pkg/pb/synthetic_monitoring"
+	smapi "github.com/grafana/synthetic-monitoring-api-go-client"
+	"github.com/grafana/synthetic-monitoring-api-go-client/model"
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+	"go.opentelemetry.io/otel/propagation"
+	semconv "go.opentelemetry.io/otel/semconv/v1.40.0"
+	"go.opentelemetry.io/otel/trace"
+)
+
+// ScopeName is the instrumentation scope name used for the tracer.
+const ScopeName = "github.com/grafana/synthetic-monitoring-api-go-client/tracing"
+
+// Attributes added to spans, in addition to the standard HTTP ones.
+const (
+	OperationKey      = attribute.Key("sm.operation")
+	CheckIDKey        = attribute.Key("sm.check.id")
+	ProbeIDKey        = attribute.Key("sm.probe.id")
+	AttemptsKey       = attribute.Key("sm.attempts")
+	ErrorMessageKey   = attribute.Key("sm.error.message")
+	ErrorDetailKey    = attribute.Key("sm.error.detail")
+	ErrorRequestIDKey = attribute.Key("sm.error.request_id")
+	ErrorFieldsKey    = attribute.Key("sm.error.fields")
+)
+
+// Option configures the interceptor returned by Interceptor.
+type Option func(*config)
+
+type config struct {
+	tracerProvider trace.TracerProvider
+	propagator     propagation.TextMapPropagator
+}
+
+// WithTracerProvider sets the tracer provider used to create spans. By
+// default the global tracer provider is used.
+func WithTracerProvider(tp trace.TracerProvider) Option {
+	return func(c *config) {
+		c.tracerProvider = tp
+	}
+}
+
+// WithPropagator sets the propagator used to send the trace context to
+// the API server. By default W3C Trace Context headers are used.
+func WithPropagator(p propagation.TextMapPropagator) Option {
+	return func(c *config) {
+		c.propagator = p
+	}
+}
+
+// Interceptor returns a smapi.Interceptor that creates a span for every
+// operation performed by the client.
+func Interceptor(opts ...Option) smapi.Interceptor {
+	cfg := config{
+		tracerProvider: otel.GetTracerProvider(),
+		propagator:     propagation.TraceContext{},
+	}
+
+	for _, opt := range opts {
+		opt(&cfg)
+	}
+
+	tracer := cfg.tracerProvider.Tracer(ScopeName)
+
+	return func(ctx context.Context, op *smapi.Operation, next smapi.Invoker) error {
+		ctx, span := tracer.Start(ctx, op.Name,
+			trace.WithSpanKind(trace.SpanKindClient),
+			trace.WithAttributes(
+				OperationKey.String(op.Name),
+				semconv.HTTPRequestMethodKey.String(op.Method),
+				semconv.URLPath(op.Path),
+			),
+		)
+		defer span.End()
+
+		cfg.propagator.Inject(ctx, propagation.HeaderCarrier(op.Header))
+
+		err := next(ctx, op)
+
+		checkID, probeID := ids(op, err == nil)
+		if checkID != 0 {
+			span.SetAttributes(CheckIDKey.Int64(checkID))
+		}
+
+		if probeID != 0 {
+			span.SetAttributes(ProbeIDKey.Int64(probeID))
+		}
+
+		if op.StatusCode != 0 {
+			span.SetAttributes(semconv.HTTPResponseStatusCode(op.StatusCode))
+		}
+
+		if op.Attempts > 0 {
+			span.SetAttributes(AttemptsKey.Int(op.Attempts))
+		}
+
+		if op.Attempts > 1 {
+			span.SetAttributes(semconv.HTTPRequestResendCount(op.Attempts - 1))
+		}
+
+		if err != nil {
+			recordError(span, err)
+		}
+
+		return err
+	}
+}
+
+func recordError(span trace.Span, err error) {
+	span.RecordError(err)
+	span.SetStatus(codes.Error, err.Error())
+
+	var httpErr *smapi.HTTPError
+	if !errors.As(err, &httpErr) {
+		span.SetAttributes(semconv.ErrorTypeOther)
+
+		return
+	}
+
+	span.SetAttributes(semconv.ErrorTypeKey.String(strconv.Itoa(httpErr.Code)))
+
+	if httpErr.Api.Msg != "" {
+		span.SetAttributes(ErrorMessageKey.String(httpErr.Api.Msg))
+	}
+
+	if httpErr.Api.Error != "" {
+		span.SetAttributes(ErrorDetailKey.String(httpErr.Api.Error))
+	}
+
+	if httpErr.RequestID != "" {
+		span.SetAttributes(ErrorRequestIDKey.String(httpErr.RequestID))
+	}
+
+	if len(httpErr.FieldErrors) > 0 {
+		span.SetAttributes(ErrorFieldsKey.Int(len(httpErr.FieldErrors)))
+	}
+}
+
+// ids returns the check and probe IDs the operation refers to. If the
+// operation succeeded, the result is consulted, too, so that the IDs
+// assigned by the API server are reported.
+func ids(op *smapi.Operation, succeeded bool) (checkID, probeID int64) {
+	checkID, probeID = op.CheckID, op.ProbeID
+
+	if !succeeded {
+		return checkID, probeID
+	}
+
+	switch result := op.Result.(type) {
+	case *model.Check:
+		checkID = cmp.Or(result.Id, checkID)
+
+	case *sm.Probe:
+		probeID = cmp.Or(result.Id, probeID)
+
+	case *model.ProbeAddResponse:
+		probeID = cmp.Or(result.Probe.Id, probeID)
+
+	case *model.ProbeUpdateResponse:
+		probeID = cmp.Or(result.Probe.Id, probeID)
+	}
+
+	return checkID, probeID
+}
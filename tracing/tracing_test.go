@@ -0,0 +1,153 @@
+package tracing
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	sm "github.com/grafana/synthetic-monitoring-agent/pkg/pb/synthetic_monitoring"
+	smapi "github.com/grafana/synthetic-monitoring-api-go-client"
+	"github.com/grafana/synthetic-monitoring-api-go-client/model"
+	"github.com/stretchr/testify/require"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+	"go.opentelemetry.io/otel/propagation"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	"go.opentelemetry.io/otel/sdk/trace/tracetest"
+	"go.opentelemetry.io/otel/trace"
+)
+
+func newTestClient(t *testing.T, handler http.Handler, opts ...smapi.Option) (*smapi.Client, *tracetest.InMemoryExporter) {
+	t.Helper()
+
+	srv := httptest.NewServer(handler)
+	t.Cleanup(srv.Close)
+
+	exporter := tracetest.NewInMemoryExporter()
+	tp := sdktrace.NewTracerProvider(sdktrace.WithSyncer(exporter))
+
+	opts = append([]smapi.Option{
+		smapi.WithAccessToken("token"),
+		smapi.WithInterceptors(Interceptor(WithTracerProvider(tp))),
+	}, opts...)
+
+	c, err := smapi.New(srv.URL, opts...)
+	require.NoError(t, err)
+
+	return c, exporter
+}
+
+func writeJSON(w http.ResponseWriter, code int, v any) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(code)
+	_ = json.NewEncoder(w).Encode(v)
+}
+
+func attributes(span tracetest.SpanStub) map[attribute.Key]attribute.Value {
+	attrs := make(map[attribute.Key]attribute.Value)
+
+	for _, kv := range span.Attributes {
+		attrs[kv.Key] = kv.Value
+	}
+
+	return attrs
+}
+
+func TestInterceptor(t *testing.T) {
+	var traceparent string
+
+	handler := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		traceparent = r.Header.Get("Traceparent")
+		writeJSON(w, http.StatusOK, model.Check{Check: sm.Check{Id: 42}})
+	})
+
+	c, exporter := newTestClient(t, handler)
+
+	_, err := c.AddCheck(context.Background(), model.Check{})
+	require.NoError(t, err)
+
+	spans := exporter.GetSpans()
+	require.Len(t, spans, 1)
+
+	span := spans[0]
+	require.Equal(t, "check add request", span.Name)
+	require.Equal(t, trace.SpanKindClient, span.SpanKind)
+	require.Equal(t, codes.Unset, span.Status.Code)
+
+	attrs := attributes(span)
+	require.Equal(t, "check add request", attrs[OperationKey].AsString())
+	require.Equal(t, http.MethodPost, attrs["http.request.method"].AsString())
+	require.Equal(t, "/check/add", attrs["url.path"].AsString())
+	require.Equal(t, int64(42), attrs[CheckIDKey].AsInt64())
+	require.Equal(t, int64(http.StatusOK), attrs["http.response.status_code"].AsInt64())
+	require.Equal(t, int64(1), attrs[AttemptsKey].AsInt64())
+
+	// The API server receives the trace context.
+	carrier := propagation.HeaderCarrier(http.Header{"Traceparent": []string{traceparent}})
+	remote := trace.SpanContextFromContext(propagation.TraceContext{}.Extract(context.Background(), carrier))
+	require.True(t, remote.IsValid())
+	require.Equal(t, span.SpanContext.TraceID(), remote.TraceID())
+	require.Equal(t, span.SpanContext.SpanID(), remote.SpanID())
+}
+
+func TestInterceptorError(t *testing.T) {
+	handler := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("X-Request-Id", "req-1")
+		writeJSON(w, http.StatusNotFound, struct {
+			Msg string `json:"msg"`
+			Err string `json:"err"`
+		}{Msg: "check not found", Err: "no rows"})
+	})
+
+	c, exporter := newTestClient(t, handler)
+
+	_, err := c.GetCheck(context.Background(), 7)
+	require.ErrorIs(t, err, smapi.ErrNotFound)
+
+	spans := exporter.GetSpans()
+	require.Len(t, spans, 1)
+
+	span := spans[0]
+	require.Equal(t, codes.Error, span.Status.Code)
+	require.Len(t, span.Events, 1)
+
+	attrs := attributes(span)
+	require.Equal(t, int64(7), attrs[CheckIDKey].AsInt64())
+	require.Equal(t, "404", attrs["error.type"].AsString())
+	require.Equal(t, "check not found", attrs[ErrorMessageKey].AsString())
+	require.Equal(t, "no rows", attrs[ErrorDetailKey].AsString())
+	require.Equal(t, "req-1", attrs[ErrorRequestIDKey].AsString())
+}
+
+func TestInterceptorRetries(t *testing.T) {
+	var requests int
+
+	handler := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		requests++
+		if requests == 1 {
+			w.WriteHeader(http.StatusServiceUnavailable)
+			return
+		}
+
+		writeJSON(w, http.StatusOK, []sm.Probe{})
+	})
+
+	c, exporter := newTestClient(t, handler, smapi.WithRetryPolicy(smapi.RetryPolicy{
+		MaxAttempts: 3,
+		MinBackoff:  time.Millisecond,
+		MaxBackoff:  time.Millisecond,
+	}))
+
+	_, err := c.ListProbes(context.Background())
+	require.NoError(t, err)
+
+	spans := exporter.GetSpans()
+	require.Len(t, spans, 1)
+
+	attrs := attributes(spans[0])
+	require.Equal(t, int64(2), attrs[AttemptsKey].AsInt64())
+	require.Equal(t, int64(1), attrs["http.request.resend_count"].AsInt64())
+}